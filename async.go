@@ -0,0 +1,219 @@
+package elog
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultAsyncQueueSize 是 AsyncWriter 队列的默认容量
+const defaultAsyncQueueSize = 1024
+
+// AsyncWriter 把写入内容放入有界队列，由后台 goroutine 依次写入被包装的 Writer，缓慢的 Writer 不会阻塞日志输出。
+// 队列已满时新的写入会被丢弃并计入 Dropped；Len、HighWater、Dropped、LastError 可以用来观察队列的状态，
+// 挂在 logger 上时也会出现在 Log.Stats 的结果中。
+type AsyncWriter struct {
+	dropped uint64 // 需要 64 位对齐，必须放在第一个字段
+	high    int64  // 队列长度的最高水位，原子读写
+
+	w       io.Writer
+	queue   chan asyncItem
+	mu      sync.RWMutex // 保护 stopped，避免向已关闭的 queue 发送
+	stopped bool
+	errMu   sync.Mutex
+	lastErr error
+	done    chan struct{} // Close 时关闭，通知丢弃报告的 goroutine 退出
+
+	report      *Log          // 不为空时定期以 Warn 等级报告丢弃的条数
+	reportEvery time.Duration // 报告的间隔
+}
+
+var _ io.WriteCloser = &AsyncWriter{}
+
+type asyncItem struct {
+	data  []byte
+	flush chan struct{} // 不为空时表示一次 flush 请求，处理到这里时关闭它
+}
+
+// AsyncOption 用于配置 NewAsyncWriter 创建的 AsyncWriter
+type AsyncOption func(w *AsyncWriter)
+
+// AsyncDropReport 设置每隔 every 检查一次丢弃的条数，这段时间内有丢弃时以 Warn 等级输出到 l，如
+// "elog: dropped 120 entries in last 1m0s"。l 可以是写入这个 AsyncWriter 的 logger，报告本身也可能因为队列已满被丢弃。
+// every <= 0 时不报告。
+func AsyncDropReport(l *Log, every time.Duration) AsyncOption {
+	return func(w *AsyncWriter) {
+		w.report, w.reportEvery = l, every
+	}
+}
+
+// NewAsyncWriter 返回一个异步写入 w 的 AsyncWriter，size 为队列的容量，size <= 0 时使用默认值 1024。
+// 不再使用时应调用 Close（Log.Close 会自动调用），它会先写完队列中的内容，再关闭 w（标准输出和标准错误除外）。
+func NewAsyncWriter(w io.Writer, size int, options ...AsyncOption) *AsyncWriter {
+	if size <= 0 {
+		size = defaultAsyncQueueSize
+	}
+	a := &AsyncWriter{w: w, queue: make(chan asyncItem, size), done: make(chan struct{})}
+	for _, opt := range options {
+		opt(a)
+	}
+	go a.run()
+	if a.report != nil && a.reportEvery > 0 {
+		go a.reportDrops()
+	}
+	return a
+}
+
+// run 在后台依次写入队列中的内容，写入失败以及（没有设置 AsyncDropReport 时）队列已满丢弃日志会报告给诊断 logger
+func (a *AsyncWriter) run() {
+	var reported uint64 // 已经报告给诊断 logger 的丢弃条数
+	for item := range a.queue {
+		if item.flush != nil {
+			if f, ok := a.w.(Flusher); ok {
+				a.setErr(f.Flush())
+			}
+			close(item.flush)
+			continue
+		}
+		if _, err := a.w.Write(item.data); err != nil {
+			a.setErr(err)
+			internalf(ErrorLevel, "async writer: write failed: %v", err)
+		}
+		if dropped := atomic.LoadUint64(&a.dropped); dropped != reported && a.report == nil {
+			internalf(WarnLevel, "async writer: queue full, dropped %d entries", dropped-reported)
+			reported = dropped
+		}
+	}
+}
+
+// Write 复制 p 放入队列后立即返回，队列已满时丢弃 p，同样返回 len(p)；Close 之后返回 os.ErrClosed
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.stopped {
+		return 0, os.ErrClosed
+	}
+	select {
+	case a.queue <- asyncItem{data: append([]byte(nil), p...)}:
+		for n, high := int64(len(a.queue)), atomic.LoadInt64(&a.high); n > high; high = atomic.LoadInt64(&a.high) {
+			if atomic.CompareAndSwapInt64(&a.high, high, n) {
+				break
+			}
+		}
+	default:
+		atomic.AddUint64(&a.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Flush 阻塞直到之前写入的内容都已写入被包装的 Writer，被包装的 Writer 实现了 Flusher 时再调用它的 Flush
+func (a *AsyncWriter) Flush() error {
+	a.mu.RLock()
+	if a.stopped {
+		a.mu.RUnlock()
+		return nil
+	}
+	done := make(chan struct{})
+	a.queue <- asyncItem{flush: done}
+	a.mu.RUnlock()
+	<-done
+	return nil
+}
+
+// Close 写完队列中的内容后停止后台 goroutine 并关闭被包装的 Writer（标准输出和标准错误除外），重复调用直接返回 nil
+func (a *AsyncWriter) Close() error {
+	a.Flush()
+	a.mu.Lock()
+	if a.stopped {
+		a.mu.Unlock()
+		return nil
+	}
+	a.stopped = true
+	close(a.queue)
+	close(a.done)
+	a.mu.Unlock()
+	if w := realStdout(a.w); w == io.Writer(os.Stdout) || w == io.Writer(os.Stderr) {
+		return nil
+	}
+	if c, ok := a.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Len 返回队列中等待写入的条数
+func (a *AsyncWriter) Len() int {
+	return len(a.queue)
+}
+
+// Cap 返回队列的容量
+func (a *AsyncWriter) Cap() int {
+	return cap(a.queue)
+}
+
+// HighWater 返回队列长度曾经达到的最大值
+func (a *AsyncWriter) HighWater() int {
+	return int(atomic.LoadInt64(&a.high))
+}
+
+// Dropped 返回因队列已满而被丢弃的条数
+func (a *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// LastError 返回最近一次写入被包装的 Writer 时的错误，之后成功的写入不会清除它
+func (a *AsyncWriter) LastError() error {
+	a.errMu.Lock()
+	defer a.errMu.Unlock()
+	return a.lastErr
+}
+
+func (a *AsyncWriter) setErr(err error) {
+	if err == nil {
+		return
+	}
+	a.errMu.Lock()
+	a.lastErr = err
+	a.errMu.Unlock()
+}
+
+// AsyncStats 是 AsyncWriter 队列在某一时刻的状态
+type AsyncStats struct {
+	Len       int
+	Cap       int
+	HighWater int
+	Dropped   uint64
+	LastError error
+}
+
+// Stats 返回队列的当前状态
+func (a *AsyncWriter) Stats() AsyncStats {
+	return AsyncStats{
+		Len:       a.Len(),
+		Cap:       a.Cap(),
+		HighWater: a.HighWater(),
+		Dropped:   a.Dropped(),
+		LastError: a.LastError(),
+	}
+}
+
+// reportDrops 每隔 reportEvery 报告一次这段时间内丢弃的条数，Close 后退出
+func (a *AsyncWriter) reportDrops() {
+	ticker := time.NewTicker(a.reportEvery)
+	defer ticker.Stop()
+	var last uint64
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			n := a.Dropped()
+			if n > last {
+				a.report.Warnf("elog: dropped %d entries in last %v", n-last, a.reportEvery)
+			}
+			last = n
+		}
+	}
+}
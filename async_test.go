@@ -0,0 +1,114 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsyncWriterMetrics(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	a := NewAsyncWriter(w, 4)
+	l := New(InfoLevel, OOutput(a))
+
+	l.Info("first")
+	waitFor(t, func() bool { return a.Len() == 0 }) // 第一条已被取出，卡在 Writer 中
+	for i := 0; i < 7; i++ {
+		l.Info("queued")
+	}
+	if a.Len() != 4 || a.HighWater() != 4 || a.Dropped() != 3 {
+		t.Errorf("want len 4, high water 4, dropped 3, got %d, %d, %d", a.Len(), a.HighWater(), a.Dropped())
+	}
+	s := l.Stats()
+	if len(s.Async) != 1 || s.Async[0].Dropped != 3 || s.Async[0].Len != 4 || s.Async[0].Cap != 4 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if s.Counts[InfoLevel] != 8 {
+		t.Errorf("dropped entries are still counted as written, got %v", s.Counts)
+	}
+
+	close(w.release)
+	if err := a.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if a.Len() != 0 || a.HighWater() != 4 {
+		t.Errorf("flush should drain the queue but keep the high water mark, got %d, %d", a.Len(), a.HighWater())
+	}
+	w.mu.Lock()
+	got := w.buf.String()
+	w.mu.Unlock()
+	if want := "first\n" + strings.Repeat("queued\n", 4); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+
+	l.Close()
+	if _, err := a.Write([]byte("late\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write after close should fail, got %v", err)
+	}
+}
+
+func TestAsyncWriterLastError(t *testing.T) {
+	boom := errors.New("disk full")
+	a := NewAsyncWriter(failWriter{boom}, 0)
+	defer a.Close()
+	if a.Cap() != defaultAsyncQueueSize {
+		t.Errorf("want default capacity %d, got %d", defaultAsyncQueueSize, a.Cap())
+	}
+	a.Write([]byte("x\n"))
+	a.Flush()
+	if !errors.Is(a.LastError(), boom) {
+		t.Errorf("want %v, got %v", boom, a.LastError())
+	}
+}
+
+func TestAsyncDropReport(t *testing.T) {
+	var mu sync.Mutex
+	var report bytes.Buffer
+	reporter := New(WarnLevel, OOutput(&lockedWriter{mu: &mu, w: &report}), OFlag(Llevel))
+	w := &blockingWriter{release: make(chan struct{})}
+	a := NewAsyncWriter(w, 1, AsyncDropReport(reporter, 10*time.Millisecond))
+	a.Write([]byte("first\n"))
+	waitFor(t, func() bool { return a.Len() == 0 })
+	a.Write([]byte("queued\n"))
+	a.Write([]byte("dropped\n"))
+	a.Write([]byte("dropped\n"))
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return report.Len() > 0
+	})
+	close(w.release)
+	a.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := report.String(), "WARN elog: dropped 2 entries in last 10ms\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  *bytes.Buffer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// waitFor 等待 cond 成立，最多等待一秒
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
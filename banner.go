@@ -0,0 +1,115 @@
+package elog
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// OBanner 在 logger 创建后以 InfoLevel 输出一次启动信息，见 Banner。
+// 设置环境变量 ELOG_NOBANNER=1 可以关闭，便于在测试中禁用。
+func OBanner() LogOption {
+	return func(logger *Log) {
+		logger.banner = os.Getenv("ELOG_NOBANNER") == ""
+	}
+}
+
+// Banner 以 InfoLevel 输出一条多行的启动信息：程序名、版本、commit、Go 版本、pid、主机名以及 logger 的配置，
+// l 为 nil 时什么也不做
+func (l *Log) Banner() {
+	if l == nil {
+		return
+	}
+	l.outputBanner(defaultCallDepth + 1)
+}
+
+func (l *Log) outputBanner(calldepth int) {
+	if !l.atLeast(InfoLevel) {
+		return
+	}
+	program := filepath.Base(os.Args[0])
+	version, commit := "(unknown)", "(unknown)"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Path != "" {
+			program = info.Path
+		}
+		version = info.Main.Version
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				commit = s.Value
+			}
+		}
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "(unknown)"
+	}
+
+	if !l.rlock() {
+		l.reentered(InfoLevel, "startup banner")
+		return
+	}
+	config := "name=" + strconv.Quote(l.name) +
+		" level=" + strings.TrimSpace(levelOf(l.Level()).levelLabel) +
+		" flag=" + flagNames(l.flag) +
+		" prefix=" + strconv.Quote(l.prefix)
+	l.mu.RUnlock()
+
+	rows := [][2]string{
+		{"program", program},
+		{"version", version},
+		{"commit", commit},
+		{"go", runtime.Version()},
+		{"pid", strconv.Itoa(os.Getpid())},
+		{"hostname", hostname},
+		{"logger", config},
+	}
+	var sb strings.Builder
+	sb.WriteString("startup banner")
+	for _, row := range rows {
+		sb.WriteString("\n    ")
+		sb.WriteString(row[0])
+		sb.WriteString(strings.Repeat(" ", len("hostname")+2-len(row[0])))
+		sb.WriteString(row[1])
+	}
+	l.Out(calldepth, InfoLevel, sb.String())
+}
+
+var flagList = []struct {
+	flag int
+	name string
+}{
+	{Ldate, "Ldate"},
+	{Ltime, "Ltime"},
+	{Lmicroseconds, "Lmicroseconds"},
+	{LUTC, "LUTC"},
+	{Llongfile, "Llongfile"},
+	{Lshortfile, "Lshortfile"},
+	{Lmsgprefix, "Lmsgprefix"},
+	{Lmsgcolor, "Lmsgcolor"},
+	{Llevel, "Llevel"},
+	{LlevelLabelColor, "LlevelLabelColor"},
+	{LnameColor, "LnameColor"},
+	{Ldateiso, "Ldateiso"},
+	{Lweekday, "Lweekday"},
+	{Lfixedtime, "Lfixedtime"},
+	{Llinecolor, "Llinecolor"},
+	{Lshortlevel, "Lshortlevel"},
+}
+
+// flagNames 返回 flag 的可读形式，如 Ldate|Ltime|Llevel，没有设置任何 flag 时返回 0
+func flagNames(flag int) string {
+	var names []string
+	for _, f := range flagList {
+		if flag&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	if len(names) == 0 {
+		return "0"
+	}
+	return strings.Join(names, "|")
+}
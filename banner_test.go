@@ -0,0 +1,50 @@
+package elog
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBanner(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OName("api"), OFlag(Lshortfile|Llevel))
+	l.Banner()
+	got := b.String()
+	for _, want := range []string{
+		"INFO banner_test.go:15 startup banner\n",
+		"\n    go        " + runtime.Version() + "\n",
+		"\n    pid       " + strconv.Itoa(os.Getpid()) + "\n",
+		"\n    logger    name=\"api\" level=INFO flag=Lshortfile|Llevel prefix=\"\"\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("banner %q should contain %q", got, want)
+		}
+	}
+	if n := strings.Count(got, "\n"); n != 8 {
+		t.Errorf("expected 8 lines, got %d: %q", n, got)
+	}
+}
+
+func TestOBanner(t *testing.T) {
+	var b bytes.Buffer
+	New(InfoLevel, OOutput(&b), OFlag(Lshortfile), OBanner())
+	if !strings.HasPrefix(b.String(), "banner_test.go:34 startup banner\n") {
+		t.Errorf("OBanner should emit the banner on construction, got %q", b.String())
+	}
+
+	b.Reset()
+	New(WarnLevel, OOutput(&b), OBanner())
+	if b.Len() != 0 {
+		t.Errorf("banner is emitted at InfoLevel, got %q", b.String())
+	}
+
+	t.Setenv("ELOG_NOBANNER", "1")
+	New(InfoLevel, OOutput(&b), OBanner())
+	if b.Len() != 0 {
+		t.Errorf("ELOG_NOBANNER should disable the banner, got %q", b.String())
+	}
+}
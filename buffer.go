@@ -0,0 +1,38 @@
+package elog
+
+// DefaultMaxBuffer 是 OMaxBuffer 的默认值
+const DefaultMaxBuffer = 64 << 10
+
+// maxPooledFields 是 EntryBuilder 和 Scope 放回对象池时字段切片的最大容量，更大的切片交给 GC 回收
+const maxPooledFields = 64
+
+// OMaxBuffer 设置 logger 格式化日志时使用的缓冲区保留的最大容量，默认为 DefaultMaxBuffer。
+// 缓冲区会增长到输出过的最长的日志的大小，写入一条日志之后容量超过 n 时释放缓冲区，下一条日志重新分配，
+// 这样偶尔输出的一条很大的日志（如调试时打印的整个响应）不会让每个 logger 一直占用同样大小的内存。
+// n 为负数时不释放，适合持续输出大日志、希望避免反复分配的场景。
+func OMaxBuffer(n int) LogOption {
+	return func(logger *Log) {
+		logger.maxBuf = n
+	}
+}
+
+// shrinkBuffers 释放容量超过上限的缓冲区，包括去掉颜色的副本和各个 Encoder 输出的缓冲区。调用方需持有锁。
+func (l *Log) shrinkBuffers() {
+	limit := l.maxBuf
+	if limit == 0 {
+		limit = DefaultMaxBuffer
+	} else if limit < 0 {
+		return
+	}
+	if cap(l.buf) > limit {
+		l.buf = nil
+	}
+	if cap(l.plain) > limit {
+		l.plain = nil
+	}
+	for _, o := range l.encoded {
+		if cap(o.buf) > limit {
+			o.buf = nil
+		}
+	}
+}
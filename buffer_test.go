@@ -0,0 +1,72 @@
+package elog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestShrinkBuffer(t *testing.T) {
+	huge := strings.Repeat("x", 4<<20)
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(Llevel))
+	l.AddOutput(io.Discard, WithEncoder(JSONEncoder))
+
+	l.Info("warm up")
+	l.Info(huge)
+	if c := cap(l.buf); c > DefaultMaxBuffer {
+		t.Errorf("cap(buf) = %d after a huge message, want <= %d", c, DefaultMaxBuffer)
+	}
+	if c := cap(l.encoded[0].buf); c > DefaultMaxBuffer {
+		t.Errorf("cap(encoded buf) = %d after a huge message", c)
+	}
+	// 之后的日志回到平常的大小
+	for i := 0; i < 10; i++ {
+		l.Info("steady state")
+	}
+	if c := cap(l.buf); c == 0 || c > 1024 {
+		t.Errorf("steady state cap(buf) = %d", c)
+	}
+
+	// 负数表示不释放，Extend 继承上限
+	keep := l.Extend(OMaxBuffer(-1))
+	keep.Info(huge)
+	if c := cap(keep.buf); c < len(huge) {
+		t.Errorf("OMaxBuffer(-1): cap(buf) = %d, want >= %d", c, len(huge))
+	}
+	// Raw 同样使用并释放这个缓冲区
+	l.Raw(huge)
+	if c := cap(l.buf); c > DefaultMaxBuffer {
+		t.Errorf("Raw: cap(buf) = %d after a huge message", c)
+	}
+	small := New(InfoLevel, OOutput(io.Discard), OMaxBuffer(100)).Extend()
+	small.Info(strings.Repeat("y", 200))
+	if c := cap(small.buf); c > 100 {
+		t.Errorf("OMaxBuffer(100): cap(buf) = %d", c)
+	}
+
+	// FormatHeader 使用同一个缓冲区
+	var header []byte
+	l.SetPrefix(huge)
+	l.AddFlag(Lmsgprefix).FormatHeader(&header, l.clock(), InfoLevel, "", 0)
+	if !bytes.Contains(header, []byte("xxx")) || cap(l.buf) > DefaultMaxBuffer {
+		t.Errorf("FormatHeader: len(header) = %d, cap(buf) = %d", len(header), cap(l.buf))
+	}
+}
+
+func TestPoolOversizedFields(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	b := l.With()
+	for i := 0; i <= maxPooledFields; i++ {
+		b.Int("n", i)
+	}
+	b.Msg("many fields")
+	if b.l == nil {
+		t.Error("a builder with oversized fields should not be reset and pooled")
+	}
+	s := l.BeginScope(make([]Field, maxPooledFields+1)...)
+	s.End()
+	if s.fields != nil {
+		t.Error("a scope with oversized fields should drop them instead of pooling")
+	}
+}
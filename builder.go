@@ -0,0 +1,227 @@
+package elog
+
+import (
+	"sync"
+	"time"
+)
+
+// EntryBuilder 以链式调用的方式构造一条带字段的日志，通过 With 从对象池中获取：
+//
+//	l.With().Warn().Str("user", u).Int("attempt", n).Err(err).Msg("login failed")
+//
+// 未调用等级方法时以 InfoLevel 输出。等级未开启时等级方法返回 nil，之后的调用都是空操作，不会产生内存分配。
+// Msg 或 Msgf 输出日志后 EntryBuilder 会被放回对象池，不能再继续使用；
+// 以 elog_debug 标签编译时重复使用会 panic：
+//
+//	go test -tags elog_debug ./...
+type EntryBuilder struct {
+	l      *Log
+	level  logLevel
+	fields []Field
+	done   bool // 是否已经输出，只在 elog_debug 模式下使用
+}
+
+var builderPool = sync.Pool{
+	New: func() any {
+		return &EntryBuilder{fields: make([]Field, 0, 8)}
+	},
+}
+
+// With 返回一个从对象池中获取的 EntryBuilder
+func (l *Log) With() *EntryBuilder {
+	b := builderPool.Get().(*EntryBuilder)
+	b.l = l
+	b.level = InfoLevel
+	b.done = false
+	return b
+}
+
+// WithFields 返回一个带有 m 中字段的 EntryBuilder，字段按键排序，见 MapFields；之后追加的字段保持调用的顺序：
+//
+//	l.WithFields(map[string]any{"user": u, "ip": ip}).Warn().Msg("login failed")
+func (l *Log) WithFields(m map[string]any) *EntryBuilder {
+	return l.With().Fields(MapFields(m)...)
+}
+
+// release 清空 EntryBuilder 并放回对象池。elog_debug 模式下不放回对象池，以便发现输出后继续使用的错误；
+// 字段切片的容量超过 maxPooledFields 时也不放回。
+func (b *EntryBuilder) release() {
+	b.done = true
+	if builderChecks {
+		return
+	}
+	if cap(b.fields) > maxPooledFields {
+		return // 字段特别多时不放回对象池，避免之后每次取出都占用这么大的切片
+	}
+	for i := range b.fields {
+		b.fields[i] = Field{} // 不再持有字段值的引用
+	}
+	b.fields = b.fields[:0]
+	b.l = nil
+	builderPool.Put(b)
+}
+
+func (b *EntryBuilder) check() {
+	if builderChecks && b.done {
+		panic("elog: EntryBuilder used after Msg")
+	}
+}
+
+// Level 设置输出等级，等级未开启时释放 EntryBuilder 并返回 nil。
+// PanicLevel 和 FatalLevel 与 Panic、Fatal 方法一样，Msg 输出后会 panic 或退出进程，退出码由字段中的错误决定（见 OExitCodes）；
+// logger 被静默或这两个等级超出等级范围时不输出，但仍会 panic 或退出进程。
+func (b *EntryBuilder) Level(level logLevel) *EntryBuilder {
+	if b == nil {
+		return nil
+	}
+	b.check()
+	if !b.wanted(level) {
+		b.release()
+		return nil
+	}
+	b.level = level
+	return b
+}
+
+func (b *EntryBuilder) Trace() *EntryBuilder { return b.Level(TraceLevel) }
+func (b *EntryBuilder) Debug() *EntryBuilder { return b.Level(DebugLevel) }
+func (b *EntryBuilder) Info() *EntryBuilder  { return b.Level(InfoLevel) }
+func (b *EntryBuilder) Warn() *EntryBuilder  { return b.Level(WarnLevel) }
+func (b *EntryBuilder) Error() *EntryBuilder { return b.Level(ErrorLevel) }
+
+// Str 追加一个字符串字段
+func (b *EntryBuilder) Str(key, value string) *EntryBuilder {
+	return b.add(String(key, value))
+}
+
+// Int 追加一个整数字段
+func (b *EntryBuilder) Int(key string, value int) *EntryBuilder {
+	return b.add(Int(key, value))
+}
+
+// Int64 追加一个 int64 字段
+func (b *EntryBuilder) Int64(key string, value int64) *EntryBuilder {
+	return b.add(Int64(key, value))
+}
+
+// Float64 追加一个浮点数字段
+func (b *EntryBuilder) Float64(key string, value float64) *EntryBuilder {
+	return b.add(Float64(key, value))
+}
+
+// Bool 追加一个布尔字段
+func (b *EntryBuilder) Bool(key string, value bool) *EntryBuilder {
+	return b.add(Bool(key, value))
+}
+
+// Err 以 "error" 为键追加 err，err 为 nil 时忽略
+func (b *EntryBuilder) Err(err error) *EntryBuilder {
+	if err == nil {
+		return b
+	}
+	return b.add(Err(err))
+}
+
+// Dur 追加一个时长字段
+func (b *EntryBuilder) Dur(key string, value time.Duration) *EntryBuilder {
+	return b.add(Dur(key, value))
+}
+
+// Time 追加一个时间字段
+func (b *EntryBuilder) Time(key string, value time.Time) *EntryBuilder {
+	return b.add(Time(key, value))
+}
+
+// Any 追加一个任意类型的字段
+func (b *EntryBuilder) Any(key string, value any) *EntryBuilder {
+	return b.add(Any(key, value))
+}
+
+// Fields 追加通过 String、Int 等构造函数创建的字段，字段总是按追加的顺序输出
+func (b *EntryBuilder) Fields(fields ...Field) *EntryBuilder {
+	if b == nil {
+		return nil
+	}
+	b.check()
+	b.fields = append(b.fields, fields...)
+	return b
+}
+
+func (b *EntryBuilder) add(f Field) *EntryBuilder {
+	if b == nil {
+		return nil
+	}
+	b.check()
+	b.fields = append(b.fields, f)
+	return b
+}
+
+// Msg 输出日志并将 EntryBuilder 放回对象池
+func (b *EntryBuilder) Msg(msg string) {
+	if b == nil {
+		return
+	}
+	b.check()
+	b.emit(msg)
+}
+
+// Msgf 是 Msg 的格式化版本
+func (b *EntryBuilder) Msgf(format string, v ...any) {
+	if b == nil {
+		return
+	}
+	b.check()
+	msg, fs := sprintf(format, v)
+	b.fields = append(b.fields, fs...)
+	b.emit(msg)
+}
+
+// emit 只能被 Msg、Msgf 和 T 直接调用，以保证文件路径指向调用处
+func (b *EntryBuilder) emit(msg string) {
+	l, level := b.l, b.level
+	if !b.wanted(level) {
+		b.release()
+		return
+	}
+	if l.enabled(level) {
+		l.out(defaultCallDepth+1, level, msg, b.fields)
+	}
+	var errs []any
+	if level == FatalLevel {
+		errs = errorArgs(b.fields)
+	}
+	b.release()
+	switch level {
+	case PanicLevel:
+		l.beforePanic()
+		panic(l.panicValue(defaultCallDepth+1, msg, nil))
+	case FatalLevel:
+		l.beforeExit()
+		osExit(l.exitCode(errs))
+	}
+}
+
+// wanted 报告以 level 等级输出时是否有事要做：PanicLevel、FatalLevel 与 Panic、Fatal 方法一样只看最低等级，
+// 即使不输出也要 panic 或退出进程；其余等级看日志是否会被输出
+func (b *EntryBuilder) wanted(level logLevel) bool {
+	if level == PanicLevel || level == FatalLevel {
+		return b.l.atLeast(level)
+	}
+	return b.l.enabled(level)
+}
+
+// errorArgs 返回 fields 中的错误，交给 exitCode 按 Fatal 的参数决定退出码
+func errorArgs(fields []Field) []any {
+	var errs []any
+	for _, f := range fields {
+		if err, ok := f.Value.(error); ok && f.Kind == KindError {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// levelCompiled 报告 level 等级的日志是否被编译进来，见 elog_notrace 和 elog_nodebug 构建标签
+func levelCompiled(level logLevel) bool {
+	return (level != TraceLevel || traceCompiled) && (level != DebugLevel || debugCompiled)
+}
@@ -0,0 +1,6 @@
+//go:build elog_debug
+
+package elog
+
+// builderChecks 为 true 时 EntryBuilder 在 Msg 之后不会放回对象池，继续使用会 panic，见 builder_check_off.go
+const builderChecks = true
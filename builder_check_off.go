@@ -0,0 +1,6 @@
+//go:build !elog_debug
+
+package elog
+
+// builderChecks 为 false 时不检查 EntryBuilder 的误用，输出后直接放回对象池
+const builderChecks = false
@@ -0,0 +1,23 @@
+//go:build elog_debug
+
+// CI 以 elog_debug 构建标签运行全部测试（见 .github/workflows/test.yml），也可以单独运行：
+//
+//	go test -tags elog_debug -run EntryBuilderReuse .
+package elog
+
+import (
+	"io"
+	"testing"
+)
+
+func TestEntryBuilderReuse(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	e := l.With().Str("k", "v")
+	e.Msg("first")
+	defer func() {
+		if recover() == nil {
+			t.Error("reusing an EntryBuilder after Msg should panic")
+		}
+	}()
+	e.Str("k", "v")
+}
@@ -0,0 +1,111 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"testing"
+)
+
+func TestEntryBuilder(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Llevel|Lshortfile))
+	l.With().Warn().Str("user", "tom").Int("attempt", 3).Err(errors.New("bad password")).Err(nil).Any("ok", false).Msg("login failed")
+	want := `^WARN builder_test\.go:\d+ login failed user=tom attempt=3 error=bad password ok=false\n$`
+	if ok, _ := regexp.MatchString(want, b.String()); !ok {
+		t.Errorf("\n got:  %q\n want: %q", b.String(), want)
+	}
+
+	b.Reset()
+	l.With().Str("k", "v").Msgf("%d items", 2)
+	if ok, _ := regexp.MatchString(`^INFO builder_test\.go:\d+ 2 items k=v\n$`, b.String()); !ok {
+		t.Errorf("default level should be INFO, got %q", b.String())
+	}
+
+	b.Reset()
+	if e := l.With().Debug(); e != nil {
+		t.Errorf("disabled level should return nil, got %v", e)
+	}
+	l.With().Debug().Str("k", "v").Int("n", 1).Err(io.EOF).Msg("dropped")
+	l.With().Level(Discard).Msgf("dropped")
+	if b.Len() != 0 {
+		t.Errorf("disabled level should not write, got %q", b.String())
+	}
+
+	// 放回对象池的 EntryBuilder 不应保留上一次的字段
+	l.With().Str("a", "1").Msg("first")
+	b.Reset()
+	l.With().Msg("second")
+	if ok, _ := regexp.MatchString(`second\n$`, b.String()); !ok {
+		t.Errorf("pooled builder kept old fields, got %q", b.String())
+	}
+}
+
+func TestEntryBuilderPanicFatal(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected panic %q, got %v", "boom", r)
+			}
+		}()
+		l.With().Level(PanicLevel).Msg("boom")
+	}()
+
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	code := -1
+	osExit = func(c int) { code = c }
+	l.With().Level(FatalLevel).Str("k", "v").Msg("bye")
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if got, want := b.String(), "boom\nbye k=v\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+
+	// 与 Fatal 一样按错误决定退出码；静默或超出等级范围时不输出，但仍然退出或 panic
+	b.Reset()
+	l = New(InfoLevel, OOutput(&b), OExitCodes(), OLevelRange(InfoLevel, ErrorLevel))
+	l.With().Level(FatalLevel).Err(codeError{3}).Msg("bye")
+	if code != 3 {
+		t.Errorf("expected exit code 3, got %d", code)
+	}
+	l.SetLevelRange(InfoLevel, FatalLevel).Mute()
+	code = -1
+	l.With().Level(FatalLevel).Msg("bye")
+	if code != 1 {
+		t.Errorf("a muted logger should still exit, got code %d", code)
+	}
+	if v := recovered(func() { l.With().Level(PanicLevel).Msg("boom") }); v != "boom" {
+		t.Errorf("a muted logger should still panic, got %v", v)
+	}
+	if b.Len() != 0 {
+		t.Errorf("nothing should be written, got %q", b.String())
+	}
+	code = -1
+	l.SetLevel(FatalLevel + 1) // 最低等级高于 FatalLevel 时与 Fatal 一样不退出
+	l.With().Level(FatalLevel).Msg("bye")
+	if code != -1 {
+		t.Errorf("should not exit above the minimum level, got code %d", code)
+	}
+}
+
+func BenchmarkEntryBuilder(b *testing.B) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	err := errors.New("bad password")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.With().Warn().Str("user", "tom").Str("ip", "127.0.0.1").Int("attempt", 3).Int("code", 401).Err(err).Msg("login failed")
+	}
+}
+
+func BenchmarkEntryBuilderDisabled(b *testing.B) {
+	l := New(ErrorLevel, OOutput(io.Discard))
+	err := errors.New("bad password")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.With().Warn().Str("user", "tom").Str("ip", "127.0.0.1").Int("attempt", 3).Int("code", 401).Err(err).Msg("login failed")
+	}
+}
@@ -0,0 +1,99 @@
+package elog
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// CallbackPanicError 是用户提供的回调在输出日志的过程中 panic 时交给错误处理函数（见 OErrorHandler）的错误。
+// 回调的 panic 不会让进程崩溃，日志会继续以默认的方式输出：
+//
+//   - 过滤器 panic 时视为不抑制这条日志
+//   - 中间件 panic 时丢弃所有中间件对日志条目的修改，按原样输出
+//   - Encoder panic 时该输出改为写入文本格式的日志
+//   - FilterWriter 的断言 panic 时视为写入
+//   - ContextExtractor panic 时忽略它提取的字段
+//   - Reporter panic 时跳过这条日志，继续处理之后的日志
+//   - OnLevelChange 的回调 panic 时继续调用其余的回调
+type CallbackPanicError struct {
+	Callback string // 发生 panic 的回调种类，如 "middleware"、"encoder"
+	Value    any    // recover 得到的值
+	Stack    string // 发生 panic 处的调用栈
+}
+
+func (e *CallbackPanicError) Error() string {
+	return fmt.Sprintf("elog: %s panicked: %v", e.Callback, e.Value)
+}
+
+// Unwrap 在 Value 是 error 时返回它
+func (e *CallbackPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverCallback 必须直接以 defer 的方式调用，recover 回调的 panic 并保存到 *err
+func recoverCallback(callback string, err *error) {
+	if v := recover(); v != nil {
+		*err = &CallbackPanicError{Callback: callback, Value: v, Stack: string(debug.Stack())}
+	}
+}
+
+// callbackFailed 把回调的 panic 交给错误处理函数，没有设置错误处理函数时报告给诊断 logger。不能持有锁调用。
+func (l *Log) callbackFailed(err error) {
+	l.mu.RLock()
+	onError := l.onError
+	l.mu.RUnlock()
+	if onError != nil {
+		onError(err)
+		return
+	}
+	if pe, ok := err.(*CallbackPanicError); ok {
+		internalf(ErrorLevel, "%v\n%s", pe, pe.Stack)
+		return
+	}
+	internalf(ErrorLevel, "%v", err)
+}
+
+// notePanic 记录写入过程中回调的 panic，只保留第一个，由 write 返回给调用方。调用方需持有锁。
+func (l *Log) notePanic(err error) {
+	if l.panicked == nil {
+		l.panicked = err
+	}
+}
+
+func callFilter(fn FilterFunc, level logLevel, msg string) (ok bool, err error) {
+	defer recoverCallback("filter", &err)
+	return fn(level, msg), nil
+}
+
+func callMiddleware(mw Middleware, e *Entry) (ok bool, err error) {
+	defer recoverCallback("middleware", &err)
+	return mw(e), nil
+}
+
+func callEncoder(enc Encoder, buf []byte, e *Entry, flag int) (out []byte, err error) {
+	defer recoverCallback("encoder", &err)
+	return enc.Encode(buf, e, flag), nil
+}
+
+func callPredicate(pred func(EntryMeta) bool, meta EntryMeta) (ok bool, err error) {
+	defer recoverCallback("filter writer", &err)
+	return pred(meta), nil
+}
+
+func callExtractor(extract ContextExtractor, c ctxLogger) (fields []Field, err error) {
+	defer recoverCallback("context extractor", &err)
+	return extract(c.ctx), nil
+}
+
+func callReporter(r Reporter, e Entry) (err error) {
+	defer recoverCallback("reporter", &err)
+	r.Report(e)
+	return nil
+}
+
+func callLevelHook(fn func(old, new logLevel), old, new logLevel) (err error) {
+	defer recoverCallback("level change callback", &err)
+	fn(old, new)
+	return nil
+}
@@ -0,0 +1,158 @@
+package elog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordPanics 返回收集回调 panic 的错误处理函数
+func recordPanics(t *testing.T) (handler func(error), panics func() []*CallbackPanicError) {
+	var (
+		mu  sync.Mutex
+		got []*CallbackPanicError
+	)
+	handler = func(err error) {
+		var pe *CallbackPanicError
+		if !errors.As(err, &pe) {
+			t.Errorf("unexpected error %v", err)
+			return
+		}
+		mu.Lock()
+		got = append(got, pe)
+		mu.Unlock()
+	}
+	panics = func() []*CallbackPanicError {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*CallbackPanicError(nil), got...)
+	}
+	return
+}
+
+func expectPanic(t *testing.T, panics []*CallbackPanicError, callback string) {
+	t.Helper()
+	if len(panics) != 1 {
+		t.Fatalf("want 1 panic, got %d", len(panics))
+	}
+	pe := panics[0]
+	if pe.Callback != callback || pe.Value != "boom" || !strings.Contains(pe.Stack, "callback_test.go") {
+		t.Errorf("unexpected panic %q %v\n%s", pe.Callback, pe.Value, pe.Stack)
+	}
+	if want := "elog: " + callback + " panicked: boom"; pe.Error() != want {
+		t.Errorf("want %q, got %q", want, pe.Error())
+	}
+}
+
+func TestPanickingMiddleware(t *testing.T) {
+	var b bytes.Buffer
+	handler, panics := recordPanics(t)
+	l := New(InfoLevel, OOutput(&b), OFlag(Llevel), OErrorHandler(handler))
+	l.Use(func(e *Entry) bool {
+		e.Msg = "changed"
+		return true
+	}, func(e *Entry) bool { panic("boom") })
+
+	if err := l.Out(0, InfoLevel, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "INFO hello\n" {
+		t.Errorf("changes made before the panic should be discarded, got %q", got)
+	}
+	expectPanic(t, panics(), "middleware")
+}
+
+func TestPanickingFilter(t *testing.T) {
+	var b bytes.Buffer
+	handler, panics := recordPanics(t)
+	l := New(InfoLevel, OOutput(&b), OFlag(0), OErrorHandler(handler))
+	l.AddFilter(func(level logLevel, msg string) bool { panic("boom") })
+
+	l.Info("hello")
+	if got := b.String(); got != "hello\n" || l.Suppressed() != 0 {
+		t.Errorf("a panicking filter should not suppress the entry, got %q", got)
+	}
+	expectPanic(t, panics(), "filter")
+}
+
+type panicEncoder struct{}
+
+func (panicEncoder) Encode(buf []byte, e *Entry, flag int) []byte {
+	buf = append(buf, "partial"...)
+	panic("boom")
+}
+
+func TestPanickingEncoder(t *testing.T) {
+	var text, encoded bytes.Buffer
+	handler, panics := recordPanics(t)
+	l := New(InfoLevel, OOutput(&text), OFlag(Llevel), OErrorHandler(handler))
+	l.AddOutput(&encoded, WithEncoder(panicEncoder{}))
+
+	if err := l.Out(0, WarnLevel, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if text.String() != "WARN hello\n" || encoded.String() != "WARN hello\n" {
+		t.Errorf("the output should fall back to text, got %q and %q", text.String(), encoded.String())
+	}
+	expectPanic(t, panics(), "encoder")
+	if l.WriteErrors() != 0 {
+		t.Error("a recovered panic is not a write error")
+	}
+}
+
+func TestPanickingFilterWriter(t *testing.T) {
+	var b bytes.Buffer
+	handler, panics := recordPanics(t)
+	l := New(InfoLevel, OOutput(FilterWriter(&b, func(EntryMeta) bool { panic("boom") })), OFlag(0), OErrorHandler(handler))
+
+	l.Info("hello")
+	if got := b.String(); got != "hello\n" {
+		t.Errorf("a panicking predicate should let the entry through, got %q", got)
+	}
+	expectPanic(t, panics(), "filter writer")
+}
+
+func TestPanickingContextExtractor(t *testing.T) {
+	var b bytes.Buffer
+	handler, panics := recordPanics(t)
+	l := New(InfoLevel, OOutput(&b), OFlag(0), OErrorHandler(handler))
+	l.AddContextExtractor(func(ctx context.Context) []Field { panic("boom") })
+
+	l.Ctx(context.Background()).Info("hello")
+	if got := b.String(); got != "hello\n" {
+		t.Errorf("want the entry without fields, got %q", got)
+	}
+	expectPanic(t, panics(), "context extractor")
+}
+
+type panicReporter struct {
+	slowReporter
+}
+
+func (r *panicReporter) Report(e Entry) {
+	if e.Msg == "boom" {
+		panic("boom")
+	}
+	r.slowReporter.Report(e)
+}
+
+func TestPanickingReporter(t *testing.T) {
+	var b bytes.Buffer
+	handler, panics := recordPanics(t)
+	r := &panicReporter{}
+	l := New(InfoLevel, OOutput(&b), OFlag(0), OErrorHandler(handler)).SetReporter(r, ErrorLevel)
+
+	l.Error("boom")
+	l.Error("after")
+	l.SetReporter(nil, ErrorLevel) // 等待队列处理完毕
+	if got := b.String(); got != "boom\nafter\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+	if len(r.entries) != 1 || r.entries[0] != "after" {
+		t.Errorf("the reporter should keep running after a panic, got %q", r.entries)
+	}
+	expectPanic(t, panics(), "reporter")
+}
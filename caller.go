@@ -0,0 +1,47 @@
+package elog
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// callerCacheSize 是 Caller 缓存的最大条目数，调用点超过这个数量后新的调用点不再缓存
+const callerCacheSize = 4096
+
+// callerCache 以程序计数器为键缓存解析出的文件路径和行号，同一个调用点重复输出日志时不必再解析帧信息
+var callerCache struct {
+	m sync.Map // uintptr -> callerInfo
+	n int32
+}
+
+type callerInfo struct {
+	file string
+	line int
+	fn   string
+}
+
+// caller 返回调用栈上第 skip 层（与 runtime.Caller 的 skip 含义相同）的文件路径、行号和完整的函数名。
+// runtime.Caller 同样只根据一个程序计数器解析帧信息，因此按程序计数器缓存的结果与它完全一致，内联的函数也不例外，
+// 函数名为调用点所在的最内层（可能被内联的）函数。
+func caller(skip int) (file string, line int, fn string, ok bool) {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) < 1 { // 跳过 runtime.Callers 和 caller 本身
+		return "", 0, "", false
+	}
+	pc := pcs[0]
+	if v, hit := callerCache.m.Load(pc); hit {
+		c := v.(callerInfo)
+		return c.file, c.line, c.fn, true
+	}
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	if frame.PC == 0 {
+		return "", 0, "", false
+	}
+	if atomic.LoadInt32(&callerCache.n) < callerCacheSize {
+		if _, loaded := callerCache.m.LoadOrStore(pc, callerInfo{frame.File, frame.Line, frame.Function}); !loaded {
+			atomic.AddInt32(&callerCache.n, 1)
+		}
+	}
+	return frame.File, frame.Line, frame.Function, true
+}
@@ -0,0 +1,91 @@
+package elog
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// inlinedCaller 足够简单，会被编译器内联，返回的应是调用它的那一行
+func inlinedCaller() (string, int, string, bool) { return caller(1) }
+
+func TestCallerCache(t *testing.T) {
+	for i := 0; i < 3; i++ { // 第一次解析，之后命中缓存
+		file, line, fn, ok := caller(0)
+		pc, wantFile, wantLine, _ := runtime.Caller(0)
+		wantFn := runtime.FuncForPC(pc).Name()
+		if !ok || file != wantFile || line != wantLine-1 || fn != wantFn {
+			t.Errorf("round %d: got %s:%d %s, want %s:%d %s", i, file, line, fn, wantFile, wantLine-1, wantFn)
+		}
+
+		file, line, fn, _ = inlinedCaller()
+		_, _, wantLine, _ = runtime.Caller(0)
+		if file != wantFile || line != wantLine-1 || fn != wantFn {
+			t.Errorf("inlined round %d: got %s:%d %s, want %s:%d %s", i, file, line, fn, wantFile, wantLine-1, wantFn)
+		}
+	}
+	if _, _, _, ok := caller(1000); ok {
+		t.Error("caller beyond the stack should not be ok")
+	}
+}
+
+func TestCallerCacheBounded(t *testing.T) {
+	n := atomic.LoadInt32(&callerCache.n)
+	defer atomic.StoreInt32(&callerCache.n, n)
+	atomic.StoreInt32(&callerCache.n, callerCacheSize)
+	if file, _, _, ok := caller(0); !ok || file == "" {
+		t.Fatal("caller should still resolve when the cache is full")
+	}
+	if got := atomic.LoadInt32(&callerCache.n); got != callerCacheSize {
+		t.Errorf("cache should not grow past its size, got %d", got)
+	}
+}
+
+// 用户自己的包装函数，配合 OCallerSkip(1) 使用
+func wrapInfo(l *Log, v ...any) { l.Info(v...) }
+func wrapPkgInfo(v ...any)      { Info(v...) }
+
+func TestCallerDepth(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lshortfile))
+	wrapped := l.Extend(OCallerSkip(1))
+	defer SetDefault(nil)
+
+	// next 返回调用它的下一行的行号
+	next := func() int { _, _, line, _ := runtime.Caller(1); return line + 1 }
+	check := func(name string, want int) {
+		t.Helper()
+		if got, w := b.String(), "caller_test.go:"+strconv.Itoa(want)+" "+name+"\n"; got != w {
+			t.Errorf("%s:\n got:  %q\n want: %q", name, got, w)
+		}
+		b.Reset()
+	}
+
+	line := next()
+	l.Info("method")
+	check("method", line)
+
+	line = next()
+	l.With().Msg("builder")
+	check("builder", line)
+
+	SetDefault(l)
+	line = next()
+	Info("package")
+	check("package", line)
+
+	line = next()
+	wrapInfo(wrapped, "wrapper")
+	check("wrapper", line)
+
+	SetDefault(wrapped)
+	line = next()
+	wrapPkgInfo("package wrapper")
+	check("package wrapper", line)
+
+	if SetDefault(nil); Default() != std {
+		t.Error("SetDefault(nil) should restore the built-in logger")
+	}
+}
@@ -0,0 +1,82 @@
+package elog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// capturing 非 0 表示 CaptureStdout 正在捕获标准输出，原子读写
+	capturing int32
+	// capturedOnce 非 0 表示调用过 CaptureStdout，没有调用过时 realStdout 不必查找 capturePipes，原子读写
+	capturedOnce int32
+	// capturePipes 记录 CaptureStdout 替换 os.Stdout 使用的管道（写入端）到原来的标准输出的映射。恢复之后仍然保留，
+	// 使捕获期间以 os.Stdout 创建的 logger 在恢复之后继续写入原来的标准输出，而不是已经关闭的管道。
+	capturePipes sync.Map
+)
+
+// CaptureStdout 把 os.Stdout 替换为一个管道，在后台 goroutine 中逐行读取写入的内容，每一行以 level 等级输出到 l，
+// 用于把大量使用 fmt.Println 调试的代码逐步迁移到日志，而不必一次修改所有调用。restore 恢复原来的 os.Stdout，
+// 并等待已经写入的内容全部输出（最后不完整的一行也会输出）之后返回，可以多次调用。
+//
+//	restore, err := elog.CaptureStdout(l, elog.DebugLevel)
+//	if err != nil {
+//		return err
+//	}
+//	defer restore()
+//
+// 超过 64 KiB 的行按 64 KiB 拆分为多条日志，见 MaxLineSize。
+// elog 的 logger 写往替换后的 os.Stdout 的内容（例如捕获期间以 OOutput(os.Stdout) 创建的 logger，或者 Plain）
+// 直接写入原来的标准输出，不会被捕获，因此 l 本身输出到标准输出也不会循环或死锁；恢复之后这些 logger 也照常输出。
+//
+// 只有通过 os.Stdout 变量的写入会被捕获，子进程和 cgo 代码直接写入的文件描述符 1 不受影响。
+// 替换 os.Stdout 与其他 goroutine 读取它并不同步，应在程序启动时或测试中调用。同一时间只能有一个捕获，重复调用返回错误。
+func CaptureStdout(l *Log, level logLevel) (restore func(), err error) {
+	if !atomic.CompareAndSwapInt32(&capturing, 0, 1) {
+		return nil, errors.New("elog: stdout is already captured")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		atomic.StoreInt32(&capturing, 0)
+		return nil, err
+	}
+	orig := os.Stdout
+	capturePipes.Store(w, orig)
+	atomic.StoreInt32(&capturedOnce, 1)
+	os.Stdout = w
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lw := l.WriterLevel(level, SplitLines(true))
+		_, _ = io.Copy(lw, r)
+		_ = lw.Close()
+		_ = r.Close()
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			os.Stdout = orig
+			_ = w.Close()
+			<-done
+			atomic.StoreInt32(&capturing, 0)
+		})
+	}, nil
+}
+
+// realStdout 把 CaptureStdout 使用的管道换成原来的标准输出，其余的 w 原样返回
+func realStdout(w io.Writer) io.Writer {
+	if atomic.LoadInt32(&capturedOnce) == 0 {
+		return w
+	}
+	if f, ok := w.(*os.File); ok {
+		if orig, ok := capturePipes.Load(f); ok {
+			return orig.(*os.File)
+		}
+	}
+	return w
+}
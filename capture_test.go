@@ -0,0 +1,79 @@
+package elog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCaptureStdout(t *testing.T) {
+	skipCompiledOut(t, DebugLevel)
+	var buf bytes.Buffer
+	l := New(DebugLevel, OOutput(&buf), OFlag(Llevel))
+	orig := os.Stdout
+	restore, err := CaptureStdout(l, DebugLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CaptureStdout(l, InfoLevel); err == nil {
+		t.Error("a second capture should fail")
+	}
+	fmt.Println("hello", 42)
+	fmt.Print("no newline")
+	restore()
+	restore() // 可以多次调用
+	if os.Stdout != orig {
+		t.Fatal("restore should put the original stdout back")
+	}
+	if got, want := buf.String(), "DEBUG hello 42\nDEBUG no newline\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// 恢复之后可以再次捕获；超长的行按 64 KiB 拆分，内容不丢失
+	buf.Reset()
+	restore, err = CaptureStdout(l, InfoLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat("z", 3*defaultMaxLineSize/2)
+	fmt.Println(long)
+	restore()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || strings.TrimPrefix(lines[0], "INFO ")+strings.TrimPrefix(lines[1], "INFO ") != long {
+		t.Errorf("long line: got %d lines, %d bytes", len(lines), buf.Len())
+	}
+}
+
+func TestCaptureStdoutSelf(t *testing.T) {
+	real, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = real
+	defer func() { os.Stdout = orig }()
+
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}), OFlag(Llevel))
+	restore, err := CaptureStdout(l, WarnLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// l 本身输出到 os.Stdout（此时是管道）：写入转到原来的标准输出，不会被再次捕获而循环或死锁
+	l.SetOutput(os.Stdout)
+	fmt.Println("from fmt")
+	l.Plain("plain")
+	restore()
+	l.Warn("after restore") // 恢复之后管道已经关闭，仍然写入原来的标准输出
+
+	b, err := os.ReadFile(real.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Plain 与后台 goroutine 输出的先后顺序不确定
+	if got := string(b); len(got) != len("plain\nWARN from fmt\nWARN after restore\n") ||
+		!strings.Contains(got, "plain\n") || !strings.Contains(got, "WARN from fmt\n") || !strings.HasSuffix(got, "WARN after restore\n") {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,64 @@
+package elog
+
+import "strings"
+
+// Check 在 err 不为 nil 时以 ErrorLevel 输出 "msg: err" 并返回 true，err 为 nil 时直接返回 false：
+//
+//	if l.Check(f.Close(), "closing file") {
+//		return
+//	}
+func (l *Log) Check(err error, msg ...any) bool {
+	if err == nil {
+		return false
+	}
+	if l.enabled(ErrorLevel) {
+		l.Out(defaultCallDepth, ErrorLevel, errMsg(err, l.sprint(msg)))
+	}
+	return true
+}
+
+// Checkf 是 Check 的格式化版本
+func (l *Log) Checkf(err error, format string, v ...any) bool {
+	if err == nil {
+		return false
+	}
+	if l.enabled(ErrorLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, ErrorLevel, errMsg(err, msg), fs)
+	}
+	return true
+}
+
+// Must 在 err 不为 nil 时以 FatalLevel 输出 "msg: err" 并退出进程，err 为 nil 时什么也不做：
+//
+//	l.Must(loadConfig(), "loading config")
+//
+// 与 Fatal 一样，l 为 nil 时既不输出也不退出进程。
+func (l *Log) Must(err error, msg ...any) {
+	if err == nil || l == nil {
+		return
+	}
+	l.Out(defaultCallDepth, FatalLevel, errMsg(err, l.sprint(msg)))
+	l.beforeExit()
+	osExit(l.exitCode([]any{err}))
+}
+
+// Mustf 是 Must 的格式化版本
+func (l *Log) Mustf(err error, format string, v ...any) {
+	if err == nil || l == nil {
+		return
+	}
+	msg, fs := sprintf(format, v)
+	l.out(defaultCallDepth, FatalLevel, errMsg(err, msg), fs)
+	l.beforeExit()
+	osExit(l.exitCode([]any{err}))
+}
+
+// errMsg 拼接 "msg: err"，msg 为空时只有 err
+func errMsg(err error, msg string) string {
+	msg = strings.TrimSuffix(msg, "\n")
+	if msg == "" {
+		return err.Error()
+	}
+	return msg + ": " + err.Error()
+}
@@ -0,0 +1,61 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Llevel|Lshortfile))
+	if l.Check(nil, "closing file") || l.Checkf(nil, "closing %s", "file") {
+		t.Error("Check should return false for nil")
+	}
+	if b.Len() != 0 {
+		t.Errorf("nil error should not write, got %q", b.String())
+	}
+
+	err := errors.New("disk full")
+	if !l.Check(err, "closing", "file") || !l.Checkf(err, "closing %s", "file") || !l.Check(err) {
+		t.Error("Check should return true for non-nil")
+	}
+	pattern := `^ERROR check_test\.go:\d+ closing file: disk full\n` +
+		`ERROR check_test\.go:\d+ closing file: disk full\n` +
+		`ERROR check_test\.go:\d+ disk full\n$`
+	if ok, _ := regexp.MatchString(pattern, b.String()); !ok {
+		t.Errorf("\n got:     %q\n pattern: %q", b.String(), pattern)
+	}
+
+	b.Reset()
+	if !l.SetLevel(FatalLevel).Check(err) || b.Len() != 0 {
+		t.Errorf("Check should return true without writing when ErrorLevel is disabled, got %q", b.String())
+	}
+}
+
+func TestMust(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Llevel|Lshortfile))
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	exits := 0
+	osExit = func(int) { exits++ }
+
+	l.Must(nil, "loading config")
+	l.Mustf(nil, "loading %s", "config")
+	if exits != 0 || b.Len() != 0 {
+		t.Errorf("nil error should neither exit nor write, got %d exits and %q", exits, b.String())
+	}
+
+	err := errors.New("no such file")
+	l.Must(err, "loading config")
+	l.Mustf(err, "loading %s", "config")
+	if exits != 2 {
+		t.Errorf("expected 2 exits, got %d", exits)
+	}
+	pattern := `^FATAL check_test\.go:\d+ loading config: no such file\n` +
+		`FATAL check_test\.go:\d+ loading config: no such file\n$`
+	if ok, _ := regexp.MatchString(pattern, b.String()); !ok {
+		t.Errorf("\n got:     %q\n pattern: %q", b.String(), pattern)
+	}
+}
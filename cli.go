@@ -0,0 +1,119 @@
+package elog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// NewCLI 返回适合命令行程序的 logger：输出到标准错误，不带日期、时间和文件路径，
+// 只有 Warn 及以上等级带彩色的等级标签，Info 等日志只输出消息本身；Fatal 系列方法按 OExitCodes 决定退出码。
+// 命令的正常输出使用 Plain 写到标准输出，不带任何修饰：
+//
+//	cli := elog.NewCLI()
+//	cli.Plain("created", name)           // 标准输出：created app
+//	cli.Info("using config", path)       // 标准错误：using config ~/.app.yaml
+//	cli.Warn("flag --old is deprecated") // 标准错误：WARN flag --old is deprecated
+//	cli.Fatal("build failed:", err)      // 标准错误：FATAL build failed: ...，以 err 的退出码退出
+//
+// 需要把 cobra 等框架自己输出的消息交给 logger 时，可以把 WriterLevel 返回的 Writer 传给它们，
+// 如 cmd.SetErr(cli.WriterLevel(elog.ErrorLevel))。options 在默认设置之后应用，可以覆盖它们。
+func NewCLI(options ...LogOption) *Log {
+	defaults := []LogOption{OName("cli"), OFlag(Llevel | LlevelLabelColor), OLevelLabelFrom(WarnLevel), OExitCodes()}
+	return New(InfoLevel, append(defaults, options...)...)
+}
+
+// OLevelLabelFrom 设置了 Llevel 时只有 level 及以上等级的日志带等级标签，如 NewCLI 中 Info 只输出消息、Warn 以上带标签。
+// 只影响文本格式的输出，审计日志总是带 AUDIT 标签。
+func OLevelLabelFrom(level logLevel) LogOption {
+	return func(logger *Log) {
+		logger.labelFrom = level
+	}
+}
+
+// ExitCoder 由携带进程退出码的错误实现，如 *exec.ExitError
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// OExitCodes 使 Fatal 系列方法和 Must 按参数决定退出码：参数中第一个包含 ExitCoder 的 error（通过 errors.As 查找）
+// 的 ExitCode()，不在 1 到 125 之间时（如 *exec.ExitError 在子进程被信号终止时的 -1）使用 1；没有这样的参数时为 1。
+// 未设置时总是以 1 退出。
+func OExitCodes() LogOption {
+	return func(logger *Log) {
+		logger.exitCodes = true
+	}
+}
+
+// exitCode 返回 Fatal 系列方法以 v 为参数时的退出码
+func (l *Log) exitCode(v []any) int {
+	if l == nil {
+		return 1
+	}
+	// exitCodes 只由 OExitCodes 在 New、Extend 中设置，读取时不加锁，在 Writer 中调用 Fatal 也不会死锁
+	if !l.exitCodes {
+		return 1
+	}
+	for _, a := range v {
+		err, ok := a.(error)
+		if !ok {
+			continue
+		}
+		var ec ExitCoder
+		if errors.As(err, &ec) {
+			if code := ec.ExitCode(); code >= 1 && code <= 125 {
+				return code
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// OStdout 设置 Plain 的输出，默认为标准输出
+func OStdout(w io.Writer) LogOption {
+	return func(logger *Log) {
+		logger.stdout = w
+	}
+}
+
+// Plain 把 v 以 fmt.Sprintln 的格式原样写到标准输出（见 OStdout），不带任何头部、颜色和字段，
+// 用于命令的正常输出，如供管道中下一个命令读取的结果。它不受日志等级和 Mute 影响，也不计入 Counts，
+// 写入失败时与普通日志一样交给错误处理函数。
+func (l *Log) Plain(v ...any) {
+	l.plainOut(fmt.Sprintln(v...))
+}
+
+// Plainf 是 Plain 的格式化版本，末尾没有换行符时补一个
+func (l *Log) Plainf(format string, v ...any) {
+	s, _ := sprintf(format, v)
+	if len(s) == 0 || s[len(s)-1] != '\n' {
+		s += "\n"
+	}
+	l.plainOut(s)
+}
+
+func (l *Log) plainOut(s string) {
+	if l == nil {
+		return
+	}
+	if !l.lock() {
+		l.dropReentered("Plain output", s)
+		return
+	}
+	atomic.StoreUint32(&l.writing, 1)
+	w := l.stdout
+	if w == nil {
+		w = os.Stdout
+	}
+	_, err := io.WriteString(realStdout(w), s)
+	onError := l.onError
+	atomic.StoreUint32(&l.writing, 0)
+	l.mu.Unlock()
+	if err != nil {
+		atomic.AddUint64(&l.writeErrors, 1)
+		l.writeFailed(onError, err)
+	}
+}
@@ -0,0 +1,80 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestNewCLI(t *testing.T) {
+	defer func(v bool) { noColor = v }(noColor)
+	noColor = false
+	var stdout, stderr bytes.Buffer
+	cli := NewCLI(OOutput(&stderr), OStdout(&stdout))
+	cli.Plain("created", "app")
+	cli.Plainf("%d files", 3)
+	cli.Info("using config", "~/.app.yaml")
+	cli.Debug("hidden")
+	cli.Warn("flag --old is deprecated")
+	cli.Errorf("cannot open %s", "x.txt")
+	cli.Audit("done")
+
+	if got, want := stdout.String(), "created app\n3 files\n"; got != want {
+		t.Errorf("stdout:\n got:  %q\n want: %q", got, want)
+	}
+	want := "using config ~/.app.yaml\n" +
+		"\x1b[0;30;43m WARN  \x1b[0m flag --old is deprecated\n" +
+		"\x1b[1;37;41m ERROR \x1b[0m cannot open x.txt\n" +
+		levelMap[AuditLevel].levelLabelColor + _AuditLabel + color_ + "done\n"
+	if got := stderr.String(); got != want {
+		t.Errorf("stderr:\n got:  %q\n want: %q", got, want)
+	}
+
+	// 没有颜色时只有标签
+	noColor = true
+	stderr.Reset()
+	cli.Info("info")
+	cli.Warn("warn")
+	if got, want := stderr.String(), "info\nWARN warn\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+type codeError struct{ code int }
+
+func (e codeError) Error() string { return fmt.Sprintf("exit %d", e.code) }
+func (e codeError) ExitCode() int { return e.code }
+
+func TestExitCodes(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	var code int
+	osExit = func(c int) { code = c }
+
+	cli := NewCLI(OOutput(&bytes.Buffer{}))
+	plain := New(InfoLevel, OOutput(&bytes.Buffer{}))
+	wrapped := fmt.Errorf("build: %w", codeError{3})
+	tests := []struct {
+		name string
+		exit func()
+		want int
+	}{
+		{"no error", func() { cli.Fatal("bye") }, 1},
+		{"exit coder", func() { cli.Fatal("build failed:", codeError{2}) }, 2},
+		{"wrapped", func() { cli.Fatalf("failed: %v", wrapped) }, 3},
+		{"out of range", func() { cli.Fatal(codeError{200}) }, 1},
+		{"exec", func() { cli.Fatal(&exec.ExitError{}) }, 1},
+		{"must", func() { cli.Must(wrapped, "building") }, 3},
+		{"ctx", func() { cli.Ctx(nil).Fatal(codeError{4}) }, 4},
+		{"not enabled", func() { plain.Fatal(codeError{2}) }, 1},
+		{"other args", func() { cli.Fatal(errors.New("plain"), codeError{5}) }, 5},
+	}
+	for _, tt := range tests {
+		code = 0
+		tt.exit()
+		if code != tt.want {
+			t.Errorf("%s: want exit code %d, got %d", tt.name, tt.want, code)
+		}
+	}
+}
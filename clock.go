@@ -0,0 +1,71 @@
+package elog
+
+import (
+	"sync"
+	"time"
+)
+
+// ONow 设置获取当前时间的函数，默认为 time.Now，主要用于测试时注入固定或可控的时间
+func ONow(now func() time.Time) LogOption {
+	return func(logger *Log) {
+		logger.now = now
+	}
+}
+
+// OMonotonicTime 开启单调时间戳：记录第一条日志时的墙上时间作为起点，之后的时间戳都由起点加上单调时钟
+// 经过的时长得出，并且保证同一个 logger（及其 Extend 派生的子 logger）取得的时间戳不会倒退。
+//
+// 这样 NTP 把系统时钟往回调时日志时间不会跳回过去，便于下游按时间排序；代价是时间戳不再跟随系统时钟的校正，
+// 运行时间越长，与墙上时间的偏差（单调时钟的漂移以及启动后发生的校时）可能越大。
+// 单调时钟不可用时（如 ONow 注入的时间不带单调读数）退化为墙上时间，但仍保证不倒退。
+func OMonotonicTime() LogOption {
+	return func(logger *Log) {
+		logger.mono = new(monoClock)
+	}
+}
+
+// monoClock 把时间戳换算为起点加单调时长，并保证不倒退
+type monoClock struct {
+	mu    sync.Mutex
+	start time.Time
+	last  time.Time
+}
+
+func (c *monoClock) adjust(t time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.start.IsZero() {
+		c.start = t
+	}
+	t = c.start.Add(t.Sub(c.start)) // 两个时间都带单调读数时 Sub 使用单调时钟
+	if t.Before(c.last) {
+		t = c.last
+	}
+	c.last = t
+	return t
+}
+
+// timestamp 返回一条日志的时间戳，now 为空时使用 time.Now，mono 不为空时保证单调不减
+func timestamp(now func() time.Time, mono *monoClock) time.Time {
+	if now == nil {
+		now = time.Now
+	}
+	t := now()
+	if mono != nil {
+		t = mono.adjust(t)
+	}
+	return t
+}
+
+// clock 返回 logger 的时钟（见 ONow）的当前时间，供 out 之外需要当前时间的组件使用，如采样
+func (l *Log) clock() time.Time {
+	var now func() time.Time
+	if l.rlock() {
+		now = l.now
+		l.mu.RUnlock()
+	}
+	if now == nil {
+		return time.Now()
+	}
+	return now()
+}
@@ -0,0 +1,108 @@
+package elog
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// steppingClock 依次返回 times 中的时间，模拟 NTP 把时钟往回调
+func steppingClock(times ...time.Time) func() time.Time {
+	i := 0
+	return func() time.Time {
+		t := times[i]
+		if i < len(times)-1 {
+			i++
+		}
+		return t
+	}
+}
+
+func TestMonotonicTime(t *testing.T) {
+	at := func(h, m, s int) time.Time { return time.Date(2022, 1, 2, h, m, s, 0, time.UTC) }
+	times := []time.Time{at(10, 0, 0), at(10, 0, 5), at(9, 59, 58), at(10, 0, 7)}
+
+	var wall, mono bytes.Buffer
+	w := New(InfoLevel, OOutput(&wall), OFlag(Ltime), ONow(steppingClock(times...)))
+	m := New(InfoLevel, OOutput(&mono), OFlag(Ltime), ONow(steppingClock(times...)), OMonotonicTime())
+	for i := range times {
+		w.Info(i)
+		m.Info(i)
+	}
+	if got, want := wall.String(), "10:00:00 0\n10:00:05 1\n09:59:58 2\n10:00:07 3\n"; got != want {
+		t.Errorf("wall clock:\n got:  %q\n want: %q", got, want)
+	}
+	if got, want := mono.String(), "10:00:00 0\n10:00:05 1\n10:00:05 2\n10:00:07 3\n"; got != want {
+		t.Errorf("monotonic:\n got:  %q\n want: %q", got, want)
+	}
+
+	// Extend 派生的子 logger 共享同一个单调时钟
+	mono.Reset()
+	m.Extend(ONow(steppingClock(at(9, 0, 0)))).Info("child")
+	if got, want := mono.String(), "10:00:07 child\n"; got != want {
+		t.Errorf("child:\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestMonoClockElapsed(t *testing.T) {
+	c := new(monoClock)
+	start := time.Now()
+	c.adjust(start)
+	// 带单调读数的时间按单调时钟计算经过的时长，墙上时间被改动也不受影响
+	later := start.Add(time.Second)
+	if got := c.adjust(later); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("expected start+1s, got %v", got)
+	}
+	if got := c.adjust(start); !got.Equal(later) {
+		t.Errorf("timestamps should not go backwards, got %v", got)
+	}
+}
+
+func TestStrictOrder(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "strict.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每次调用前进 1µs 的时钟，时间戳的先后完全由获取的先后决定
+	var ticks int64
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return base.Add(time.Duration(atomic.AddInt64(&ticks, 1)) * time.Microsecond) }
+	l := New(InfoLevel, OOutput(f), OFlag(Ldate|Lmicroseconds|LUTC), ONow(clock), OStrictOrder())
+
+	const writers, perWriter = 50, 100
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				l.Info("x")
+			}
+		}()
+	}
+	wg.Wait()
+	l.Close()
+
+	f, err = os.Open(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var prev string
+	n := 0
+	for sc := bufio.NewScanner(f); sc.Scan(); n++ {
+		ts := sc.Text()[:len("2006/01/02 15:04:05.000000")]
+		if ts < prev {
+			t.Fatalf("line %d: timestamp %s is before %s", n+1, ts, prev)
+		}
+		prev = ts
+	}
+	if n != writers*perWriter {
+		t.Errorf("want %d lines, got %d", writers*perWriter, n)
+	}
+}
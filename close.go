@@ -0,0 +1,291 @@
+package elog
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Flusher 由带缓冲的 Writer 实现，Close 时会先调用 Flush 再调用 Close
+type Flusher interface {
+	Flush() error
+}
+
+// registry 记录尚未 Close 的 logger，供 CloseAll、FlushAll 以及 Fatal 系列方法退出前使用，见 ONoRegister。
+// 注册表引用的是 logger 的 closer 而不是 logger 本身，没有 Close 的临时 logger 仍然可以被垃圾回收，随后由终结器移出注册表。
+var registry = struct {
+	mu      sync.Mutex
+	closers map[*closer]struct{}
+}{closers: make(map[*closer]struct{})}
+
+// closer 是 logger 的所有输出（见 allWriters）、Reporter 和 Follow 的订阅者的快照，Close、Flush 只使用它，
+// logger 修改这些配置后通过 syncCloser 更新。closer 不引用 logger，被注册表引用时 logger 仍然可以被垃圾回收
+type closer struct {
+	mu        sync.Mutex
+	closed    bool
+	writers   []io.Writer
+	reporter  *reporter
+	followers []*follower
+}
+
+// ONoRegister 使 logger 不加入包级的注册表：CloseAll、FlushAll 不会关闭、Flush 它，其它 logger 的 Fatal 系列方法退出进程之前
+// 也不会 Flush 它。默认情况下 New、Extend 创建的 logger 都会被注册，被垃圾回收后自动移出注册表，通常不需要设置。
+// 通过 Extend 派生的子 logger 继承这个设置。
+func ONoRegister() LogOption {
+	return func(logger *Log) {
+		logger.noRegister = true
+	}
+}
+
+// register 把 l 加入注册表，l 被垃圾回收时由终结器移出
+func register(l *Log) {
+	c := l.closer
+	registry.mu.Lock()
+	registry.closers[c] = struct{}{}
+	registry.mu.Unlock()
+	runtime.SetFinalizer(l, func(*Log) { unregister(c) })
+}
+
+// registered 返回所有已注册 logger 的 closer 的快照
+func registered() []*closer {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	closers := make([]*closer, 0, len(registry.closers))
+	for c := range registry.closers {
+		closers = append(closers, c)
+	}
+	return closers
+}
+
+func unregister(c *closer) {
+	registry.mu.Lock()
+	delete(registry.closers, c)
+	registry.mu.Unlock()
+}
+
+// syncCloser 把 l 当前的输出、Reporter 和订阅者同步到 l.closer，l 已经 Close 时不再同步并返回 false。调用方需持有锁。
+func (l *Log) syncCloser() bool {
+	if l.closer == nil {
+		l.closer = new(closer)
+	}
+	c := l.closer
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	c.writers, c.reporter, c.followers = l.allWriters(), l.reporter, l.followers
+	return true
+}
+
+// Close 停止 logger 拥有的后台 goroutine（如 Reporter）、移除 Follow 的订阅者，并对所有输出 Writer 依次调用 Flush 和 Close，
+// 标准输出和标准错误不会被关闭。Close 是幂等的，重复调用直接返回 nil；Fatal 系列方法会在退出前自动调用。
+// 通过 Extend 派生的子 logger 与父 logger 共享 Writer，关闭任意一个都会关闭共享的 Writer。l 为 nil 时返回 nil。
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.lazyInit()
+	l.mu.Lock()
+	c := l.closer
+	c.mu.Lock()
+	if !c.closed {
+		// 订阅者和 Reporter 由 c.close 停止，这里只是不再使用它们
+		l.reporter, l.followers = nil, nil
+	}
+	c.mu.Unlock()
+	l.mu.Unlock()
+	return c.close(make(map[io.Writer]bool))
+}
+
+// CloseAll 关闭所有已注册（见 ONoRegister）且尚未 Close 的 logger，被多个 logger 共享的 Writer 只会被关闭一次
+func CloseAll() error {
+	var firstErr error
+	closed := make(map[io.Writer]bool)
+	for _, c := range registered() {
+		if err := c.close(closed); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// close 的参数 closed 记录已经关闭过的 Writer，避免被多个 logger 共享的 Writer 重复关闭
+func (c *closer) close(closed map[io.Writer]bool) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	writers, rep := c.writers, c.reporter
+	for _, f := range c.followers {
+		f.stop()
+	}
+	c.followers = nil
+	c.mu.Unlock()
+
+	unregister(c)
+	if rep != nil {
+		rep.stop()
+	}
+	return closeWriters(writers, closed)
+}
+
+// allWriters 返回 logger 的所有输出，包括 AddOutput 添加的输出和审计日志的输出。调用方需持有锁。
+func (l *Log) allWriters() []io.Writer {
+	writers := append([]io.Writer(nil), l.writers...)
+	for _, o := range l.encoded {
+		writers = append(writers, o.w)
+	}
+	if l.audit != nil {
+		writers = append(writers, l.audit)
+	}
+	return writers
+}
+
+// Flush 等待 Reporter 处理完队列中的日志，并对所有实现了 Flusher 的输出调用 Flush（如 bufio.Writer、AsyncWriter），
+// 不关闭任何 Writer，之后 logger 仍可正常使用。返回遇到的第一个错误，l 为 nil 时返回 nil。
+func (l *Log) Flush() error {
+	if l == nil {
+		return nil
+	}
+	l.lazyInit()
+	return l.closer.flush(make(map[io.Writer]bool))
+}
+
+// FlushAll 对所有已注册（见 ONoRegister）且尚未 Close 的 logger 调用 Flush，被多个 logger 共享的 Writer 只会被 Flush 一次
+func FlushAll() error {
+	return flushAll(nil)
+}
+
+// flushAll 是 FlushAll 的实现，跳过 skip 的输出，包括与其它 logger 共享的输出
+func flushAll(skip *closer) error {
+	var firstErr error
+	flushed := make(map[io.Writer]bool)
+	if skip != nil {
+		skip.mu.Lock()
+		for _, w := range skip.writers {
+			if w = unwrapWriter(w); w != nil && reflect.TypeOf(w).Comparable() {
+				flushed[w] = true
+			}
+		}
+		skip.mu.Unlock()
+	}
+	for _, c := range registered() {
+		if c == skip {
+			continue
+		}
+		if err := c.flush(flushed); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (c *closer) flush(flushed map[io.Writer]bool) error {
+	c.mu.Lock()
+	writers, rep := c.writers, c.reporter
+	c.mu.Unlock()
+	if rep != nil {
+		rep.flush()
+	}
+	var firstErr error
+	for _, w := range writers {
+		w = unwrapWriter(w)
+		if w == nil || (reflect.TypeOf(w).Comparable() && once(flushed, w)) {
+			continue
+		}
+		if f, ok := w.(Flusher); ok {
+			if err := f.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// once 报告 w 是否已经记录在 seen 中，没有时记录它
+func once(seen map[io.Writer]bool, w io.Writer) bool {
+	if seen[w] {
+		return true
+	}
+	seen[w] = true
+	return false
+}
+
+// exitTimeout 是 Fatal 系列方法退出前 Flush 所有已注册的 logger 的总时限，超时后不再等待，直接退出进程
+var exitTimeout = 5 * time.Second
+
+// flushBeforeExit 在 exitTimeout 内对所有已注册（见 ONoRegister）的 logger 调用 Flush，再关闭 l。
+// os.Exit 不会执行 defer，不这样做时其它 logger 缓冲中的日志会丢失；缓慢或卡住的 Writer 不会让进程无法退出。
+// 在 l 的 Writer 中调用 Fatal 时 l 的 Writer 正在写入，只 Flush 其它 logger 的输出。
+func (l *Log) flushBeforeExit() {
+	if l.inWriter() {
+		within(exitTimeout, func() { flushAll(l.closer) })
+		return
+	}
+	within(exitTimeout, func() {
+		FlushAll()
+		l.Close()
+	})
+}
+
+// beforePanic 在 Panic 系列方法 panic 之前调用，在 exitTimeout 内对 l 调用 Flush，
+// 使 AsyncWriter、bufio.Writer 等缓冲中的 panic 日志在 panic 向上传播之前写入，
+// 不会排在 recover 处输出的日志之后，也不会在 recover 后进程退出时丢失。与 Fatal 不同，进程可能继续运行，因此不关闭 Writer。
+func (l *Log) beforePanic() {
+	if l.inWriter() {
+		// l 的 Writer 正在写入，不能同时 Flush 它
+		return
+	}
+	within(exitTimeout, func() { l.Flush() })
+}
+
+// within 在另一个 goroutine 中执行 fn，最多等待 d
+func within(d time.Duration, fn func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+}
+
+// closeWriters 依次 Flush 和 Close writers，closed 中记录的 Writer 会被跳过，返回遇到的第一个错误
+func closeWriters(writers []io.Writer, closed map[io.Writer]bool) error {
+	var firstErr error
+	for _, w := range writers {
+		w = realStdout(unwrapWriter(w))
+		if w == nil || w == io.Writer(os.Stdout) || w == io.Writer(os.Stderr) {
+			continue
+		}
+		// 不可比较的类型无法作为 map 的 key，只能直接关闭
+		comparable := reflect.TypeOf(w).Comparable()
+		if comparable {
+			if closed[w] {
+				continue
+			}
+			closed[w] = true
+		}
+		if f, ok := w.(Flusher); ok {
+			if err := f.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if c, ok := w.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
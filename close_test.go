@@ -0,0 +1,203 @@
+package elog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// closeRecorder 记录 Flush 和 Close 的调用次数
+type closeRecorder struct {
+	bytes.Buffer
+	flushed, closed int
+	err             error
+}
+
+func (w *closeRecorder) Flush() error { w.flushed++; return nil }
+func (w *closeRecorder) Close() error { w.closed++; return w.err }
+
+func TestClose(t *testing.T) {
+	var out, audit closeRecorder
+	var raw bytes.Buffer
+	buffered := bufio.NewWriter(&raw)
+	r := &slowReporter{}
+	l := New(InfoLevel, OOutput(&out, buffered), OAuditOutput(&audit)).SetReporter(r, ErrorLevel)
+	l.Error("pending")
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close should be idempotent, got %v", err)
+	}
+	if out.flushed != 1 || out.closed != 1 || audit.flushed != 1 || audit.closed != 1 {
+		t.Errorf("each writer should be flushed and closed once, out: %+v, audit: %+v", out, audit)
+	}
+	if raw.String() != "pending\n" {
+		t.Errorf("buffered writer should be flushed, got %q", raw.String())
+	}
+	if len(r.entries) != 1 {
+		t.Errorf("reporter queue should be drained before closing, got %q", r.entries)
+	}
+	l.Error("after close") // Reporter 已停止，不应 panic
+}
+
+func TestCloseError(t *testing.T) {
+	errClose := errors.New("close failed")
+	w := &closeRecorder{err: errClose}
+	l := New(InfoLevel, OOutput(w))
+	if err := l.Close(); err != errClose {
+		t.Errorf("expected %v, got %v", errClose, err)
+	}
+}
+
+func TestCloseAll(t *testing.T) {
+	var shared, own closeRecorder
+	var unregistered closeRecorder
+	l1 := New(InfoLevel, OOutput(&shared))
+	l2 := New(InfoLevel, OOutput(&shared, &own))
+	child := l1.Extend()
+	l3 := New(InfoLevel, OOutput(&unregistered), ONoRegister())
+	if err := CloseAll(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if shared.closed != 1 || own.closed != 1 {
+		t.Errorf("shared writer should be closed once, shared: %d, own: %d", shared.closed, own.closed)
+	}
+	if err := l1.Close(); err != nil || !l2.closer.closed || !child.closer.closed || l3.closer.closed || unregistered.closed != 0 {
+		t.Errorf("CloseAll should close registered loggers only")
+	}
+}
+
+// isRegistered 报告 c 是否在注册表中
+func isRegistered(c *closer) bool {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	_, ok := registry.closers[c]
+	return ok
+}
+
+// 注册表不引用 logger 本身，用完后即使没有 Close 也能被垃圾回收，随后由终结器移出注册表
+func TestRegisteredCollected(t *testing.T) {
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}))
+	l.Extend().Info("temporary")
+	c := l.closer
+	if !isRegistered(c) {
+		t.Fatal("New should register the logger")
+	}
+	l = nil
+	for i := 0; i < 10; i++ {
+		runtime.GC()
+		if !isRegistered(c) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("an unused logger should be garbage collected and unregistered")
+}
+
+func TestFatalCloses(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	exited := false
+	osExit = func(int) { exited = true }
+	var raw bytes.Buffer
+	buffered := bufio.NewWriter(&raw)
+	l := New(InfoLevel, OOutput(buffered))
+	l.Fatal("bye")
+	if !exited || raw.String() != "bye\n" {
+		t.Errorf("Fatal should flush writers before exiting, got %q", raw.String())
+	}
+}
+
+func TestFatalFlushesAllLoggers(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	var console, file bytes.Buffer
+	bc, bf := bufio.NewWriter(&console), bufio.NewWriter(&file)
+	l1 := New(InfoLevel, OOutput(bc), OFlag(0))
+	l2 := New(InfoLevel, OOutput(bf), OFlag(0))
+	defer l1.Close()
+	defer l2.Close()
+	l1.Info("console entry")
+	l2.Info("file entry")
+
+	exited := false
+	osExit = func(int) {
+		exited = true
+		if console.String() != "console entry\n" || file.String() != "file entry\n" {
+			t.Errorf("other loggers should be flushed before exit, got %q and %q", console.String(), file.String())
+		}
+	}
+	New(InfoLevel, OOutput(&bytes.Buffer{})).Fatal("bye")
+	if !exited {
+		t.Fatal("Fatal should exit")
+	}
+	l1.Info("still usable") // 其它 logger 只是被 Flush，没有被关闭
+	bc.Flush()
+	if console.String() != "console entry\nstill usable\n" {
+		t.Errorf("unexpected output %q", console.String())
+	}
+}
+
+// stuckFlusher 的 Flush 一直阻塞到 release 被关闭
+type stuckFlusher struct {
+	bytes.Buffer
+	release chan struct{}
+}
+
+func (w *stuckFlusher) Flush() error {
+	<-w.release
+	return nil
+}
+
+func TestFatalExitTimeout(t *testing.T) {
+	defer func(exit func(int), timeout time.Duration) { osExit, exitTimeout = exit, timeout }(osExit, exitTimeout)
+	exitTimeout = 50 * time.Millisecond
+	stuck := &stuckFlusher{release: make(chan struct{})}
+	other := New(InfoLevel, OOutput(stuck))
+	defer other.Close()
+	defer close(stuck.release)
+
+	exited := false
+	osExit = func(int) { exited = true }
+	start := time.Now()
+	New(InfoLevel, OOutput(&bytes.Buffer{})).Fatal("bye")
+	if d := time.Since(start); !exited || d > time.Second {
+		t.Errorf("a stuck writer should not keep the process from exiting, took %v", d)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	var raw bytes.Buffer
+	w := &closeRecorder{}
+	buffered := bufio.NewWriter(&raw)
+	l := New(InfoLevel, OOutput(buffered, w), OFlag(0))
+	defer l.Close()
+	l.Info("pending")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if raw.String() != "pending\n" || w.flushed != 1 || w.closed != 0 {
+		t.Errorf("Flush should flush without closing, got %q %+v", raw.String(), w)
+	}
+}
+
+// CloseAll 停止订阅者时不持有 logger 的锁，之后的日志不会发送给已经停止的订阅者
+func TestCloseAllFollowers(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	l.Follow(io.Discard)
+	if err := CloseAll(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("after close")
+	l.mu.RLock()
+	n := len(l.followers)
+	l.mu.RUnlock()
+	if n != 0 {
+		t.Errorf("stopped subscribers should be removed, %d left", n)
+	}
+	l.Follow(io.Discard)() // 关闭后订阅是空操作
+}
@@ -0,0 +1,120 @@
+package elog
+
+import (
+	"io"
+	"sync"
+)
+
+// ColorMode 决定文本格式的输出是否带有颜色转义字符，见 Colors
+type ColorMode int
+
+const (
+	ColorAuto   ColorMode = iota // 由颜色相关的 flag 决定，环境变量 NO_COLOR 不为空时不输出颜色
+	ColorAlways                  // 由颜色相关的 flag 决定，忽略 NO_COLOR
+	ColorNever                   // 去掉所有颜色转义字符，包括消息本身含有的
+)
+
+// Colors 为 AddOutput 添加的文本输出单独设置颜色模式，同一个 logger 可以同时写入带颜色和不带颜色的输出，
+// 例如能够显示 ANSI 颜色的网页控制台总是带颜色、日志文件总是不带颜色：
+//
+//	l := elog.New(elog.InfoLevel, elog.OFlag(elog.LstdFlags|elog.LlevelLabelColor))
+//	l.AddOutput(ws, elog.Colors(elog.ColorAlways)).AddOutput(f, elog.Colors(elog.ColorNever))
+//
+// 哪些项带颜色仍由 LlevelLabelColor、Lmsgcolor、LnameColor 等 flag 决定，没有设置这些 flag 时 ColorAlways 不会添加颜色。
+// 去掉颜色的输出与带颜色的输出只相差转义字符本身。使用 Encoder 的输出不受影响，JSON 等格式本来就不带颜色。
+func Colors(mode ColorMode) OutputOption {
+	return func(o *encodedOutput) {
+		o.color = mode
+	}
+}
+
+// colorWriter 是设置了 ColorAlways 或 ColorNever 的文本输出
+type colorWriter struct {
+	w    io.Writer
+	mode ColorMode
+	mu   sync.Mutex // 保护 buf，Extend 派生的子 logger 共享同一个 colorWriter
+	buf  []byte     // ColorNever 去掉转义字符时使用
+}
+
+// Write 在 ColorNever 时去掉 p 中的颜色转义字符后写入，如 Raw 写入的内容；返回值按 p 的长度计算
+func (c *colorWriter) Write(p []byte) (int, error) {
+	if c.mode != ColorNever {
+		return realStdout(c.w).Write(p)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buf = stripColors(c.buf[:0], p)
+	if _, err := realStdout(c.w).Write(c.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// withColors 按 mode 包装文本输出 w，FilterWriter 包装在最外层，以便 writeText 判断是否写入
+func withColors(w io.Writer, mode ColorMode) io.Writer {
+	if mode == ColorAuto {
+		return w
+	}
+	if f, ok := w.(*filterWriter); ok {
+		return &filterWriter{w: &colorWriter{w: f.w, mode: mode}, pred: f.pred}
+	}
+	return &colorWriter{w: w, mode: mode}
+}
+
+// unwrapWriter 返回 w 去掉 FilterWriter 和 Colors 的包装后真正写入的 Writer
+func unwrapWriter(w io.Writer) io.Writer {
+	for {
+		switch x := w.(type) {
+		case *filterWriter:
+			w = x.w
+		case *colorWriter:
+			w = x.w
+		default:
+			return w
+		}
+	}
+}
+
+// forcesColor 报告是否有设置了 ColorAlways 的文本输出。调用方需持有锁。
+func (l *Log) forcesColor() bool {
+	for _, w := range l.writers {
+		if f, ok := w.(*filterWriter); ok {
+			w = f.w
+		}
+		if c, ok := w.(*colorWriter); ok && c.mode == ColorAlways {
+			return true
+		}
+	}
+	return false
+}
+
+// colorOn 报告格式化当前这条日志时是否输出颜色。调用方需持有锁。
+func (l *Log) colorOn() bool {
+	return colorEnabled() || l.colorForced
+}
+
+// plainText 返回去掉了颜色转义字符的 l.buf，同一条日志只计算一次。调用方需持有锁。
+func (l *Log) plainText() []byte {
+	if !l.plainDone {
+		l.plain = stripColors(l.plain[:0], l.buf)
+		l.plainDone = true
+	}
+	return l.plain
+}
+
+// stripColors 把 src 去掉 ANSI 转义序列（ESC [ ... 结束字节）后追加到 dst
+func stripColors(dst, src []byte) []byte {
+	for i := 0; i < len(src); {
+		if src[i] == '\x1b' && i+1 < len(src) && src[i+1] == '[' {
+			i += 2
+			for i < len(src) && (src[i] < 0x40 || src[i] > 0x7e) {
+				i++
+			}
+			i++
+			continue
+		}
+		dst = append(dst, src[i])
+		i++
+	}
+	return dst
+}
@@ -0,0 +1,57 @@
+package elog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestColorsPerOutput(t *testing.T) {
+	var web, file bytes.Buffer
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(Llevel|LlevelLabelColor|Lmsgcolor))
+	l.AddOutput(&web, Colors(ColorAlways)).AddOutput(&file, Colors(ColorNever))
+	l.Warn("disk almost full")
+
+	if !strings.Contains(web.String(), "\x1b[") {
+		t.Errorf("ColorAlways output should have escapes, got %q", web.String())
+	}
+	if strings.Contains(file.String(), "\x1b") {
+		t.Errorf("ColorNever output should not have escapes, got %q", file.String())
+	}
+	if got := string(stripColors(nil, web.Bytes())); got != file.String() {
+		t.Errorf("outputs should only differ in escapes: %q vs %q", got, file.String())
+	}
+}
+
+func TestColorsAlwaysWithNoColor(t *testing.T) {
+	defer func(v bool) { noColor = v }(noColor)
+	noColor = true
+	var console, web bytes.Buffer
+	l := New(InfoLevel, OOutput(&console), OFlag(Llevel|LlevelLabelColor))
+	l.AddOutput(&web, Colors(ColorAlways))
+	l.Error("boom")
+
+	if console.String() != " ERROR  boom\n" {
+		t.Errorf("NO_COLOR should still apply to automatic outputs, got %q", console.String())
+	}
+	if !strings.HasPrefix(web.String(), levelMap[ErrorLevel].levelLabelColor) {
+		t.Errorf("ColorAlways should ignore NO_COLOR, got %q", web.String())
+	}
+}
+
+func TestColorsWithFilterWriter(t *testing.T) {
+	var errs closeRecorder
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}), OFlag(Llevel|LlevelLabelColor))
+	l.AddOutput(LevelWriter(&errs, ErrorLevel), Colors(ColorNever))
+	l.Info("skipped")
+	l.Error("kept")
+	l.Raw("\x1b[31mraw\x1b[0m\n")
+	if got := errs.String(); got != " ERROR  kept\nraw\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+	l.Close()
+	if errs.flushed != 1 || errs.closed != 1 {
+		t.Errorf("the wrapped writer should be flushed and closed, got %+v", errs)
+	}
+}
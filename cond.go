@@ -0,0 +1,205 @@
+package elog
+
+// LoggerIf 是带条件的日志方法，cond 为 false 时直接返回，不会格式化参数：
+//
+//	l.InfoIf(verbose, "request", dump(req))
+//
+// 它们没有放进 Logger 接口，以免破坏已有的 Logger 实现；*Log 实现了 LoggerIf。
+type LoggerIf interface {
+	FatalIf(bool, ...any)
+	PanicIf(bool, ...any)
+	ErrorIf(bool, ...any)
+	WarnIf(bool, ...any)
+	InfoIf(bool, ...any)
+	DebugIf(bool, ...any)
+	TraceIf(bool, ...any)
+
+	FatalfIf(bool, string, ...any)
+	PanicfIf(bool, string, ...any)
+	ErrorfIf(bool, string, ...any)
+	WarnfIf(bool, string, ...any)
+	InfofIf(bool, string, ...any)
+	DebugfIf(bool, string, ...any)
+	TracefIf(bool, string, ...any)
+}
+
+var _ LoggerIf = &Log{}
+
+// Method Set
+func (l *Log) FatalIf(cond bool, v ...any) {
+	if cond && l.atLeast(FatalLevel) {
+		l.Out(defaultCallDepth, FatalLevel, l.sprint(v))
+		l.beforeExit()
+		osExit(l.exitCode(v))
+	}
+}
+func (l *Log) PanicIf(cond bool, v ...any) {
+	if cond && l.atLeast(PanicLevel) {
+		s := l.sprint(v)
+		l.Out(defaultCallDepth, PanicLevel, s)
+		l.beforePanic()
+		panic(l.panicValue(defaultCallDepth, s, v))
+	}
+}
+func (l *Log) ErrorIf(cond bool, v ...any) {
+	if cond && l.enabled(ErrorLevel) {
+		l.Out(defaultCallDepth, ErrorLevel, l.sprint(v))
+	}
+}
+func (l *Log) WarnIf(cond bool, v ...any) {
+	if cond && l.enabled(WarnLevel) {
+		l.Out(defaultCallDepth, WarnLevel, l.sprint(v))
+	}
+}
+func (l *Log) InfoIf(cond bool, v ...any) {
+	if cond && l.enabled(InfoLevel) {
+		l.Out(defaultCallDepth, InfoLevel, l.sprint(v))
+	}
+}
+func (l *Log) DebugIf(cond bool, v ...any) {
+	if cond && debugCompiled && l.enabled(DebugLevel) {
+		l.Out(defaultCallDepth, DebugLevel, l.sprint(v))
+	}
+}
+func (l *Log) TraceIf(cond bool, v ...any) {
+	if cond && traceCompiled && l.enabled(TraceLevel) {
+		l.Out(defaultCallDepth, TraceLevel, l.sprint(v))
+	}
+}
+
+func (l *Log) FatalfIf(cond bool, format string, v ...any) {
+	if cond && l.atLeast(FatalLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, FatalLevel, msg, fs)
+		l.beforeExit()
+		osExit(l.exitCode(v))
+	}
+}
+func (l *Log) PanicfIf(cond bool, format string, v ...any) {
+	if cond && l.atLeast(PanicLevel) {
+		s, fs := sprintf(format, v)
+		l.out(defaultCallDepth, PanicLevel, s, fs)
+		l.beforePanic()
+		panic(l.panicValue(defaultCallDepth, s, v))
+	}
+}
+func (l *Log) ErrorfIf(cond bool, format string, v ...any) {
+	if cond && l.enabled(ErrorLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, ErrorLevel, msg, fs)
+	}
+}
+func (l *Log) WarnfIf(cond bool, format string, v ...any) {
+	if cond && l.enabled(WarnLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, WarnLevel, msg, fs)
+	}
+}
+func (l *Log) InfofIf(cond bool, format string, v ...any) {
+	if cond && l.enabled(InfoLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, InfoLevel, msg, fs)
+	}
+}
+func (l *Log) DebugfIf(cond bool, format string, v ...any) {
+	if cond && debugCompiled && l.enabled(DebugLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, DebugLevel, msg, fs)
+	}
+}
+func (l *Log) TracefIf(cond bool, format string, v ...any) {
+	if cond && traceCompiled && l.enabled(TraceLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, TraceLevel, msg, fs)
+	}
+}
+
+// 包级函数，使用 Default() 返回的 logger
+
+func FatalIf(cond bool, v ...any) {
+	if l := Default(); cond && l.atLeast(FatalLevel) {
+		l.Out(defaultCallDepth, FatalLevel, l.sprint(v))
+		l.beforeExit()
+		osExit(l.exitCode(v))
+	}
+}
+func PanicIf(cond bool, v ...any) {
+	if l := Default(); cond && l.atLeast(PanicLevel) {
+		s := l.sprint(v)
+		l.Out(defaultCallDepth, PanicLevel, s)
+		l.beforePanic()
+		panic(l.panicValue(defaultCallDepth, s, v))
+	}
+}
+func ErrorIf(cond bool, v ...any) {
+	if l := Default(); cond && l.enabled(ErrorLevel) {
+		l.Out(defaultCallDepth, ErrorLevel, l.sprint(v))
+	}
+}
+func WarnIf(cond bool, v ...any) {
+	if l := Default(); cond && l.enabled(WarnLevel) {
+		l.Out(defaultCallDepth, WarnLevel, l.sprint(v))
+	}
+}
+func InfoIf(cond bool, v ...any) {
+	if l := Default(); cond && l.enabled(InfoLevel) {
+		l.Out(defaultCallDepth, InfoLevel, l.sprint(v))
+	}
+}
+func DebugIf(cond bool, v ...any) {
+	if l := Default(); cond && debugCompiled && l.enabled(DebugLevel) {
+		l.Out(defaultCallDepth, DebugLevel, l.sprint(v))
+	}
+}
+func TraceIf(cond bool, v ...any) {
+	if l := Default(); cond && traceCompiled && l.enabled(TraceLevel) {
+		l.Out(defaultCallDepth, TraceLevel, l.sprint(v))
+	}
+}
+
+func FatalfIf(cond bool, format string, v ...any) {
+	if l := Default(); cond && l.atLeast(FatalLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, FatalLevel, msg, fs)
+		l.beforeExit()
+		osExit(l.exitCode(v))
+	}
+}
+func PanicfIf(cond bool, format string, v ...any) {
+	if l := Default(); cond && l.atLeast(PanicLevel) {
+		s, fs := sprintf(format, v)
+		l.out(defaultCallDepth, PanicLevel, s, fs)
+		l.beforePanic()
+		panic(l.panicValue(defaultCallDepth, s, v))
+	}
+}
+func ErrorfIf(cond bool, format string, v ...any) {
+	if l := Default(); cond && l.enabled(ErrorLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, ErrorLevel, msg, fs)
+	}
+}
+func WarnfIf(cond bool, format string, v ...any) {
+	if l := Default(); cond && l.enabled(WarnLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, WarnLevel, msg, fs)
+	}
+}
+func InfofIf(cond bool, format string, v ...any) {
+	if l := Default(); cond && l.enabled(InfoLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, InfoLevel, msg, fs)
+	}
+}
+func DebugfIf(cond bool, format string, v ...any) {
+	if l := Default(); cond && debugCompiled && l.enabled(DebugLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, DebugLevel, msg, fs)
+	}
+}
+func TracefIf(cond bool, format string, v ...any) {
+	if l := Default(); cond && traceCompiled && l.enabled(TraceLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, TraceLevel, msg, fs)
+	}
+}
@@ -0,0 +1,84 @@
+package elog
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// countingStringer 记录 String 被调用的次数，用来确认 cond 为 false 时参数没有被格式化
+type countingStringer struct{ n *int }
+
+func (s countingStringer) String() string { *s.n++; return "expensive" }
+
+func TestConditional(t *testing.T) {
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b), OFlag(Llevel|Lshortfile))
+	calls := 0
+	arg := countingStringer{&calls}
+
+	for _, cond := range []bool{false, true} {
+		l.ErrorIf(cond, arg)
+		l.WarnIf(cond, arg)
+		l.InfoIf(cond, arg)
+		l.DebugIf(cond, arg)
+		l.TraceIf(cond, arg)
+		l.ErrorfIf(cond, "%v", arg)
+		l.WarnfIf(cond, "%v", arg)
+		l.InfofIf(cond, "%v", arg)
+		l.DebugfIf(cond, "%v", arg)
+		l.TracefIf(cond, "%v", arg)
+		if !cond && (calls != 0 || b.Len() != 0) {
+			t.Fatalf("cond=false should not format or write, got %d calls and %q", calls, b.String())
+		}
+	}
+	want := 6 // 以 elog_notrace、elog_nodebug 构建时 Trace、Debug 两组不会格式化也不会输出
+	if debugCompiled {
+		want += 2
+	}
+	if traceCompiled {
+		want += 2
+	}
+	if calls != want {
+		t.Errorf("expected %d String calls, got %d", want, calls)
+	}
+	pattern := `^((ERROR|WARN|INFO|DEBUG|TRACE) cond_test\.go:\d+ expensive\n){` + strconv.Itoa(want) + `}$`
+	if ok, _ := regexp.MatchString(pattern, b.String()); !ok {
+		t.Errorf("\n got:     %q\n pattern: %q", b.String(), pattern)
+	}
+
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	exits := 0
+	osExit = func(int) { exits++ }
+	l.FatalIf(false, arg)
+	l.FatalfIf(false, "%v", arg)
+	l.FatalIf(true, arg)
+	l.FatalfIf(true, "%v", arg)
+	if exits != 2 {
+		t.Errorf("expected 2 exits, got %d", exits)
+	}
+	l.PanicIf(false, arg)
+	l.PanicfIf(false, "%v", arg)
+	func() {
+		defer func() {
+			if r := recover(); r != "expensive" {
+				t.Errorf("expected panic %q, got %v", "expensive", r)
+			}
+		}()
+		l.PanicfIf(true, "%v", arg)
+	}()
+}
+
+func TestConditionalDefault(t *testing.T) {
+	var b bytes.Buffer
+	SetDefault(New(InfoLevel, OOutput(&b), OFlag(Lshortfile)))
+	defer SetDefault(nil)
+	InfoIf(false, "skipped")
+	InfofIf(true, "%d items", 3)
+	WarnIf(true, "warn")
+	DebugIf(true, "below level")
+	if ok, _ := regexp.MatchString(`^cond_test\.go:\d+ 3 items\ncond_test\.go:\d+ warn\n$`, b.String()); !ok {
+		t.Errorf("got %q", b.String())
+	}
+}
@@ -0,0 +1,56 @@
+package elog
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Config 是 SetConfig 一次性应用的一组配置，零值的 Order 表示使用默认的输出顺序
+type Config struct {
+	Level  logLevel // 最低等级，最高等级保持不变，见 SetLevelRange。按数值保存时注意内置等级的数值已经改变，见等级常量的说明
+	Flag   int      // 经过 NormalizeFlags 规范化
+	Prefix string
+	Order  []logOrder
+}
+
+// Config 返回 l 当前的配置，可以修改后交给 SetConfig：
+//
+//	c := l.Config()
+//	c.Level = elog.DebugLevel
+//	err := l.SetConfig(c)
+func (l *Log) Config() Config {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return Config{
+		Level:  l.Level(),
+		Flag:   l.flag,
+		Prefix: l.prefix,
+		Order:  append([]logOrder(nil), l.order...),
+	}
+}
+
+// SetConfig 在同一次加锁中替换等级、flag、前缀和输出顺序，并发输出的每条日志要么完全使用旧的配置，要么完全使用新的配置，
+// 不会像依次调用 SetLevel、SetFlag、SetPrefix、SetOrder 那样在切换过程中输出混合了新旧配置的日志。
+// 适合从配置文件热加载配置。c 无效（未知的等级或输出顺序中有未知的项）时不做任何修改，返回错误。
+// 等级变化时与 SetLevel 一样调用 OnLevelChange 注册的回调。
+func (l *Log) SetConfig(c Config) error {
+	if c.Level < Discard || c.Level > AuditLevel {
+		return errors.New("elog: unknown level " + strconv.Itoa(int(c.Level)))
+	}
+	if err := validateOrder(c.Order); err != nil {
+		return err
+	}
+	order := normalizeOrder(c.Order)
+	l.lazyInit()
+	l.mu.Lock()
+	l.flag = NormalizeFlags(c.Flag)
+	l.prefix = c.Prefix
+	l.order = order
+	l.steps = compileOrder(order)
+	notify := l.setMinLevel(c.Level)
+	l.mu.Unlock()
+	if notify {
+		l.notifyLevel()
+	}
+	return nil
+}
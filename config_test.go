@@ -0,0 +1,94 @@
+package elog
+
+import (
+	"bytes"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSetConfig(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Llevel))
+	var changes []logLevel
+	l.OnLevelChange(func(old, cur logLevel) { changes = append(changes, cur) })
+
+	c := Config{Level: WarnLevel, Flag: Llevel | Lmsgprefix | Lmicroseconds, Prefix: "[app]", Order: []logOrder{OrderPrefix, OrderLevel}}
+	if err := l.SetConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("filtered")
+	l.Warn("applied")
+	if got, want := b.String(), "[app] WARN applied\n"; !strings.HasPrefix(got, "[app] WARN ") || !strings.HasSuffix(got, " applied\n") {
+		t.Errorf("got %q, want like %q", got, want)
+	}
+	got := l.Config()
+	if got.Level != WarnLevel || got.Flag != Llevel|Lmsgprefix|Lmicroseconds|Ltime || got.Prefix != "[app]" || len(got.Order) != 2 {
+		t.Errorf("Config() = %+v", got)
+	}
+	if len(changes) != 1 || changes[0] != WarnLevel {
+		t.Errorf("level hooks: %v", changes)
+	}
+
+	for _, bad := range []Config{
+		{Level: AuditLevel + 1, Flag: Ldate},
+		{Level: DebugLevel, Flag: Ldate, Order: []logOrder{"Nope"}},
+	} {
+		if err := l.SetConfig(bad); err == nil {
+			t.Errorf("SetConfig(%+v) should fail", bad)
+		}
+	}
+	if after := l.Config(); after.Level != WarnLevel || after.Flag != got.Flag || after.Prefix != "[app]" {
+		t.Errorf("an invalid config should not change anything, got %+v", after)
+	}
+}
+
+func TestSetConfigConcurrent(t *testing.T) {
+	var b bytes.Buffer
+	a := Config{Level: InfoLevel, Flag: Llevel | Lmsgprefix, Prefix: "[a]", Order: []logOrder{OrderPrefix, OrderLevel}}
+	c := Config{Level: InfoLevel, Flag: Ltime | Lshortfile | Lmsgprefix, Prefix: "[c]"}
+	l := New(InfoLevel, OOutput(&b))
+	l.SetConfig(a)
+	// 中间件在获取配置快照之后、格式化之前执行，让出调度使 SetConfig 更容易发生在两者之间
+	l.Use(func(e *Entry) bool { runtime.Gosched(); return true })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				l.Info("msg")
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	for i := 0; ; i++ {
+		select {
+		case <-done:
+		default:
+			if i%2 == 0 {
+				l.SetConfig(c)
+			} else {
+				l.SetConfig(a)
+			}
+			runtime.Gosched()
+			continue
+		}
+		break
+	}
+
+	whole := regexp.MustCompile(`^(\[a\] INFO msg|\d\d:\d\d:\d\d config_test\.go:\d+ \[c\] msg)$`)
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 2000 {
+		t.Fatalf("want 2000 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !whole.MatchString(line) {
+			t.Fatalf("mixed configuration in %q", line)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package elog
+
+import (
+	"context"
+)
+
+// ContextExtractor 从 ctx 中提取需要附加到日志上的字段，如请求 id、trace id 等。
+// 子包 elogotel 提供了提取 OpenTelemetry trace_id 和 span_id 的实现。
+type ContextExtractor func(ctx context.Context) []Field
+
+// OContextExtractor 设置通过 Ctx 输出日志时使用的 ContextExtractor，多个 extractor 的结果按顺序合并
+func OContextExtractor(extractors ...ContextExtractor) LogOption {
+	return func(logger *Log) {
+		logger.extractors = append(logger.extractors, extractors...)
+	}
+}
+
+// AddContextExtractor 追加 ContextExtractor
+func (l *Log) AddContextExtractor(extractors ...ContextExtractor) *Log {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// 总是分配新的切片，正在输出的日志拿到的旧切片不会被修改
+	es := make([]ContextExtractor, 0, len(l.extractors)+len(extractors))
+	es = append(es, l.extractors...)
+	l.extractors = append(es, extractors...)
+	return l
+}
+
+// Ctx 返回一个绑定了 ctx 的 Logger，通过它输出的日志会附加 ContextExtractor 从 ctx 中提取的字段：
+//
+//	l.Ctx(ctx).Info("user logged in")
+func (l *Log) Ctx(ctx context.Context) Logger {
+	return ctxLogger{l: l, ctx: ctx}
+}
+
+type ctxLogger struct {
+	l   *Log
+	ctx context.Context
+}
+
+var (
+	_ Logger       = ctxLogger{}
+	_ LevelEnabler = ctxLogger{}
+)
+
+// Enabled 与所属 logger 的 Enabled 相同
+func (c ctxLogger) Enabled(level logLevel) bool { return c.l.Enabled(level) }
+
+// out 必须被 Logger 接口的方法直接调用，以保证文件路径指向调用处
+func (c ctxLogger) out(level logLevel, msg string, extra ...Field) {
+	if !c.l.enabled(level) {
+		return
+	}
+	if !c.l.rlock() {
+		c.l.reentered(level, msg)
+		return
+	}
+	extractors := c.l.extractors
+	c.l.mu.RUnlock()
+	var fields []Field
+	if c.ctx != nil {
+		for _, extract := range extractors {
+			fs, err := callExtractor(extract, c)
+			if err != nil {
+				c.l.callbackFailed(err)
+				continue
+			}
+			fields = append(fields, fs...)
+		}
+	}
+	c.l.out(defaultCallDepth+1, level, msg, append(fields, extra...))
+}
+
+func (c ctxLogger) Fatal(v ...any) {
+	c.out(FatalLevel, c.l.sprint(v))
+	if c.l.atLeast(FatalLevel) {
+		c.l.beforeExit()
+		osExit(c.l.exitCode(v))
+	}
+}
+func (c ctxLogger) Panic(v ...any) {
+	if c.l.atLeast(PanicLevel) {
+		s := c.l.sprint(v)
+		c.out(PanicLevel, s)
+		c.l.beforePanic()
+		panic(c.l.panicValue(defaultCallDepth, s, v))
+	}
+}
+func (c ctxLogger) Error(v ...any) { c.out(ErrorLevel, c.l.sprint(v)) }
+func (c ctxLogger) Warn(v ...any)  { c.out(WarnLevel, c.l.sprint(v)) }
+func (c ctxLogger) Info(v ...any)  { c.out(InfoLevel, c.l.sprint(v)) }
+func (c ctxLogger) Debug(v ...any) { c.out(DebugLevel, c.l.sprint(v)) }
+func (c ctxLogger) Trace(v ...any) { c.out(TraceLevel, c.l.sprint(v)) }
+
+func (c ctxLogger) Fatalf(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	c.out(FatalLevel, msg, fs...)
+	if c.l.atLeast(FatalLevel) {
+		c.l.beforeExit()
+		osExit(c.l.exitCode(v))
+	}
+}
+func (c ctxLogger) Panicf(format string, v ...any) {
+	if c.l.atLeast(PanicLevel) {
+		s, fs := sprintf(format, v)
+		c.out(PanicLevel, s, fs...)
+		c.l.beforePanic()
+		panic(c.l.panicValue(defaultCallDepth, s, v))
+	}
+}
+func (c ctxLogger) Errorf(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	c.out(ErrorLevel, msg, fs...)
+}
+func (c ctxLogger) Warnf(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	c.out(WarnLevel, msg, fs...)
+}
+func (c ctxLogger) Infof(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	c.out(InfoLevel, msg, fs...)
+}
+func (c ctxLogger) Debugf(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	c.out(DebugLevel, msg, fs...)
+}
+func (c ctxLogger) Tracef(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	c.out(TraceLevel, msg, fs...)
+}
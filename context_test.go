@@ -0,0 +1,47 @@
+package elog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type requestIDKey struct{}
+
+func requestID(ctx context.Context) []Field {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return []Field{{Key: "request_id", Value: id}}
+	}
+	return nil
+}
+
+func TestCtx(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lshortfile), OContextExtractor(requestID))
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "r-42")
+
+	l.Ctx(ctx).Info("user logged in")
+	l.Ctx(context.Background()).Warnf("no request %s", "id")
+	l.Ctx(ctx).Debug("filtered")
+
+	want := "context_test.go:23 user logged in request_id=r-42\n" +
+		"context_test.go:24 no request id\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestCtxExtend(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b)).AddContextExtractor(requestID)
+	child := l.Extend().AddContextExtractor(func(ctx context.Context) []Field {
+		return []Field{{Key: "component", Value: "db"}}
+	})
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "r-7")
+	l.Ctx(ctx).Error("parent")
+	child.Ctx(ctx).Error("child")
+	want := "parent request_id=r-7\nchild request_id=r-7 component=db\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
@@ -0,0 +1,30 @@
+package elog
+
+// DedupKey 是 WithKey 和 EntryBuilder.Key 输出去重键时使用的字段名
+const DedupKey = "dedup_key"
+
+// WithKey 返回附带去重键 key 的 Scope。消息中含有 id、时间等变化的内容时，按消息去重的功能无法把它们归为一类，
+// 指定去重键后这些功能按键分组：
+//
+//	l.WithKey("job-failed").Errorf("job %d failed: %v", id, err)
+//
+// 目前按键分组的是 OSamplePerLevel 的“每分钟首次必定输出”，AddFilter 添加的过滤器仍然收到消息。
+// 去重键同时作为字段 dedup_key=key 输出，便于下游按它分组。
+func (l *Log) WithKey(key string) *Scope {
+	return l.BeginScope(String(DedupKey, key))
+}
+
+// Key 为日志指定去重键，见 WithKey
+func (b *EntryBuilder) Key(key string) *EntryBuilder {
+	return b.add(String(DedupKey, key))
+}
+
+// dedupKey 返回 fields 中的去重键，没有时返回 msg
+func dedupKey(msg string, fields []Field) string {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if f := fields[i]; f.Key == DedupKey && f.Kind == KindString {
+			return f.str
+		}
+	}
+	return msg
+}
@@ -0,0 +1,43 @@
+package elog
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestWithKey(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OSamplePerLevel(map[logLevel]float64{ErrorLevel: 0}))
+	l.WithKey("job-failed").Errorf("job %d failed", 1)
+	l.WithKey("job-failed").Errorf("job %d failed", 2) // 同一个键，本分钟内不再是首次出现
+	l.With().Error().Key("job-failed").Msgf("job %d failed", 3)
+	l.With().Error().Key("disk-full").Msg("disk full")
+	l.Errorf("job %d failed", 4) // 没有键时仍按消息判断
+	l.Errorf("job %d failed", 5)
+	want := "job 1 failed dedup_key=job-failed\ndisk full dedup_key=disk-full\njob 4 failed\njob 5 failed\n"
+	if b.String() != want {
+		t.Errorf("\n got:  %q\n want: %q", b.String(), want)
+	}
+
+	// 普通的过滤器仍然收到消息
+	b.Reset()
+	remove := l.SuppressPattern(regexp.MustCompile(`^job 6`))
+	defer remove()
+	l.WithKey("job-failed").Infof("job %d failed", 6)
+	if b.Len() != 0 {
+		t.Errorf("filters should match the message, got %q", b.String())
+	}
+}
+
+func TestDedupKey(t *testing.T) {
+	if got := dedupKey("msg", nil); got != "msg" {
+		t.Errorf("want msg, got %q", got)
+	}
+	if got := dedupKey("msg", []Field{String("a", "b"), Int(DedupKey, 1)}); got != "msg" {
+		t.Errorf("non-string keys should be ignored, got %q", got)
+	}
+	if got := dedupKey("msg", []Field{String(DedupKey, "k")}); got != "k" {
+		t.Errorf("want k, got %q", got)
+	}
+}
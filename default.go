@@ -1,40 +1,140 @@
 package elog
 
-func Default() *Log { return std }
+import (
+	"io"
+	"sync/atomic"
+)
 
+// std 是内置的默认 logger
 var std *Log = New(InfoLevel, OName("Global"), OPrefix("[eLog]"), OFlag(LstdFlags))
 
-var (
-	// Getter & Setter
-	Output    = std.Output
-	Level     = std.Level
-	Name      = std.Name
-	Prefix    = std.Prefix
-	Order     = std.Order
-	Flag      = std.Flag
-	SetOutput = std.SetOutput
-	SetLevel  = std.SetLevel
-	SetName   = std.SetName
-	SetPrefix = std.SetPrefix
-	SetOrder  = std.SetOrder
-	SetFlag   = std.SetFlag
-	AddFlag   = std.AddFlag
-	SubFlag   = std.SubFlag
-
-	// Method Set
-	Fatal = std.Fatal
-	Panic = std.Panic
-	Error = std.Error
-	Warn  = std.Warn
-	Info  = std.Info
-	Debug = std.Debug
-	Trace = std.Trace
-
-	Fatalf = std.Fatalf
-	Panicf = std.Panicf
-	Errorf = std.Errorf
-	Warnf  = std.Warnf
-	Infof  = std.Infof
-	Debugf = std.Debugf
-	Tracef = std.Tracef
-)
+// defaultLogger 保存包级函数使用的 logger，可以通过 SetDefault 替换
+var defaultLogger atomic.Value
+
+func init() {
+	defaultLogger.Store(std)
+}
+
+// Default 返回包级函数使用的 logger
+func Default() *Log { return defaultLogger.Load().(*Log) }
+
+// SetDefault 替换包级函数使用的 logger，l 为 nil 时恢复为内置的默认 logger。
+// 在包级函数之外再包装一层时，可以让 l 带上 OCallerSkip(1)，使文件路径指向包装函数的调用处：
+//
+//	elog.SetDefault(elog.Default().Extend(elog.OCallerSkip(1)))
+func SetDefault(l *Log) {
+	if l == nil {
+		l = std
+	}
+	defaultLogger.Store(l)
+}
+
+// Getter & Setter
+func Output() io.Writer                           { return Default().Output() }
+func Level() logLevel                             { return Default().Level() }
+func Name() string                                { return Default().Name() }
+func Prefix() string                              { return Default().Prefix() }
+func Order() []logOrder                           { return Default().Order() }
+func Flag() int                                   { return Default().Flag() }
+func SetOutput(w1 io.Writer, w ...io.Writer) *Log { return Default().SetOutput(w1, w...) }
+func SetLevel(level logLevel) *Log                { return Default().SetLevel(level) }
+func SetName(name string) *Log                    { return Default().SetName(name) }
+func SetPrefix(prefix string) *Log                { return Default().SetPrefix(prefix) }
+func SetOrder(orders ...logOrder) *Log            { return Default().SetOrder(orders...) }
+func SetOrderE(orders ...logOrder) error          { return Default().SetOrderE(orders...) }
+func SetFlag(flag int) *Log                       { return Default().SetFlag(flag) }
+func AddFlag(flag int) *Log                       { return Default().AddFlag(flag) }
+func SubFlag(flag int) *Log                       { return Default().SubFlag(flag) }
+func Mute() *Log                                  { return Default().Mute() }
+func Unmute() *Log                                { return Default().Unmute() }
+func Muted() bool                                 { return Default().Muted() }
+
+// SetWindowLevel 设置默认 logger 的时间窗口等级，见 (*Log).SetWindowLevel
+func SetWindowLevel(from, to string, level logLevel) error {
+	return Default().SetWindowLevel(from, to, level)
+}
+
+// Method Set
+// 包级函数与对应的方法一样直接调用 Out，调用深度相同，文件路径指向包级函数的调用处
+
+func Fatal(v ...any) {
+	if l := Default(); l.atLeast(FatalLevel) {
+		l.Out(defaultCallDepth, FatalLevel, l.sprint(v))
+		l.beforeExit()
+		osExit(l.exitCode(v))
+	}
+}
+func Panic(v ...any) {
+	if l := Default(); l.atLeast(PanicLevel) {
+		s := l.sprint(v)
+		l.Out(defaultCallDepth, PanicLevel, s)
+		l.beforePanic()
+		panic(l.panicValue(defaultCallDepth, s, v))
+	}
+}
+func Error(v ...any) {
+	if l := Default(); l.enabled(ErrorLevel) {
+		l.Out(defaultCallDepth, ErrorLevel, l.sprint(v))
+	}
+}
+func Warn(v ...any) {
+	if l := Default(); l.enabled(WarnLevel) {
+		l.Out(defaultCallDepth, WarnLevel, l.sprint(v))
+	}
+}
+func Info(v ...any) {
+	if l := Default(); l.enabled(InfoLevel) {
+		l.Out(defaultCallDepth, InfoLevel, l.sprint(v))
+	}
+}
+func Raw(msg string) { Default().Raw(msg) }
+func Audit(v ...any) {
+	l := Default()
+	l.Out(defaultCallDepth, AuditLevel, l.sprint(v))
+}
+
+func Fatalf(format string, v ...any) {
+	if l := Default(); l.atLeast(FatalLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, FatalLevel, msg, fs)
+		l.beforeExit()
+		osExit(l.exitCode(v))
+	}
+}
+func Panicf(format string, v ...any) {
+	if l := Default(); l.atLeast(PanicLevel) {
+		s, fs := sprintf(format, v)
+		l.out(defaultCallDepth, PanicLevel, s, fs)
+		l.beforePanic()
+		panic(l.panicValue(defaultCallDepth, s, v))
+	}
+}
+func Errorf(format string, v ...any) {
+	if l := Default(); l.enabled(ErrorLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, ErrorLevel, msg, fs)
+	}
+}
+func Warnf(format string, v ...any) {
+	if l := Default(); l.enabled(WarnLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, WarnLevel, msg, fs)
+	}
+}
+func Infof(format string, v ...any) {
+	if l := Default(); l.enabled(InfoLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, InfoLevel, msg, fs)
+	}
+}
+func Auditf(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	Default().out(defaultCallDepth, AuditLevel, msg, fs)
+}
+
+// T 以 InfoLevel 输出 key 对应模板格式化后的消息，见 RegisterTemplates
+func T(key string, v ...any) {
+	if l := Default(); l.enabled(InfoLevel) {
+		l.Out(defaultCallDepth, InfoLevel, l.render(key, v))
+	}
+}
@@ -1,103 +1,339 @@
 package elog
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"runtime"
+	"reflect"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Log 是 logger，应通过 New 或 Extend 创建。
+//
+// 零值的 Log 也可以直接使用，第一次输出日志时按 InfoLevel、不带任何 flag、输出到标准错误初始化，
+// 适合结构体中可选的 logger 字段。nil 的 *Log 调用输出日志的方法（Info、Errorf、Out、Audit、Raw、
+// 带 If 后缀的方法等）是空操作，Fatal、Panic 系列方法也不会退出进程或 panic；修改配置的方法不支持 nil。
 type Log struct {
-	mu     sync.RWMutex
-	output io.Writer // 日志输出方式
-	level  logLevel  // 日志最低等级，低于这个等级的日志不会被打印
-	name   string    // 日志对象名称
-	flag   int       // 日志对象属性
-	prefix string    // 日志前缀
-	buf    []byte
+	// 被过滤器抑制的日志条数及各等级的计数，需要 64 位对齐，必须放在最前面
+	suppressed uint64
+	counts     [AuditLevel + 1]uint64 // 各等级已写入的日志条数，原子读写
+	bytes      [AuditLevel + 1]uint64 // 各等级已写入的字节数，原子读写
+	// 各等级被过滤器抑制的日志条数，原子读写
+	suppressedBy [AuditLevel + 1]uint64
+	writeErrors  uint64 // 写入失败的日志条数，原子读写
+	// 日志等级范围：低 32 位是最低等级，低于它的日志不会被打印；高 32 位是最高等级，高于它的日志不会被打印
+	// （Fatal、Panic 仍会退出或 panic）。原子读写，使判断等级时不必加锁，见 levelRange
+	levels  uint64
+	muted   uint32 // 非 0 时不输出任何日志，原子读写
+	ready   uint32 // 非 0 表示已经初始化，New、Extend 创建时设置，零值 Log 在第一次输出时初始化，原子读写
+	writing uint32 // 非 0 表示正处于写入的临界区（write、Raw）中，用于发现重入，见 rlock，原子读写
+
+	mu         sync.RWMutex
+	output     io.Writer   // 日志输出方式
+	writers    []io.Writer // output 中包含的所有 Writer，Close 时逐一 Flush/Close
+	audit      io.Writer   // 审计日志输出方式，为空时使用 output
+	onError    func(error) // 写入失败时的错误处理函数
+	name       string      // 日志对象名称
+	flag       int         // 日志对象属性
+	prefix     string      // 日志前缀
+	banner     bool        // 创建后是否输出启动信息
+	noRegister bool        // 不加入包级的注册表，见 ONoRegister
+	closer     *closer     // Close、Flush 使用的输出等的快照，New、Extend 或 lazyInit 之后不再改变，见 syncCloser
+	align      int         // 消息起始列，0 表示不对齐，AlignAuto 表示按出现过的最宽头部自动对齐
+	msgSep     string      // 头部与消息之间的分隔符，空字符串表示使用一个空格
+	alignAt    int         // AlignAuto 模式下目前为止最宽头部的显示宽度
+	skip       int         // 获取文件路径时额外跳过的调用栈层数
+	buf        []byte
+	maxBuf     int // buf 等缓冲区保留的最大容量，0 表示 DefaultMaxBuffer，见 OMaxBuffer
+	// 低于这个等级的日志不获取文件路径，见 OCallerMinLevel
+	callerMin logLevel
+	// 非格式化方法按 fmt.Sprint 而不是 fmt.Sprintln 拼接参数，见 OSprint
+	useSprint bool
 	// 日志输出顺序，如果没有设置输出顺序，输出内容项以 flag 为准，输出顺序为默认顺序
 	// 如果设置了输出顺序，输出内容项先以 order 为准，输出顺序以 order 为准，再以 flag 为准，输出顺序为剩余的默认顺序
 	order []logOrder
+	steps []orderStep // order 编译后的形式，总是与 order 一同设置，见 compileOrder
+	// 中间件按注册顺序在格式化之前执行，可以修改或丢弃日志条目
+	middlewares []Middleware
+	filters     []filter
+	extractors  []ContextExtractor
+	reporter    *reporter
+	encoded     []*encodedOutput  // 通过 AddOutput 添加、使用独立 Encoder 的输出
+	followers   []*follower       // 通过 Follow 添加的订阅者
+	now         func() time.Time  // 获取当前时间的函数，为空时使用 time.Now
+	mono        *monoClock        // 不为空时时间戳单调不减
+	guard       *writeGuard       // 不为空时写入带超时，见 OWriteTimeout
+	panicErr    bool              // Panic 系列方法是否抛出 *PanicError，见 OPanicError
+	strict      bool              // 是否在写入的临界区内获取时间戳，见 OStrictOrder
+	layout      string            // 日期和时间的自定义格式，见 OTimeLayout
+	layoutWidth int               // Lfixedtime 时 layout 填充到的宽度，0 表示尚未计算
+	labelFrom   logLevel          // 低于这个等级的日志不输出等级标签，见 OLevelLabelFrom
+	exitCodes   bool              // Fatal 系列方法是否按参数决定退出码，见 OExitCodes
+	stdout      io.Writer         // Plain 的输出，为空时使用标准输出
+	ending      string            // 行结束符，为空时使用 "\n"，见 OLineEnding
+	templates   map[string]string // 优先于全局模板的消息模板，写时复制，见 OTemplates
+	window      *windowLevel      // 每天固定时段内提高的最低等级，见 SetWindowLevel
+	reset       int               // Extend 不继承的配置，Inherit 系列常量的组合，见 OInherit
+	touched     int               // 被 OFlag 等选项设置过的配置，设置的值与继承的值相同时也不恢复为默认值
+	panicked    error             // 本次 write 中第一个 panic 的回调，见 CallbackPanicError
+	// 等级变化的回调，见 OnLevelChange；notifying 为 true 时正在通知，notified 是已经通知到的等级
+	levelHooks []levelHook
+	notifying  bool
+	notified   logLevel
+	// 创建时应用选项遇到的无效配置，由 checkConfig 报告后清空
+	configErrs   []error
+	strictConfig bool // 无效配置是否 panic，见 OStrictConfig
+	// 颜色默认关闭但有 ColorAlways 的输出时为 true，此时格式化时输出颜色，其余输出写入去掉颜色的 plain
+	colorForced bool
+	plain       []byte
+	plainDone   bool // plain 是否已经由当前这条日志计算过
 }
 
-var _ Logger = &Log{}
+var (
+	_ LoggerE      = &Log{}
+	_ LevelEnabler = &Log{}
+)
+
+// osExit 是 Fatal 系列方法退出进程时调用的函数，测试时可以替换
+var osExit = os.Exit
+
+// beforeExit 在 Fatal 系列方法退出进程前调用，同步地处理完尚未完成的异步工作、Flush 所有已注册的 logger 并关闭 l 的 Writer，
+// 总耗时不超过 exitTimeout
+func (l *Log) beforeExit() {
+	l.flushBeforeExit()
+}
 
 // Out is a core method
+// 低于最低等级或超出等级范围的日志不会输出（审计日志除外），判断与格式化使用同一份配置快照，见 SetConfig。
 func (l *Log) Out(calldepth int, level logLevel, msg string) error {
-	now := time.Now()
-	var file string
-	var line int
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	return l.out(calldepth+1, level, msg, nil)
+}
 
-	if l.flag&LUTC != 0 {
-		now = now.UTC()
+// out 是 Out 的实现，fields 为调用方额外附加的字段
+func (l *Log) out(calldepth int, level logLevel, msg string, fields []Field) error {
+	if l == nil {
+		return nil
+	}
+	l.lazyInit()
+	// 获取 Caller 信息和执行过滤器、中间件时不持有锁，因为上锁成本很高
+	if !l.rlock() {
+		l.reentered(level, msg)
+		return nil
+	}
+	flag, steps := l.flag, l.steps
+	middlewares := l.middlewares
+	filters := l.filters
+	rep := l.reporter
+	onError := l.onError
+	min, upper := l.levelRange()
+	calldepth += l.skip
+	if level < l.callerMin {
+		flag = subFlag(flag, Lshortfile|Llongfile)
+	}
+	nowFn, mono, strict := l.now, l.mono, l.strict
+	window := l.window
+	e := Entry{LoggerName: l.name, Prefix: l.prefix}
+	l.mu.RUnlock()
+	if (level < min || level > upper || atomic.LoadUint32(&l.muted) != 0) && level != AuditLevel {
+		return nil
 	}
+	if len(filters) > 0 && l.filtered(filters, level, msg, fields) {
+		return nil
+	}
+
+	e.Time = timestamp(nowFn, mono)
+	if window != nil {
+		t := e.Time
+		if flag&LUTC != 0 {
+			t = t.UTC()
+		}
+		if window.suppresses(level, t) {
+			return nil
+		}
+	}
+	e.Level = level
+	e.Msg = msg // 末尾的换行符在格式化时去掉，交给中间件和 Reporter 之前也会先去掉
+	e.Fields = fields
+	stamped := e.Time
+
 	// 如果设置了 Lshortfile 或 Llongfile 这两个 flag 则通过 runtime.Caller 获取文件路径和行号
-	if l.flag&(Lshortfile|Llongfile) != 0 {
-		// 获取 Caller 信息时先释放锁，因为上锁成本很高
-		l.mu.Unlock()
+	if flag&(Lshortfile|Llongfile) != 0 {
 		var ok bool
-		_, file, line, ok = runtime.Caller(calldepth)
+		e.File, e.Line, e.Func, ok = caller(calldepth)
 		if !ok {
-			file = "??? UNKNOWN FILE ???"
-			line = 0
+			e.File = "??? UNKNOWN FILE ???"
+			e.Line = 0
 		}
-		l.mu.Lock()
+	}
+	if len(middlewares) > 0 {
+		// 中间件拿到的是堆上的副本，e 本身留在栈上，没有中间件时不产生内存分配
+		me := new(Entry)
+		*me = e
+		me.Msg = strings.TrimSuffix(me.Msg, "\n")
+		ok, perr := me.apply(middlewares)
+		if perr != nil {
+			l.callbackFailed(perr) // 丢弃中间件的修改，按原样输出
+		} else {
+			if !ok && level != AuditLevel {
+				return nil
+			}
+			e = *me
+		}
+	}
+	if rep != nil && e.Level >= rep.min && e.Level != AuditLevel {
+		re := e
+		re.Msg = strings.TrimSuffix(re.Msg, "\n")
+		re.Fields = append([]Field(nil), e.Fields...)
+		re.Stack = callerStack(calldepth + 1)
+		rep.report(re)
+	}
+
+	if strict && e.Time == stamped {
+		e.Time = time.Time{} // 由 write 在锁内重新获取，中间件修改过的时间则保持不变
+	}
+	// 错误处理函数在锁外调用，它可以安全地使用同一个 logger
+	err, perr := l.write(flag, steps, &e)
+	if perr != nil {
+		l.callbackFailed(perr)
+	}
+	if err != nil {
+		atomic.AddUint64(&l.writeErrors, 1)
+		l.writeFailed(onError, err)
+	}
+	return err
+}
+
+// writeFailed 把写入错误交给错误处理函数，没有设置时报告给诊断 logger（诊断 logger 自身的错误除外）
+// 多个输出写入失败时每个输出的错误分别处理。
+func (l *Log) writeFailed(onError func(error), err error) {
+	for _, err := range splitErrors(err) {
+		if onError != nil {
+			onError(err)
+		} else if l != Internal() {
+			internalf(ErrorLevel, "logger %q: write failed: %v", l.Name(), err)
+		}
+	}
+}
+
+// write 持有锁将日志条目 e 格式化到 buffer 中并写入 Writer，e.Time 为零值时在锁内获取时间戳。
+// flag 和 steps 是 out 开始时获取的快照，格式化时不再读取 l.flag 和 l.steps，避免并发修改 flag 时头部与获取的 Caller 信息不一致，
+// 或者 SetConfig 替换配置时一条日志混用新旧两份配置。
+// panicked 是写入过程中第一个 panic 的回调，调用方需在锁外把它交给 callbackFailed。
+func (l *Log) write(flag int, steps []orderStep, e *Entry) (err, panicked error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	atomic.StoreUint32(&l.writing, 1)
+	defer atomic.StoreUint32(&l.writing, 0)
+	defer l.shrinkBuffers()
+	defer func() { panicked, l.panicked = l.panicked, nil }()
+
+	if e.Time.IsZero() {
+		e.Time = timestamp(l.now, l.mono)
+	}
+	if flag&LUTC != 0 {
+		e.Time = e.Time.UTC()
 	}
 	// 清空 buffer
 	l.buf = l.buf[:0]
+	l.colorForced = !colorEnabled() && l.forcesColor()
+	l.plainDone = false
+
+	l.format(flag, steps, e, false)
+
+	l.endLine()
+	l.count(e.Level, len(l.buf))
+	l.mirror(e.Level, l.buf)
+	if e.Level == AuditLevel && l.audit != nil {
+		return l.writeTo(l.audit, l.buf), nil
+	}
+	meta := EntryMeta{Level: e.Level, Name: e.LoggerName, Prefix: e.Prefix}
+	err = l.writeText(&meta)
+	if len(l.encoded) > 0 {
+		if e.Level == AuditLevel {
+			flag |= Llevel
+		}
+		if encErr := l.writeEncoded(&meta, flag, e); err == nil {
+			err = encErr
+		}
+	}
+	return err, nil
+}
 
+// format 按 steps 和 flag 把 e 格式化到 l.buf 中，不含行结束符。
+// header 为 true 时只格式化消息之前的头部（包括对齐消息的空格），见 FormatHeader。调用方需持有锁。
+func (l *Log) format(flag int, steps []orderStep, e *Entry, header bool) {
 	var (
-		unwriteFlag int  = l.flag
+		unwriteFlag int  = flag
 		msgWritten  bool // msg 有可能 order 里有，
 	)
-	if len(l.order) > 0 {
-		for _, order := range l.order {
-			switch order {
-			case OrderDate:
-				l.outputDate(&unwriteFlag, now)
-			case OrderTime:
-				l.outputTime(&unwriteFlag, now)
-			case OrderLevel:
-				l.outputLevel(&unwriteFlag, level)
-			case OrderPrefix:
-				l.outputPrefix(&unwriteFlag)
-			case OrderPath:
-				l.outputPath(&unwriteFlag, file, line)
-			case OrderMsg:
-				l.outputMsg(&msgWritten, level, msg)
+	if e.Level == AuditLevel {
+		unwriteFlag |= Llevel // 审计日志总是带上 AUDIT 标签
+	}
+	if flag&Llinecolor != 0 {
+		// 整行的颜色优先于各项各自的颜色；颜色不属于头部，FormatHeader 不输出
+		unwriteFlag = subFlag(unwriteFlag, Lmsgcolor|LlevelLabelColor|LnameColor)
+		if color := lineColor(e.Level); !header && color != "" && l.colorOn() {
+			start := len(l.buf)
+			l.buf = append(l.buf, color...)
+			defer l.closeLineColor(start, len(color))
+		}
+	}
+	for _, step := range steps {
+		switch step {
+		case stepDate:
+			l.outputDate(&unwriteFlag, e)
+		case stepTime:
+			l.outputTime(&unwriteFlag, e)
+		case stepLevel:
+			l.outputLevel(&unwriteFlag, e)
+		case stepPrefix:
+			l.outputPrefix(&unwriteFlag, e)
+		case stepPath:
+			l.outputPath(&unwriteFlag, e)
+		case stepMsg:
+			if header {
+				l.alignMsg()
+				l.separateMsg()
+				return
 			}
+			l.outputMsg(&msgWritten, unwriteFlag, e)
 		}
 	}
 	// Default order: Date Time Microseconds Level shortfile/longfile:Line Msgprefix MESSAGE
 	// 将格式化头部填充到 buffer 中
-	l.outputDate(&unwriteFlag, now)
-	l.outputTime(&unwriteFlag, now)
-	l.outputLevel(&unwriteFlag, level)
-	l.outputPath(&unwriteFlag, file, line)
-	l.outputPrefix(&unwriteFlag)
-	l.outputMsg(&msgWritten, level, msg)
-
-	setNewLine(&l.buf)
-	_, err := l.output.Write(l.buf)
-	return err
+	l.outputDate(&unwriteFlag, e)
+	l.outputTime(&unwriteFlag, e)
+	l.outputLevel(&unwriteFlag, e)
+	l.outputPath(&unwriteFlag, e)
+	l.outputPrefix(&unwriteFlag, e)
+	if header {
+		l.alignMsg()
+		l.separateMsg()
+		return
+	}
+	l.outputMsg(&msgWritten, unwriteFlag, e)
 }
 
 // Create Logger Option
 type LogOption func(logger *Log)
 
+// OFlag 设置日志属性，flag 会经过 NormalizeFlags 规范化
 func OFlag(flag int) LogOption {
 	return func(logger *Log) {
-		logger.flag = flag
+		logger.flag = NormalizeFlags(flag)
+		logger.touched |= InheritFlags
 	}
 }
 
 func OPrefix(prefix string) LogOption {
 	return func(logger *Log) {
 		logger.prefix = prefix
+		logger.touched |= InheritPrefix
 	}
 }
 
@@ -107,58 +343,307 @@ func OName(name string) LogOption {
 	}
 }
 
+// OOrder 设置日志各项的输出顺序，重复的项只保留第一次出现的位置。未知的项（如拼写错误）会被忽略，
+// 并在创建 logger 时报告给诊断 logger（见 Internal），同一个未知项只报告一次；设置了 OStrictConfig 时改为 panic。
 func OOrder(order ...logOrder) LogOption {
 	return func(logger *Log) {
-		logger.order = order
+		logger.order = normalizeOrder(order)
+		logger.steps = compileOrder(logger.order)
+		if err := validateOrder(order); err != nil {
+			logger.configErrs = append(logger.configErrs, err)
+		}
+	}
+}
+
+// OStrictConfig 开启严格的配置检查：OOrder、SetOrder 中的未知项以及 OLineEnding 的无效参数不再被忽略，而是直接 panic，
+// 适合在启动时尽早发现配置文件中的拼写错误。通过 Extend 派生的子 logger 会继承这个设置。
+func OStrictConfig() LogOption {
+	return func(logger *Log) {
+		logger.strictConfig = true
 	}
 }
 
+// OOutput 设置日志的输出，与 SetOutput 相同：替换之前的输出，按参数顺序依次写入，忽略其中的 nil
 func OOutput(w1 io.Writer, w ...io.Writer) LogOption {
 	return func(logger *Log) {
-		if w1 == nil {
-			w1 = os.Stderr
+		writers, err := outputWriters(w1, w)
+		if err != nil {
+			logger.configErrs = append(logger.configErrs, err)
+		}
+		logger.writers = writers
+		logger.output = newFanout(writers...)
+	}
+}
+
+// outputWriters 返回按参数顺序排列、去掉了 nil（包括值为 nil 的指针等）的输出，全部为 nil 时使用标准错误。
+// 只有部分为 nil 时返回描述它们位置的错误，通常是打开文件失败等没有检查的错误。
+func outputWriters(w1 io.Writer, w []io.Writer) ([]io.Writer, error) {
+	writers := make([]io.Writer, 0, 1+len(w))
+	var missing []string
+	for i, x := range append([]io.Writer{w1}, w...) {
+		if isNilWriter(x) {
+			missing = append(missing, strconv.Itoa(i))
+			continue
+		}
+		writers = append(writers, x)
+	}
+	if len(writers) == 0 {
+		return []io.Writer{os.Stderr}, nil
+	}
+	if len(missing) > 0 {
+		return writers, errors.New("elog: nil writer at position " + strings.Join(missing, ", "))
+	}
+	return writers, nil
+}
+
+// isNilWriter 报告 w 是否为 nil，或者是值为 nil 的指针、map、chan、func，如打开失败时返回的 (*os.File)(nil)
+func isNilWriter(w io.Writer) bool {
+	if w == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(w); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
+
+// OErrorHandler 设置写入失败时的错误处理函数。Info、Error 等方法不返回错误，
+// 写入失败（如磁盘已满）只能通过它感知；需要直接拿到错误时可以使用 LogE、LogfE。
+// 有多个输出时一个输出失败不影响其余输出，每个失败的输出各调用一次 handler，LogE 返回合并后的错误。
+// 中间件、过滤器等回调 panic 时也会以 *CallbackPanicError 交给它，这类错误不计入 WriteErrors。
+func OErrorHandler(handler func(err error)) LogOption {
+	return func(logger *Log) {
+		logger.onError = handler
+	}
+}
+
+// AlignAuto 用于 OAlign，按目前为止出现过的最宽头部自动对齐消息
+const AlignAuto = -1
+
+// OAlign 用空格填充日志头部，使消息总是从第 width 列开始（头部超过 width 时不填充）；
+// width 为 AlignAuto 时按目前为止出现过的最宽头部对齐。宽度按显示宽度计算，忽略颜色转义字符。
+func OAlign(width int) LogOption {
+	return func(logger *Log) {
+		logger.align = width
+	}
+}
+
+// OMsgSeparator 设置头部与消息之间的分隔符，如 "|" 输出 "15:04:05 W main.go:42|msg"，默认为一个空格。
+// 头部各项之间仍以空格分隔；没有头部（如没有设置任何 flag）时不输出分隔符。与 OAlign 同时使用时，分隔符替换对齐填充的最后一个空格。
+func OMsgSeparator(sep string) LogOption {
+	return func(logger *Log) {
+		logger.msgSep = sep
+	}
+}
+
+// OCompact 使用适合很窄的终端的紧凑头部，输出形如 "15:04:05 W main.go:42|msg"：不输出日期，等级标签只有一个字母，
+// 头部与消息以 "|" 分隔，不对齐、不填充空格。相当于依次应用
+//
+//	OFlag(Ltime|Lshortfile|Llevel|Lshortlevel), OMsgSeparator("|"), OAlign(0)
+//
+// 之后的选项可以覆盖其中的任意一项，如 OCompact(), OFlag(elog.Ltime|elog.Llevel|elog.Lshortlevel|elog.LlevelLabelColor)。
+func OCompact() LogOption {
+	return func(logger *Log) {
+		OFlag(Ltime | Lshortfile | Llevel | Lshortlevel)(logger)
+		OMsgSeparator("|")(logger)
+		OAlign(0)(logger)
+	}
+}
+
+// OCallerSkip 设置获取文件路径时额外跳过的调用栈层数。在 logger 外面再包装一层函数时使用 OCallerSkip(1)，
+// 文件路径会指向包装函数的调用处而不是包装函数内部。
+func OCallerSkip(skip int) LogOption {
+	return func(logger *Log) {
+		logger.skip = skip
+	}
+}
+
+// OCallerMinLevel 只为 level 及以上等级的日志获取文件路径，如只在 Warn 以上输出 file:line，
+// 大量的 Trace、Debug 日志不再调用代价很高的 runtime.Caller。低于 level 的日志即使设置了 Lshortfile 或 Llongfile
+// 也不输出文件路径这一项，Entry 的 File、Line、Func 为空。审计日志总是获取文件路径。
+func OCallerMinLevel(level logLevel) LogOption {
+	return func(logger *Log) {
+		logger.callerMin = level
+	}
+}
+
+// OSprint 使 Info、Warn 等不带 f 后缀的方法与标准库的 log.Print 一样按 fmt.Sprint 拼接参数，
+// 只在两侧都不是字符串的相邻参数之间加空格：Info("count=", n) 输出 "count=5"。
+// 默认与 log.Println 一样按 fmt.Sprintln 拼接，相邻参数之间总是加空格：输出 "count= 5"。
+// 对通过 Ctx、BeginScope、ReadOnly、Throttle、Multi 以及 InfoIf 等条件方法输出的日志同样生效，Plain 总是按 fmt.Sprintln 拼接。
+func OSprint() LogOption {
+	return func(logger *Log) {
+		logger.useSprint = true
+	}
+}
+
+// sprint 按 OSprint 的设置拼接非格式化方法的参数，错误链中有合并错误（如 errors.Join 的结果）的参数展开为多行，见 appendError
+func (l *Log) sprint(v []any) string {
+	v = renderErrors(v)
+	if l != nil && l.useSprint {
+		return fmt.Sprint(v...)
+	}
+	return fmt.Sprintln(v...)
+}
+
+// OLevelRange 设置日志等级范围，见 SetLevelRange
+func OLevelRange(min, max logLevel) LogOption {
+	return func(logger *Log) {
+		logger.storeLevelRange(min, max)
+		logger.touched |= InheritLevel
+	}
+}
+
+// OTimeLayout 使用 time.Time.Format 的格式 layout 输出日期和时间，如 time.RFC1123Z。
+// 设置了 Ldate、Ltime、Lmicroseconds 中任意一个时才输出，输出在日期的位置，格式完全由 layout 决定，
+// Ldateiso、Lweekday、Lmicroseconds 不再生效；LUTC 仍然生效。layout 为空时恢复默认格式。
+func OTimeLayout(layout string) LogOption {
+	return func(logger *Log) {
+		logger.layout = layout
+		logger.layoutWidth = 0
+	}
+}
+
+// OLineEnding 设置每条日志的行结束符，默认为 "\n"，如 Windows 上的某些工具要求使用 "\r\n"。
+// ending 必须以 '\n' 结尾，否则与 OOrder 中的未知项一样被忽略并报告。文本格式和 AddOutput 添加的 JSON、logfmt、CSV 等编码器的输出都使用它
+// （JournalEncoder 的协议格式除外），消息末尾已有的换行符（包括 "\r\n"）不会与它重复，每条日志总是恰好以一个 ending 结尾。
+func OLineEnding(ending string) LogOption {
+	return func(logger *Log) {
+		if !strings.HasSuffix(ending, "\n") {
+			logger.configErrs = append(logger.configErrs, fmt.Errorf("elog: line ending %q does not end with \"\\n\"", ending))
+			return
 		}
-		w = append(w, w1)
-		if logger.output != nil {
-			w = append(w, logger.output)
+		logger.ending = ending
+		if ending == "\n" {
+			logger.ending = ""
 		}
-		logger.output = io.MultiWriter(w...)
+	}
+}
+
+// OStrictOrder 保证日志在输出中的顺序与时间戳的顺序一致。
+//
+// 默认为了减少持有锁的时间，时间戳在获取 Caller、执行中间件之前、不持有锁时获取，多个 goroutine 并发输出时，
+// 先获取时间戳的日志可能后写入，输出中的时间戳偶尔会出现倒序。开启后时间戳在写入的临界区内重新获取，
+// 时间戳与写入顺序严格一致，代价是获取时间的开销计入临界区，高并发时锁竞争略有增加。
+// 中间件修改过的时间保持不变；Reporter 收到的仍是最初获取的时间。
+func OStrictOrder() LogOption {
+	return func(logger *Log) {
+		logger.strict = true
+	}
+}
+
+// OAuditOutput 设置审计日志专用的输出方式，未设置时审计日志与普通日志写到同一处
+func OAuditOutput(w io.Writer) LogOption {
+	return func(logger *Log) {
+		logger.audit = w
 	}
 }
 
 func New(level logLevel, options ...LogOption) *Log {
 	l := new(Log)
-	l.level = level
+	l.ready = 1
+	l.storeLevelRange(level, FatalLevel)
 	for _, opt := range options {
 		opt(l)
 	}
+	l.reset, l.touched = 0, 0 // 只在 Extend 中使用
+	l.checkConfig()
 	if l.output == nil {
-		l.output = os.Stderr
+		l.writers = []io.Writer{os.Stderr}
+		l.output = newFanout(l.writers...)
+	}
+	l.syncCloser()
+	if !l.noRegister {
+		register(l)
+	}
+	if l.banner {
+		l.outputBanner(defaultCallDepth + 1)
 	}
 	return l
 }
 
 func Extend(options ...LogOption) *Log {
-	return std.Extend(options...)
+	return Default().Extend(options...)
 }
 
 func (parent *Log) Extend(options ...LogOption) *Log {
 	son := new(Log)
+	son.ready = 1
 	if parent == nil {
-		parent = std
+		parent = Default()
 	}
 	son.output = parent.output
-	son.level = parent.level
+	son.writers = append([]io.Writer(nil), parent.writers...)
+	son.audit = parent.audit
+	for _, o := range parent.encoded {
+		son.encoded = append(son.encoded, &encodedOutput{w: o.w, enc: o.enc, header: o.header})
+	}
+	son.storeLevelRange(parent.levelRange())
 	son.flag = parent.flag
 	son.prefix = parent.prefix
+	son.align = parent.align
+	son.msgSep = parent.msgSep
+	son.skip = parent.skip
+	son.callerMin = parent.callerMin
+	son.useSprint = parent.useSprint
 	son.order = make([]logOrder, len(parent.order))
 	copy(son.order, parent.order)
+	son.steps = append([]orderStep(nil), parent.steps...)
+	son.middlewares = append([]Middleware(nil), parent.middlewares...)
+	son.filters = append([]filter(nil), parent.filters...)
+	son.extractors = append([]ContextExtractor(nil), parent.extractors...)
+	son.reporter = parent.reporter
+	son.onError = parent.onError
+	son.now = parent.now
+	son.mono = parent.mono
+	son.guard = parent.guard
+	son.panicErr = parent.panicErr
+	son.strict = parent.strict
+	son.layout = parent.layout
+	son.labelFrom = parent.labelFrom
+	son.exitCodes = parent.exitCodes
+	son.stdout = parent.stdout
+	son.ending = parent.ending
+	son.templates = parent.templates
+	son.window = parent.window
+	son.strictConfig = parent.strictConfig
+	son.maxBuf = parent.maxBuf
+	son.noRegister = parent.noRegister
+	snap := son.inheritSnapshot()
 	for _, opt := range options {
 		opt(son)
 	}
+	if son.reset != 0 {
+		son.resetInherited(snap)
+	}
+	son.reset, son.touched = 0, 0
+	son.checkConfig()
+	son.syncCloser()
+	if !son.noRegister {
+		register(son)
+	}
+	if son.banner {
+		son.outputBanner(defaultCallDepth + 1)
+	}
 	return son
 }
 
+// WithPrefix 返回一个前缀为 prefix 的子 logger，与 l 共享输出，l 本身不会被修改。
+// 库在接收调用方传入的 *Log 时应使用 WithPrefix 或 WithName 加上自己的标识，而不是调用会修改共享 logger 的 SetPrefix。
+func (l *Log) WithPrefix(prefix string) *Log {
+	return l.Extend(OName(l.Name()), OPrefix(prefix))
+}
+
+// WithName 返回一个名称为 "父名称.name" 的子 logger（父 logger 没有名称时直接使用 name），如 "app.db"，
+// 与 l 共享输出，l 本身不会被修改。
+func (l *Log) WithName(name string) *Log {
+	if parent := l.Name(); parent != "" {
+		name = parent + "." + name
+	}
+	return l.Extend(OName(name))
+}
+
 // Getter & Setter
 func (l *Log) Output() io.Writer {
 	l.mu.RLock()
@@ -166,9 +651,13 @@ func (l *Log) Output() io.Writer {
 	return l.output
 }
 func (l *Log) Level() logLevel {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.level
+	min, _ := l.levelRange()
+	return min
+}
+
+// LevelRange 返回日志等级范围，只有 min <= level <= max 的日志会被打印
+func (l *Log) LevelRange() (min, max logLevel) {
+	return l.levelRange()
 }
 func (l *Log) Name() string {
 	l.mu.RLock()
@@ -190,19 +679,48 @@ func (l *Log) Flag() int {
 	defer l.mu.RUnlock()
 	return l.flag
 }
+
+// SetOutput 替换日志的输出，按参数顺序依次写入，一个输出失败不影响其余的输出。
+// 参数中的 nil 被忽略并报告（见 OStrictConfig），全部为 nil 时输出到标准错误。
+// 之前通过 AddOutput 添加的文本输出同样被替换，使用 Encoder 的输出不受影响。
 func (l *Log) SetOutput(w1 io.Writer, w ...io.Writer) *Log {
+	writers, err := outputWriters(w1, w)
 	l.mu.Lock()
-	defer l.mu.Unlock()
-	if w1 == nil {
-		w1 = os.Stderr
+	l.writers = writers
+	l.output = newFanout(writers...)
+	l.syncCloser()
+	l.mu.Unlock()
+	if err != nil {
+		l.configFailed(err)
 	}
-	l.output = io.MultiWriter(append(w, w1)...)
 	return l
 }
-func (l *Log) SetLevel(level logLevel) *Log {
+func (l *Log) SetErrorHandler(handler func(err error)) *Log {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.level = level
+	l.onError = handler
+	return l
+}
+func (l *Log) SetLevel(level logLevel) *Log {
+	l.mu.Lock()
+	notify := l.setMinLevel(level)
+	l.mu.Unlock()
+	if notify {
+		l.notifyLevel()
+	}
+	return l
+}
+
+// SetLevelRange 设置日志等级范围，只打印 min 到 max 之间（包含两端）的日志，max 默认为 FatalLevel。
+// 例如 SetLevelRange(TraceLevel, DebugLevel) 只打印调试日志，可以配合另一个 logger 把日志按等级拆分到不同文件。
+// 超出范围的 Fatal、Panic 不会被打印，但仍会退出进程或 panic；审计日志不受影响。
+func (l *Log) SetLevelRange(min, max logLevel) *Log {
+	l.mu.Lock()
+	notify := l.setLevelRange(min, max)
+	l.mu.Unlock()
+	if notify {
+		l.notifyLevel()
+	}
 	return l
 }
 func (l *Log) SetName(name string) *Log {
@@ -220,28 +738,58 @@ func (l *Log) SetPrefix(prefix string) *Log {
 func (l *Log) SetFlag(flag int) *Log {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.flag = flag
+	l.flag = NormalizeFlags(flag)
 	return l
 }
+
+// CheckOrder 检查通过 OOrder 或 SetOrder 设置的输出顺序中是否有因为对应的 flag 没有设置而不会输出的项，
+// 如设置了 OrderTime 却没有 Ltime 或 Lmicroseconds。这样的项不会导致错误，只会被静默忽略，
+// 在修改 flag 或输出顺序之后可以调用它确认配置是否符合预期。OrderMsg 总是会输出。
+func (l *Log) CheckOrder() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return checkOrder(l.order, l.flag)
+}
+
+// SetOrder 设置日志各项的输出顺序，未知的项与 OOrder 一样被忽略并报告，设置了 OStrictConfig 时 panic
 func (l *Log) SetOrder(orders ...logOrder) *Log {
+	err := validateOrder(orders)
 	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.order = l.order[:0]
-	l.order = append(l.order, orders...)
+	l.order = normalizeOrder(orders)
+	l.steps = compileOrder(l.order)
+	l.mu.Unlock()
+	if err != nil {
+		l.configFailed(err)
+	}
 	return l
 }
 
+// SetOrderE 与 SetOrder 相同，但有未知的项时不修改输出顺序，而是返回错误，适合应用从配置文件中读取的输出顺序
+func (l *Log) SetOrderE(orders ...logOrder) error {
+	if err := validateOrder(orders); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order = normalizeOrder(orders)
+	l.steps = compileOrder(l.order)
+	return nil
+}
+
 // Manipulate Flag
 func (l *Log) AddFlag(flag int) *Log {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.flag = l.flag | flag
+	l.flag = NormalizeFlags(l.flag | flag)
 	return l
 }
 func (l *Log) SubFlag(flag int) *Log {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.flag = l.flag &^ flag
+	if flag&Ltime != 0 {
+		flag |= Lmicroseconds // 去掉时间时微秒也没有意义
+	}
+	l.flag = NormalizeFlags(l.flag &^ flag)
 	return l
 }
 
@@ -250,81 +798,242 @@ func subFlag(flag1 int, flag2 int) int {
 	return flag1 &^ flag2
 }
 
+// enabled 报告 level 等级的日志是否在等级范围内并且没有被构建标签去掉（见 levelCompiled），l 为 nil 时返回 false。
+// 所有输出日志的入口都通过它判断，Log、Logf 等以变量为等级的方法也不会输出被去掉的等级
+func (l *Log) enabled(level logLevel) bool {
+	if l == nil || !levelCompiled(level) {
+		return false
+	}
+	l.lazyInit()
+	min, max := l.levelRange()
+	return min <= level && level <= max && atomic.LoadUint32(&l.muted) == 0
+}
+
+// atLeast 报告 level 是否不低于最低等级，Fatal、Panic 系列方法据此决定是否退出进程或 panic，l 为 nil 时返回 false
+func (l *Log) atLeast(level logLevel) bool {
+	if l == nil {
+		return false
+	}
+	l.lazyInit()
+	min, _ := l.levelRange()
+	return min <= level
+}
+
+// levelRange 原子地读取日志等级范围，不需要持有锁
+func (l *Log) levelRange() (min, max logLevel) {
+	v := atomic.LoadUint64(&l.levels)
+	return logLevel(int32(v)), logLevel(int32(v >> 32))
+}
+
+// storeLevelRange 原子地写入日志等级范围。除了创建 logger 时，调用方需持有锁，以免与其它修改等级的调用交错
+func (l *Log) storeLevelRange(min, max logLevel) {
+	atomic.StoreUint64(&l.levels, uint64(uint32(min))|uint64(uint32(max))<<32)
+}
+
+// lazyInit 初始化零值的 Log：等级为 InfoLevel，输出到标准错误。已经设置过的等级和输出保持不变。
+func (l *Log) lazyInit() {
+	if atomic.LoadUint32(&l.ready) != 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ready != 0 {
+		return
+	}
+	min, max := l.levelRange()
+	if min == Discard {
+		min = InfoLevel
+	}
+	if max == Discard {
+		max = FatalLevel
+	}
+	l.storeLevelRange(min, max)
+	if l.output == nil {
+		l.writers = []io.Writer{os.Stderr}
+		l.output = newFanout(l.writers...)
+	}
+	l.syncCloser()
+	atomic.StoreUint32(&l.ready, 1)
+}
+
+// Enabled 报告 level 等级的日志是否会被输出，即在等级范围内、logger 没有被静默，并且没有被构建标签去掉，
+// 实现了 LevelEnabler。过滤器、采样等在输出时才判断的条件不在考虑之内。l 为 nil 时总是返回 false。
+func (l *Log) Enabled(level logLevel) bool {
+	if level == AuditLevel {
+		return l != nil // 审计日志不受等级和静默的影响
+	}
+	return l.enabled(level)
+}
+
+// Mute 暂时静默 logger，不改变输出等配置。静默期间日志在格式化之前就被丢弃，
+// Fatal、Panic 仍会退出进程或 panic，审计日志不受影响。
+func (l *Log) Mute() *Log {
+	atomic.StoreUint32(&l.muted, 1)
+	return l
+}
+
+// Unmute 取消 Mute 的静默
+func (l *Log) Unmute() *Log {
+	atomic.StoreUint32(&l.muted, 0)
+	return l
+}
+
+// Muted 报告 logger 是否处于静默状态，l 为 nil 时返回 false
+func (l *Log) Muted() bool {
+	return l != nil && atomic.LoadUint32(&l.muted) != 0
+}
+
 // Method Set
+//
+// 不带 f 后缀的方法默认按 fmt.Sprintln 拼接参数，相邻参数之间总是以空格分隔，设置了 OSprint 时改为按 fmt.Sprint 拼接。
+
+// Fatal 以 FatalLevel 输出日志后退出进程，参数的拼接方式见 OSprint
 func (l *Log) Fatal(v ...any) {
-	if l.level <= FatalLevel {
-		l.Out(defaultCallDepth, FatalLevel, fmt.Sprintln(v...))
-		os.Exit(1)
+	if l.atLeast(FatalLevel) {
+		l.Out(defaultCallDepth, FatalLevel, l.sprint(v))
+		l.beforeExit()
+		osExit(l.exitCode(v))
 	}
 }
+
+// Panic 以 PanicLevel 输出日志后 panic，参数的拼接方式见 OSprint
 func (l *Log) Panic(v ...any) {
-	if l.level <= PanicLevel {
-		s := fmt.Sprintln(v...)
+	if l.atLeast(PanicLevel) {
+		s := l.sprint(v)
 		l.Out(defaultCallDepth, PanicLevel, s)
-		panic(s)
+		l.beforePanic()
+		panic(l.panicValue(defaultCallDepth, s, v))
 	}
 }
+
+// Error 以 ErrorLevel 输出日志，参数的拼接方式见 OSprint
 func (l *Log) Error(v ...any) {
-	if l.level <= ErrorLevel {
-		l.Out(defaultCallDepth, ErrorLevel, fmt.Sprintln(v...))
+	if l.enabled(ErrorLevel) {
+		l.Out(defaultCallDepth, ErrorLevel, l.sprint(v))
 	}
 }
+
+// Warn 以 WarnLevel 输出日志，参数的拼接方式见 OSprint
 func (l *Log) Warn(v ...any) {
-	if l.level <= WarnLevel {
-		l.Out(defaultCallDepth, WarnLevel, fmt.Sprintln(v...))
+	if l.enabled(WarnLevel) {
+		l.Out(defaultCallDepth, WarnLevel, l.sprint(v))
 	}
 }
+
+// Info 以 InfoLevel 输出日志，参数的拼接方式见 OSprint：默认 Info("count=", 5) 输出 "count= 5"，设置了 OSprint 时输出 "count=5"
 func (l *Log) Info(v ...any) {
-	if l.level <= InfoLevel {
-		l.Out(defaultCallDepth, InfoLevel, fmt.Sprintln(v...))
+	if l.enabled(InfoLevel) {
+		l.Out(defaultCallDepth, InfoLevel, l.sprint(v))
+	}
+}
+
+// Log 以 level 等级输出日志，可以是 RegisterLevel 注册的自定义等级，参数的拼接方式见 OSprint。
+// 与 LogE 一样，以 FatalLevel 或 PanicLevel 输出时不会退出进程或 panic。
+func (l *Log) Log(level logLevel, v ...any) {
+	if l.enabled(level) {
+		l.Out(defaultCallDepth, level, l.sprint(v))
+	}
+}
+
+// Logf 是 Log 的格式化版本
+func (l *Log) Logf(level logLevel, format string, v ...any) {
+	if l.enabled(level) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, level, msg, fs)
 	}
 }
-func (l *Log) Debug(v ...any) {
-	if l.level <= DebugLevel {
-		l.Out(defaultCallDepth, DebugLevel, fmt.Sprintln(v...))
+
+// LogE 以 level 等级输出日志并返回写入时的错误，等级被过滤时返回 nil。
+// 与 Fatal、Panic 不同，LogE 以 FatalLevel 或 PanicLevel 输出时不会退出进程或 panic。
+func (l *Log) LogE(level logLevel, v ...any) error {
+	if !l.enabled(level) {
+		return nil
 	}
+	return l.Out(defaultCallDepth, level, l.sprint(v))
 }
-func (l *Log) Trace(v ...any) {
-	if l.level <= TraceLevel {
-		l.Out(defaultCallDepth, TraceLevel, fmt.Sprintln(v...))
+
+// Raw 以 InfoLevel 把 msg 原样写入，只在末尾补一个换行符，不输出任何头部（日期、等级、文件路径、前缀等）
+// 也不经过过滤器和中间件，适合输出横幅或供机器解析的行。它同样受日志等级和 Mute 控制，
+// 只写入文本格式的输出（通过 WithEncoder 添加的输出会被跳过），并且与普通日志一样只调用一次 Write，不会与其它日志交错。
+func (l *Log) Raw(msg string) {
+	if !l.enabled(InfoLevel) {
+		return
+	}
+	if !l.lock() {
+		l.reentered(InfoLevel, msg)
+		return
+	}
+	atomic.StoreUint32(&l.writing, 1)
+	l.buf = append(l.buf[:0], msg...)
+	if len(msg) == 0 || msg[len(msg)-1] != '\n' {
+		l.buf = append(l.buf, '\n')
+	}
+	if l.ending != "" {
+		l.buf = appendLineEnding(l.buf[:len(l.buf)-1], l.ending)
+	}
+	err := l.writeTo(l.output, l.buf)
+	l.mirror(InfoLevel, l.buf)
+	l.shrinkBuffers()
+	onError := l.onError
+	atomic.StoreUint32(&l.writing, 0)
+	l.mu.Unlock()
+	if err != nil {
+		atomic.AddUint64(&l.writeErrors, 1)
+		l.writeFailed(onError, err)
 	}
 }
 
+// Audit 输出审计日志，不受日志等级过滤，总是会被写入
+func (l *Log) Audit(v ...any) {
+	l.Out(defaultCallDepth, AuditLevel, l.sprint(v))
+}
+
 func (l *Log) Fatalf(format string, v ...any) {
-	if l.level <= FatalLevel {
-		l.Out(defaultCallDepth, FatalLevel, fmt.Sprintf(format, v...))
-		os.Exit(1)
+	if l.atLeast(FatalLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, FatalLevel, msg, fs)
+		l.beforeExit()
+		osExit(l.exitCode(v))
 	}
 }
 func (l *Log) Panicf(format string, v ...any) {
-	if l.level <= PanicLevel {
-		s := fmt.Sprintf(format, v...)
-		l.Out(defaultCallDepth, PanicLevel, s)
-		panic(s)
+	if l.atLeast(PanicLevel) {
+		s, fs := sprintf(format, v)
+		l.out(defaultCallDepth, PanicLevel, s, fs)
+		l.beforePanic()
+		panic(l.panicValue(defaultCallDepth, s, v))
 	}
 }
 func (l *Log) Errorf(format string, v ...any) {
-	if l.level <= ErrorLevel {
-		l.Out(defaultCallDepth, ErrorLevel, fmt.Sprintf(format, v...))
+	if l.enabled(ErrorLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, ErrorLevel, msg, fs)
 	}
 }
 func (l *Log) Warnf(format string, v ...any) {
-	if l.level <= WarnLevel {
-		l.Out(defaultCallDepth, WarnLevel, fmt.Sprintf(format, v...))
+	if l.enabled(WarnLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, WarnLevel, msg, fs)
 	}
 }
 func (l *Log) Infof(format string, v ...any) {
-	if l.level <= InfoLevel {
-		l.Out(defaultCallDepth, InfoLevel, fmt.Sprintf(format, v...))
+	if l.enabled(InfoLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, InfoLevel, msg, fs)
 	}
 }
-func (l *Log) Debugf(format string, v ...any) {
-	if l.level <= DebugLevel {
-		l.Out(defaultCallDepth, DebugLevel, fmt.Sprintf(format, v...))
+
+// LogfE 是 LogE 的格式化版本
+func (l *Log) LogfE(level logLevel, format string, v ...any) error {
+	if !l.enabled(level) {
+		return nil
 	}
+	msg, fs := sprintf(format, v)
+	return l.out(defaultCallDepth, level, msg, fs)
 }
-func (l *Log) Tracef(format string, v ...any) {
-	if l.level <= TraceLevel {
-		l.Out(defaultCallDepth, TraceLevel, fmt.Sprintf(format, v...))
-	}
+
+// Auditf 输出审计日志，不受日志等级过滤，总是会被写入
+func (l *Log) Auditf(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	l.out(defaultCallDepth, AuditLevel, msg, fs)
 }
@@ -0,0 +1,56 @@
+//go:build elog_nodebug
+
+// CI 以 elog_nodebug 构建标签运行全部测试（见 .github/workflows/test.yml），也可以单独运行：
+//
+//	go test -tags elog_nodebug -run NoDebug -bench Disabled .
+package elog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestNoDebug(t *testing.T) {
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b))
+	l.Debug("compiled", "out")
+	l.Debugf("compiled %s", "out")
+	Printer(l, DebugLevel).Printf("compiled %s", "out")
+	if b.Len() != 0 {
+		t.Errorf("Debug should not write anything when built with elog_nodebug, got %q", b.String())
+	}
+	for name, lg := range map[string]Logger{
+		"ctx":      l.Ctx(context.Background()),
+		"readonly": l.ReadOnly(),
+		"multi":    Multi(l),
+	} {
+		lg.Debug("compiled", "out")
+		lg.Debugf("compiled %s", "out")
+		if b.Len() != 0 {
+			t.Errorf("%s: Debug should not write anything when built with elog_nodebug, got %q", name, b.String())
+		}
+	}
+	l.Info("still works")
+	if got, want := b.String(), "still works\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+// 以变量为等级的方法同样不输出被去掉的等级
+func TestNoDebugLevelArgument(t *testing.T) {
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b))
+	l.Log(DebugLevel, "compiled", "out")
+	l.Logf(DebugLevel, "compiled %s", "out")
+	l.LogE(DebugLevel, "compiled", "out")
+	l.LogfE(DebugLevel, "compiled %s", "out")
+	l.TimeTrack(DebugLevel, "compiled out")()
+	called := false
+	l.Timed(DebugLevel, "compiled out", func() error { called = true; return nil })
+	w := l.WriterLevel(DebugLevel)
+	w.Write([]byte("compiled out\n"))
+	if b.Len() != 0 || !called {
+		t.Errorf("Debug should not write anything when built with elog_nodebug, got %q (fn called: %v)", b.String(), called)
+	}
+}
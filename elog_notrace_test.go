@@ -0,0 +1,57 @@
+//go:build elog_notrace
+
+// CI 以 elog_notrace 构建标签运行全部测试（见 .github/workflows/test.yml），也可以单独运行：
+//
+//	go test -tags elog_notrace -run NoTrace -bench Disabled .
+package elog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestNoTrace(t *testing.T) {
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b))
+	l.Trace("compiled", "out")
+	l.Tracef("compiled %s", "out")
+	Printer(l, TraceLevel).Printf("compiled %s", "out")
+	l.TraceFn("compiled", "out")()
+	if b.Len() != 0 {
+		t.Errorf("Trace should not write anything when built with elog_notrace, got %q", b.String())
+	}
+	for name, lg := range map[string]Logger{
+		"ctx":      l.Ctx(context.Background()),
+		"readonly": l.ReadOnly(),
+		"multi":    Multi(l),
+	} {
+		lg.Trace("compiled", "out")
+		lg.Tracef("compiled %s", "out")
+		if b.Len() != 0 {
+			t.Errorf("%s: Trace should not write anything when built with elog_notrace, got %q", name, b.String())
+		}
+	}
+	l.Info("still works")
+	if got, want := b.String(), "still works\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+// 以变量为等级的方法同样不输出被去掉的等级
+func TestNoTraceLevelArgument(t *testing.T) {
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b))
+	l.Log(TraceLevel, "compiled", "out")
+	l.Logf(TraceLevel, "compiled %s", "out")
+	l.LogE(TraceLevel, "compiled", "out")
+	l.LogfE(TraceLevel, "compiled %s", "out")
+	l.TimeTrack(TraceLevel, "compiled out")()
+	called := false
+	l.Timed(TraceLevel, "compiled out", func() error { called = true; return nil })
+	w := l.WriterLevel(TraceLevel)
+	w.Write([]byte("compiled out\n"))
+	if b.Len() != 0 || !called {
+		t.Errorf("Trace should not write anything when built with elog_notrace, got %q (fn called: %v)", b.String(), called)
+	}
+}
@@ -2,11 +2,15 @@ package elog
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -15,7 +19,13 @@ const (
 	RegDate         = `[0-9][0-9][0-9][0-9]/[0-9][0-9]/[0-9][0-9]\s*`
 	RegTime         = `[0-9][0-9]:[0-9][0-9]:[0-9][0-9]\s*`
 	RegMicroseconds = `\.[0-9][0-9][0-9][0-9][0-9][0-9]\s*`
+	RegWeekday      = `(Mon|Tue|Wed|Thu|Fri|Sat|Sun)\s*`
+	RegDateISO      = `[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]\s*`
+	RegDateTimeISO  = `[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]T[0-9][0-9]:[0-9][0-9]:[0-9][0-9](\.[0-9]{6})?[+-][0-9][0-9]:[0-9][0-9]\s*`
 	RegLevel        = `\x1b\[\d;[0-9][0-9];[0-9][0-9]m(\s+)(\w+)(\s+)\x1b\[0m\s*`
+	RegPlainLevel   = `(TRACE|DEBUG|INFO|WARN|ERROR|PANIC|FATAL|AUDIT)\s*`
+	RegLineColor    = `\x1b\[\d;[0-9][0-9];[0-9][0-9]m` // Llinecolor 在第一项之前开始的颜色，没有前导空格
+	RegLineReset    = `\x1b\[0m`                        // Llinecolor 在行结束符之前结束颜色
 	RegPrefix       = TEST_PREFIX + " "
 	RegLine         = `(\d+)\s*`
 	RegLongfile     = `.*/[A-Za-z0-9_\-]+\.go:` + RegLine
@@ -65,7 +75,7 @@ var tests = []tester{
 		RegTime + RegMicroseconds},
 	{ // microsec implies time
 		in{name: "t9", level: InfoLevel, flag: Lmicroseconds},
-		RegTime + RegMicroseconds + " "},
+		RegTime + RegMicroseconds},
 	{
 		in{name: "t10", level: ErrorLevel, flag: Llongfile},
 		RegLongfile},
@@ -81,6 +91,14 @@ var tests = []tester{
 	{in{name: "t14", level: ErrorLevel, flag: Ldate | Ltime | Lmicroseconds | Llevel | LlevelLabelColor | Llongfile | Lmsgprefix, prefix: TEST_PREFIX}, RegDate + RegTime + RegMicroseconds + RegLevel + RegLongfile + RegPrefix},
 	{in{name: "t15", level: ErrorLevel, flag: Ldate | Ltime | Lmicroseconds | Llevel | LlevelLabelColor | Lshortfile | Lmsgprefix, prefix: TEST_PREFIX}, RegDate + RegTime + RegMicroseconds + RegLevel + RegShortfile + RegPrefix},
 
+	{in{name: "t18", level: InfoLevel, flag: Ldate | Ldateiso}, RegDateISO},
+	{in{name: "t19", level: InfoLevel, flag: Ldate | Ltime | Ldateiso | Lshortfile}, RegDateTimeISO + RegShortfile},
+	{in{name: "t20", level: InfoLevel, flag: Ldate | Lmicroseconds | Ldateiso | Lweekday}, RegWeekday + RegDateTimeISO},
+	{in{name: "t21", level: InfoLevel, flag: Ldate | Ltime | Lweekday}, RegWeekday + RegDate + RegTime},
+	{ // ISO 日期和时间作为一个整体，输出在日期和时间中先出现的位置
+		in{name: "t22", level: ErrorLevel, flag: Ldate | Ltime | Ldateiso | Lshortfile, order: []logOrder{OrderPath, OrderTime}},
+		RegShortfile + RegDateTimeISO},
+
 	{ // test order
 		in{name: "t16", level: ErrorLevel, flag: Lmsgprefix | Ldate | Lshortfile, prefix: TEST_PREFIX, order: []logOrder{OrderLevel, OrderPrefix, OrderDate, OrderPath}},
 		RegPrefix + RegDate + RegShortfile},
@@ -88,9 +106,16 @@ var tests = []tester{
 	{
 		in{name: "t17", level: ErrorLevel, flag: Lmsgprefix | Ldate | Lshortfile | Llevel | LlevelLabelColor, prefix: TEST_PREFIX, order: []logOrder{OrderLevel, OrderPrefix, OrderDate, OrderPath}},
 		RegLevel + RegPrefix + RegDate + RegShortfile},
+
+	{ // Llinecolor 包裹整行，优先于各项的颜色
+		in{name: "t23", level: WarnLevel, flag: Llinecolor | Ltime | Llevel | LlevelLabelColor | Lmsgcolor | Lshortfile},
+		RegLineColor + RegTime + RegPlainLevel + RegShortfile},
 }
 
 func testPrint(t *testing.T, name string, level logLevel, flag int, prefix string, order []logOrder, pattern string, useFormat bool) {
+	if !levelCompiled(level) {
+		return // 以 elog_notrace、elog_nodebug 构建时这些等级没有输出，见 TestNoTrace、TestNoDebug
+	}
 	var buf bytes.Buffer
 	l := New(level, OOutput(&buf), OFlag(flag), OPrefix(prefix), OOrder(order...))
 	if useFormat {
@@ -123,7 +148,11 @@ func testPrint(t *testing.T, name string, level logLevel, flag int, prefix strin
 
 	got := buf.String()
 	got = got[0 : len(got)-1]
-	pattern = `^` + pattern + `hello 18 word\s*$`
+	tail := ""
+	if flag&Llinecolor != 0 {
+		tail = RegLineReset
+	}
+	pattern = `^` + pattern + `hello 18 word` + tail + `$`
 	matched, err := regexp.MatchString(pattern, got)
 	if err != nil {
 		t.Errorf(`%s: pattern did not compile: %q`, name, err)
@@ -134,6 +163,16 @@ func testPrint(t *testing.T, name string, level logLevel, flag int, prefix strin
 	SetOutput(os.Stderr)
 }
 
+// skipCompiledOut 在 levels 中有等级被 elog_notrace、elog_nodebug 构建标签去掉时跳过依赖这些等级输出的测试
+func skipCompiledOut(t *testing.T, levels ...logLevel) {
+	t.Helper()
+	for _, level := range levels {
+		if !levelCompiled(level) {
+			t.Skipf("%s is compiled out by a build tag", level)
+		}
+	}
+}
+
 func TestDefault(t *testing.T) {
 	if got := Default(); got != std {
 		t.Errorf("Default [%p] should be std [%p]", got, std)
@@ -152,7 +191,7 @@ func TestExtend(t *testing.T) {
 	parent := New(InfoLevel, OOutput(&b), OFlag(Llevel|Ldate), OPrefix("Test: "), OOrder(OrderDate, OrderLevel))
 	child := parent.Extend()
 	if !reflect.DeepEqual(parent, child) {
-		t.Errorf("logger child has some different with logger parent.\n child:  %q,\n parent: %q", child, parent)
+		t.Errorf("logger child has some different with logger parent.\n child:  %+v,\n parent: %+v", child, parent)
 	}
 	child.SetOrder(OrderMsg, OrderLevel)
 	if reflect.DeepEqual(child, parent) {
@@ -168,11 +207,11 @@ func TestMethodChaining(t *testing.T) {
 	var b bytes.Buffer
 	parent := New(InfoLevel).SetFlag(Llevel).SetName("chaining").SetOutput(&b)
 	if parent.Flag() != Llevel || parent.Name() != "chaining" {
-		t.Errorf("the method chaining may have some problem when logger parent creating. parent: %q", parent)
+		t.Errorf("the method chaining may have some problem when logger parent creating. parent: %+v", parent)
 	}
 	child := parent.Extend().AddFlag(Ldate)
 	if child.Flag() != Llevel|Ldate {
-		t.Errorf("the method chaining may have some problem when logger child extending. child:  %q", child)
+		t.Errorf("the method chaining may have some problem when logger child extending. child:  %+v", child)
 	}
 }
 
@@ -225,9 +264,36 @@ func TestFlagSetting(t *testing.T) {
 	}
 }
 
+func TestNormalizeFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		l    *Log
+		want int
+	}{
+		{"OFlag microseconds", New(InfoLevel, OFlag(Lmicroseconds)), Ltime | Lmicroseconds},
+		{"OFlag both files", New(InfoLevel, OFlag(Lshortfile|Llongfile)), Lshortfile},
+		{"SetFlag microseconds", New(InfoLevel).SetFlag(Ldate | Lmicroseconds), Ldate | Ltime | Lmicroseconds},
+		{"AddFlag microseconds", New(InfoLevel, OFlag(Llevel)).AddFlag(Lmicroseconds), Llevel | Ltime | Lmicroseconds},
+		{"AddFlag longfile", New(InfoLevel, OFlag(Lshortfile)).AddFlag(Llongfile), Lshortfile},
+		{"SubFlag time", New(InfoLevel, OFlag(Ldate|Lmicroseconds)).SubFlag(Ltime), Ldate},
+		{"SubFlag microseconds", New(InfoLevel, OFlag(Lmicroseconds)).SubFlag(Lmicroseconds), Ltime},
+	}
+	for _, tc := range tests {
+		if got := tc.l.Flag(); got != tc.want {
+			t.Errorf("%s: expected %b got %b", tc.name, tc.want, got)
+		}
+	}
+
+	var b bytes.Buffer
+	New(InfoLevel, OOutput(&b), OFlag(Lmicroseconds)).Info("hello")
+	if ok, _ := regexp.MatchString(`^\d\d:\d\d:\d\d\.\d{6} hello\n$`, b.String()); !ok {
+		t.Errorf("Lmicroseconds output: got %q", b.String())
+	}
+}
+
 func TestPrefixSetting(t *testing.T) {
 	var b bytes.Buffer
-	l := New(InfoLevel, OOutput(&b), OFlag(LstdFlags|Lmsgprefix), OPrefix("Test: "))
+	l := New(InfoLevel, OOutput(&b), OFlag(LstdFlags|LlevelLabelColor|Lmsgprefix), OPrefix("Test: "))
 
 	p := l.Prefix()
 	if p != "Test: " {
@@ -362,6 +428,52 @@ func TestEmptyPrintCreatesLine(t *testing.T) {
 	}
 }
 
+func TestAudit(t *testing.T) {
+	var b bytes.Buffer
+	l := New(FatalLevel, OOutput(&b))
+	l.Error("dropped")
+	l.Audit("user", "admin", "deleted", 42)
+	l.Auditf("role %s granted", "root")
+	want := "AUDIT user admin deleted 42\nAUDIT role root granted\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+// 采样、过滤器、去重等抑制功能都不影响审计日志
+func TestAuditNotSuppressed(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OSamplePerLevel(map[logLevel]float64{InfoLevel: 0, AuditLevel: 0}))
+	l.AddFilter(func(logLevel, string) bool { return false })
+	l.SuppressPattern(regexp.MustCompile("."))
+	for i := 0; i < 3; i++ {
+		l.Info("sampled")
+		l.Audit("login")
+		l.WithKey("k").Info("deduped")
+	}
+	l.Auditf("user %s", "tom")
+	if got, want := b.String(), "AUDIT login\nAUDIT login\nAUDIT login\nAUDIT user tom\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+	if n := l.SuppressedByLevel()[AuditLevel]; n != 0 {
+		t.Errorf("audit entries counted as suppressed: %d", n)
+	}
+}
+
+func TestAuditOutput(t *testing.T) {
+	var b, audit bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OAuditOutput(&audit), OFlag(Llevel|LlevelLabelColor))
+	l.Info("normal")
+	l.Extend().Audit("compliance")
+	if got, want := b.String(), "normal\n"; !strings.HasSuffix(got, want) || strings.Contains(got, "compliance") {
+		t.Errorf("normal output should not receive audit entries, got %q", got)
+	}
+	want := levelMap[AuditLevel].levelLabelColor + _AuditLabel + color_ + "compliance\n"
+	if got := audit.String(); got != want {
+		t.Errorf("audit output:\n got:  %q\n want: %q", got, want)
+	}
+}
+
 func BenchmarkItoa(b *testing.B) {
 	dst := make([]byte, 0, 64)
 	for i := 0; i < b.N; i++ {
@@ -379,7 +491,19 @@ func BenchmarkItoa(b *testing.B) {
 func BenchmarkPrint(b *testing.B) {
 	const testString = "Hello"
 	var buf bytes.Buffer
-	l := New(InfoLevel, OFlag(LstdFlags))
+	l := New(InfoLevel, OOutput(&buf), OFlag(LstdFlags))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		l.Info(testString)
+	}
+}
+
+// BenchmarkPrintOrdered 与 BenchmarkPrint 输出相同的项，但通过 OOrder 指定了输出顺序
+func BenchmarkPrintOrdered(b *testing.B) {
+	const testString = "Hello"
+	var buf bytes.Buffer
+	l := New(InfoLevel, OOutput(&buf), OFlag(LstdFlags), OOrder(OrderLevel, OrderPath, OrderDate, OrderTime, OrderMsg))
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		buf.Reset()
@@ -397,3 +521,937 @@ func BenchmarkPrintNoFlag(b *testing.B) {
 		l.Info(testString)
 	}
 }
+
+// BenchmarkTraceCallerMinLevel 与 BenchmarkTraceNoCaller 比较，OCallerMinLevel 跳过 runtime.Caller 后的开销
+func BenchmarkTraceCallerMinLevel(b *testing.B) {
+	var buf bytes.Buffer
+	l := New(TraceLevel, OOutput(&buf), OFlag(LstdFlags), OCallerMinLevel(WarnLevel))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		l.Trace("Hello")
+	}
+}
+
+func BenchmarkTraceNoCaller(b *testing.B) {
+	var buf bytes.Buffer
+	l := New(TraceLevel, OOutput(&buf), OFlag(LstdFlags&^Lshortfile))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		l.Trace("Hello")
+	}
+}
+
+// 分别以默认方式和 -tags elog_notrace,elog_nodebug 运行，比较关闭等级时调用的开销
+func BenchmarkTraceDisabled(b *testing.B) {
+	l := New(InfoLevel)
+	for i := 0; i < b.N; i++ {
+		l.Tracef("iteration %d of %d", i, b.N)
+	}
+}
+
+func BenchmarkDebugDisabled(b *testing.B) {
+	l := New(InfoLevel)
+	for i := 0; i < b.N; i++ {
+		l.Debug("iteration", i, "of", b.N)
+	}
+}
+
+func TestAlign(t *testing.T) {
+	stripColor := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	for _, flag := range []int{Llevel | Lshortfile | Lmsgprefix, Llevel | LlevelLabelColor | Lshortfile | Lmsgprefix | Lmsgcolor} {
+		var b bytes.Buffer
+		l := New(TraceLevel, OOutput(&b), OFlag(flag), OAlign(30))
+		l.Info("first")
+		l.SetPrefix("[db]")
+		l.Error("second")
+		l.SetPrefix("[a-very-long-prefix-exceeding-width]")
+		l.Warn("third")
+
+		lines := strings.Split(strings.TrimSuffix(stripColor.ReplaceAllString(b.String(), ""), "\n"), "\n")
+		want := 30
+		if flag&Lmsgcolor != 0 {
+			want++ // 消息颜色自带一个空格
+		}
+		for i, msg := range []string{"first", "second"} {
+			if col := strings.Index(lines[i], msg); col != want {
+				t.Errorf("flag %x: message %q should start at column %d, got %d in %q", flag, msg, want, col, lines[i])
+			}
+		}
+		if matched, _ := regexp.MatchString(`exceeding-width\] {1,2}third`, lines[2]); !matched {
+			t.Errorf("flag %x: header wider than the width should not be padded, got %q", flag, lines[2])
+		}
+	}
+}
+
+func TestAlignAuto(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lmsgprefix), OAlign(AlignAuto))
+	l.SetPrefix("[short]").Info("a")
+	l.SetPrefix("[much-longer]").Info("b")
+	l.SetPrefix("[short]").Info("c")
+	want := "[short] a\n[much-longer] b\n[short]       c\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	tests := map[string]int{
+		"":                              0,
+		"INFO ":                         5,
+		Warn_ + _WarnLabel + color_:     8,
+		"日志 ":                           3,
+		"\x1b[1;31;40m x\x1b[0m \x1b[K": 3,
+	}
+	for in, want := range tests {
+		if got := displayWidth([]byte(in)); got != want {
+			t.Errorf("displayWidth(%q): want %d, got %d", in, want, got)
+		}
+	}
+}
+
+func TestEmptyComponents(t *testing.T) {
+	tests := []struct {
+		name   string
+		flag   int
+		order  []logOrder
+		msg    string
+		want   string
+		prefix string
+	}{
+		{"empty message without header", 0, nil, "", "\n", ""},
+		{"empty prefix first", Lmsgprefix, []logOrder{OrderPrefix, OrderMsg}, "", "\n", ""},
+		{"empty prefix first with message", Lmsgprefix, []logOrder{OrderPrefix}, "hello", "hello\n", ""},
+		{"unset path first", Lmsgprefix, []logOrder{OrderPath, OrderPrefix}, "hello", "P hello\n", "P"},
+		{"unset path only", 0, []logOrder{OrderPath}, "", "\n", ""},
+		{"message first", Lmsgprefix | Llevel, []logOrder{OrderMsg, OrderLevel, OrderPrefix}, "hello", "hello INFO P\n", "P"},
+		{"empty message first", Lmsgprefix, []logOrder{OrderMsg, OrderPrefix}, "", "P\n", "P"},
+		{"empty message first empty prefix", Lmsgprefix, []logOrder{OrderMsg, OrderPrefix}, "", "\n", ""},
+	}
+	for _, tc := range tests {
+		var b bytes.Buffer
+		l := New(InfoLevel, OOutput(&b), OFlag(tc.flag), OPrefix(tc.prefix), OOrder(tc.order...))
+		l.Infof(tc.msg)
+		if got := b.String(); got != tc.want {
+			t.Errorf("%s:\n got:  %q\n want: %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNoTrailingSeparator(t *testing.T) {
+	tests := []struct {
+		name  string
+		flag  int
+		order []logOrder
+		msg   string
+		want  string
+	}{
+		{"path after message", Lshortfile | Lmsgprefix, []logOrder{OrderPrefix, OrderMsg, OrderPath}, "hello", "Test: hello elog_test.go:%d\n"},
+		{"level after message", Llevel, []logOrder{OrderMsg, OrderLevel}, "hello", "hello INFO\n"},
+		{"empty message with level", Llevel, nil, "", "INFO\n"},
+		{"empty colored message", Llevel | Lmsgcolor, nil, "", "INFO\n"},
+		{"empty colored message first", Llevel | Lmsgcolor, []logOrder{OrderMsg, OrderLevel}, "", "INFO\n"},
+		{"colored message", Lmsgcolor, nil, "hi", _cyan + "hi" + color_[:len(color_)-1] + "\n"},
+	}
+	for _, tc := range tests {
+		var b bytes.Buffer
+		l := New(InfoLevel, OOutput(&b), OFlag(tc.flag), OPrefix("Test: "), OOrder(tc.order...))
+		l.Infof(tc.msg)
+		// 行号用 %d 占位，只校验它是一个数字
+		pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(tc.want), "%d", `\d+`) + "$"
+		if got := b.String(); !regexp.MustCompile(pattern).MatchString(got) {
+			t.Errorf("%s:\n got:  %q\n want: %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestOrderNormalize(t *testing.T) {
+	captureInternal(t) // 未知项的警告不输出到标准错误
+	tests := []struct {
+		name  string
+		order []logOrder
+		want  []logOrder
+		out   string
+	}{
+		{"duplicates", []logOrder{OrderLevel, OrderLevel, OrderMsg, OrderLevel}, []logOrder{OrderLevel, OrderMsg}, "INFO hello P\n"},
+		{"unknown", []logOrder{logOrder("Datee"), OrderPrefix, logOrder("")}, []logOrder{OrderPrefix}, "P INFO hello\n"},
+		{"longer than fields", []logOrder{OrderMsg, OrderPath, OrderPrefix, OrderLevel, OrderTime, OrderDate, OrderMsg, OrderPrefix, OrderLevel}, []logOrder{OrderMsg, OrderPath, OrderPrefix, OrderLevel, OrderTime, OrderDate}, "hello P INFO\n"},
+		{"datetime", []logOrder{OrderLevel, OrderDateTime, OrderTime}, []logOrder{OrderLevel, OrderDate, OrderTime}, "INFO P hello\n"},
+	}
+	for _, tc := range tests {
+		var b bytes.Buffer
+		l := New(InfoLevel, OOutput(&b), OFlag(Llevel|Lmsgprefix), OPrefix("P"), OOrder(tc.order...))
+		if got := l.Order(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: OOrder want %v, got %v", tc.name, tc.want, got)
+		}
+		if got := l.SetOrder(tc.order...).Order(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: SetOrder want %v, got %v", tc.name, tc.want, got)
+		}
+		l.Info("hello")
+		if got := b.String(); got != tc.out {
+			t.Errorf("%s:\n got:  %q\n want: %q", tc.name, got, tc.out)
+		}
+	}
+}
+
+// 设置输出顺序的各处都同时更新编译后的 steps
+func TestCompiledOrder(t *testing.T) {
+	check := func(name string, l *Log) {
+		t.Helper()
+		var got []logOrder
+		for _, s := range l.steps {
+			got = append(got, orderList[s])
+		}
+		if !reflect.DeepEqual(got, l.order) {
+			t.Errorf("%s: steps %v, order %v", name, got, l.order)
+		}
+	}
+	l := New(InfoLevel, OOutput(io.Discard), OOrder(OrderMsg, OrderDateTime, OrderPath))
+	check("OOrder", l)
+	check("Extend", l.Extend())
+	check("OInherit", l.Extend(OInherit(InheritAll&^InheritOrder)))
+	l.SetOrder(OrderPrefix, OrderLevel)
+	check("SetOrder", l)
+	if err := l.SetOrderE(OrderLevel, OrderMsg, OrderTime); err != nil {
+		t.Fatal(err)
+	}
+	check("SetOrderE", l)
+	c := l.Config()
+	c.Order = []logOrder{OrderPath, OrderMsg}
+	if err := l.SetConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	check("SetConfig", l)
+	c.Order = nil
+	if err := l.SetConfig(c); err != nil || l.steps != nil {
+		t.Errorf("SetConfig(nil order): %v %v", err, l.steps)
+	}
+}
+
+func TestCheckOrder(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(Llevel), OOrder(OrderLevel, OrderMsg))
+	if err := l.CheckOrder(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	l.SetOrder(OrderDateTime, OrderLevel, OrderPath)
+	err := l.CheckOrder()
+	want := "elog: order has no effect: Date requires Ldate, Time requires Ltime or Lmicroseconds, Path requires Lshortfile or Llongfile"
+	if err == nil || err.Error() != want {
+		t.Errorf("want %q, got %v", want, err)
+	}
+	// Lmicroseconds 也能让 OrderTime 生效
+	if err := l.AddFlag(Ldate | Lmicroseconds | Lshortfile).CheckOrder(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	for name, want := range map[string]logOrder{"date": OrderDate, " Message ": OrderMsg, "DATETIME": OrderDateTime} {
+		if got, err := ParseOrder(name); err != nil || got != want {
+			t.Errorf("ParseOrder(%q): want %q, got %q %v", name, want, got, err)
+		}
+	}
+	if _, err := ParseOrder("Datee"); err == nil || err.Error() != `elog: unknown order "Datee"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSetOrderE(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard), OOrder(OrderLevel, OrderMsg))
+	err := l.SetOrderE(OrderMsg, logOrder("Datee"), logOrder("Lvl"))
+	if err == nil || err.Error() != `elog: unknown order "Datee", "Lvl"` {
+		t.Errorf("unexpected error %v", err)
+	}
+	if got := l.Order(); len(got) != 2 || got[0] != OrderLevel {
+		t.Errorf("an invalid order should not be applied, got %v", got)
+	}
+	if err := l.SetOrderE(OrderDateTime, OrderMsg); err != nil || len(l.Order()) != 3 {
+		t.Errorf("unexpected result %v %v", err, l.Order())
+	}
+}
+
+func TestUnknownOrderWarnsOnce(t *testing.T) {
+	b := captureInternal(t)
+	l := New(InfoLevel, OOutput(io.Discard), OOrder(OrderLevel, logOrder("Prefx")))
+	if got := l.Order(); len(got) != 1 || got[0] != OrderLevel {
+		t.Errorf("unknown items should be dropped, got %v", got)
+	}
+	l.Extend(OOrder(logOrder("Prefx")))
+	l.SetOrder(logOrder("Prefx"))
+	if got, want := b.String(), "WARN [elog] unknown order \"Prefx\", ignored\n"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestStrictConfig(t *testing.T) {
+	expectPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s should panic in strict mode", name)
+			}
+		}()
+		f()
+	}
+	expectPanic("OOrder", func() { New(InfoLevel, OStrictConfig(), OOrder(logOrder("Mesage"))) })
+	expectPanic("OOrder before OStrictConfig", func() { New(InfoLevel, OOrder(logOrder("Mesage")), OStrictConfig()) })
+	expectPanic("OLineEnding", func() { New(InfoLevel, OStrictConfig(), OLineEnding("\r")) })
+	l := New(InfoLevel, OOutput(io.Discard), OStrictConfig())
+	expectPanic("SetOrder", func() { l.SetOrder(logOrder("Mesage")) })
+	expectPanic("Extend", func() { l.Extend(OOrder(logOrder("Mesage"))) })
+}
+
+// failWriter 总是返回写入失败
+type failWriter struct{ err error }
+
+func (w failWriter) Write(p []byte) (int, error) { return 0, w.err }
+
+func TestWriteError(t *testing.T) {
+	errDiskFull := errors.New("disk full")
+	var handled []error
+	l := New(InfoLevel, OOutput(failWriter{errDiskFull}), OErrorHandler(func(err error) {
+		handled = append(handled, err)
+	}))
+
+	l.Info("lost")
+	if err := l.LogE(WarnLevel, "lost", "again"); !errors.Is(err, errDiskFull) {
+		t.Errorf("LogE should return the write error, got %v", err)
+	}
+	if err := l.LogfE(ErrorLevel, "lost %d", 3); !errors.Is(err, errDiskFull) {
+		t.Errorf("LogfE should return the write error, got %v", err)
+	}
+	if err := l.LogE(DebugLevel, "filtered"); err != nil {
+		t.Errorf("filtered entries should not return an error, got %v", err)
+	}
+	if len(handled) != 3 {
+		t.Errorf("expected 3 errors routed to the handler, got %d", len(handled))
+	}
+
+	// 错误处理函数在锁外调用，可以使用同一个 logger
+	var b bytes.Buffer
+	l.SetErrorHandler(func(err error) {
+		l.SetOutput(&b).Error("write failed:", err)
+	})
+	l.Info("lost")
+	if got, want := b.String(), "write failed: disk full\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestLevelRange(t *testing.T) {
+	skipCompiledOut(t, TraceLevel, DebugLevel)
+	var verbose, main bytes.Buffer
+	v := New(TraceLevel, OOutput(&verbose), OLevelRange(TraceLevel, DebugLevel))
+	m := New(InfoLevel, OOutput(&main))
+	for _, l := range []*Log{v, m} {
+		l.Trace("trace")
+		l.Debugf("debug")
+		l.Info("info")
+		l.Errorf("error")
+		l.With().Warn().Msg("warn")
+	}
+	if got, want := verbose.String(), "trace\ndebug\n"; got != want {
+		t.Errorf("verbose:\n got:  %q\n want: %q", got, want)
+	}
+	if got, want := main.String(), "info\nerror\nwarn\n"; got != want {
+		t.Errorf("main:\n got:  %q\n want: %q", got, want)
+	}
+	if min, max := v.Extend().LevelRange(); min != TraceLevel || max != DebugLevel {
+		t.Errorf("Extend should keep the range, got %v %v", min, max)
+	}
+	if min, max := m.LevelRange(); min != InfoLevel || max != FatalLevel {
+		t.Errorf("default max should be FatalLevel, got %v %v", min, max)
+	}
+
+	// 超出范围的 Fatal 和 Panic 不打印，但仍然退出或 panic；审计日志不受范围限制
+	verbose.Reset()
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	exited := false
+	osExit = func(int) { exited = true }
+	v.Fatal("fatal")
+	if !exited {
+		t.Error("Fatal should exit even when outside the range")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Panic should panic even when outside the range")
+			}
+		}()
+		v.Panicf("panic")
+	}()
+	v.Audit("audit")
+	if got, want := verbose.String(), "AUDIT audit\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+
+	verbose.Reset()
+	v.SetLevelRange(WarnLevel, ErrorLevel).Info("info")
+	v.Warn("warn")
+	v.Error("error")
+	if got, want := verbose.String(), "warn\nerror\n"; got != want {
+		t.Errorf("SetLevelRange:\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestWithPrefixName(t *testing.T) {
+	var b bytes.Buffer
+	app := New(InfoLevel, OOutput(&b), OName("app"), OPrefix("[app]"), OFlag(Lmsgprefix))
+	db := app.WithName("db").WithPrefix("[db]")
+	if db.Name() != "app.db" || db.Prefix() != "[db]" {
+		t.Errorf("got name %q prefix %q", db.Name(), db.Prefix())
+	}
+	if app.Name() != "app" || app.Prefix() != "[app]" {
+		t.Errorf("parent should be untouched, got name %q prefix %q", app.Name(), app.Prefix())
+	}
+	if got := New(InfoLevel).WithName("db").Name(); got != "db" {
+		t.Errorf("unnamed parent: got %q", got)
+	}
+	db.Info("query")
+	app.Info("start")
+	if got, want := b.String(), "[db] query\n[app] start\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	skipCompiledOut(t, DebugLevel)
+	l := New(InfoLevel, OOutput(io.Discard), OLevelRange(InfoLevel, ErrorLevel))
+	other := New(DebugLevel, OOutput(io.Discard))
+	tests := []struct {
+		name string
+		lg   Logger
+		want map[logLevel]bool
+	}{
+		{"log", l, map[logLevel]bool{DebugLevel: false, InfoLevel: true, FatalLevel: false, AuditLevel: true}},
+		{"ctx", l.Ctx(context.Background()), map[logLevel]bool{DebugLevel: false, InfoLevel: true}},
+		{"scope", l.BeginScope(), map[logLevel]bool{DebugLevel: false, InfoLevel: true}},
+		{"multi", Multi(l, other), map[logLevel]bool{TraceLevel: false, DebugLevel: true, InfoLevel: true}},
+	}
+	for _, tc := range tests {
+		for level, want := range tc.want {
+			if got := Enabled(tc.lg, level); got != want {
+				t.Errorf("%s: Enabled(%s) want %v, got %v", tc.name, levelName(level), want, got)
+			}
+		}
+	}
+	l.Mute()
+	if Enabled(l, ErrorLevel) || !Enabled(l, AuditLevel) {
+		t.Error("a muted logger only emits audit entries")
+	}
+	if !Enabled(struct{ Logger }{l}, TraceLevel) {
+		t.Error("loggers without Enabled should be treated as enabled")
+	}
+}
+
+// 判断等级时不加锁，与 SetLevel、SetLevelRange 并发时不应有数据竞争，在 -race 下运行
+func TestLevelRace(t *testing.T) {
+	var b bytes.Buffer
+	l := New(ErrorLevel, OOutput(&b))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if i%2 == 0 {
+				l.SetLevel(WarnLevel)
+			} else {
+				l.SetLevelRange(ErrorLevel, FatalLevel)
+			}
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		l.Info("filtered") // 总是被过滤，不经过加锁的写入路径
+		if l.Enabled(DebugLevel) || !l.Enabled(ErrorLevel) {
+			t.Fatal("level range out of sync")
+		}
+	}
+	<-done
+	if b.Len() != 0 {
+		t.Errorf("got %q", b.String())
+	}
+}
+
+func TestMute(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	l.Mute().Info("muted")
+	l.With().Warn().Msg("muted")
+	l.Audit("audit")
+	if !l.Muted() {
+		t.Error("Muted should report true after Mute")
+	}
+
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	exited := false
+	osExit = func(int) { exited = true }
+	l.Fatal("fatal")
+	if !exited {
+		t.Error("Fatal should exit while muted")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Panic should panic while muted")
+			}
+		}()
+		l.Panic("panic")
+	}()
+
+	l.Unmute().Info("unmuted")
+	if got, want := b.String(), "AUDIT audit\nunmuted\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+
+	// 并发输出时切换静默状态，配合 -race 运行
+	l.SetOutput(io.Discard)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Infof("line %d", j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if j%2 == 0 {
+					l.Mute()
+				} else {
+					l.Unmute()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	Mute()
+	defer Unmute()
+	if !Muted() || !Default().Muted() {
+		t.Error("package-level Mute should mute the default logger")
+	}
+}
+
+func TestRaw(t *testing.T) {
+	var b bytes.Buffer
+	var errs []error
+	l := New(InfoLevel, OOutput(&b), OFlag(LstdFlags|Lmsgprefix), OPrefix("P"))
+	l.Raw(`{"event":"start"}`)
+	l.Raw("already terminated\n")
+	l.Raw("")
+	if got, want := b.String(), "{\"event\":\"start\"}\nalready terminated\n\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+
+	b.Reset()
+	l.SetLevel(WarnLevel).Raw("filtered")
+	l.SetLevel(InfoLevel).Mute().Raw("muted")
+	if b.Len() != 0 {
+		t.Errorf("Raw should honor level and Mute, got %q", b.String())
+	}
+
+	errWrite := errors.New("disk full")
+	l = New(InfoLevel, OOutput(failWriter{errWrite}), OErrorHandler(func(err error) { errs = append(errs, err) }))
+	l.Raw("x")
+	if len(errs) != 1 || errs[0] != errWrite {
+		t.Errorf("expected write error to reach the handler, got %v", errs)
+	}
+
+	// 每条 Raw 只调用一次 Write，与普通日志交错时每一行都是完整的
+	var lines lineRecorder
+	l = New(InfoLevel, OOutput(&lines))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.Raw("raw line")
+				l.Info("info line")
+			}
+		}()
+	}
+	wg.Wait()
+	for _, line := range lines.lines {
+		if line != "raw line\n" && line != "info line\n" {
+			t.Fatalf("unexpected write %q", line)
+		}
+	}
+}
+
+// lineRecorder 记录每一次 Write 的内容
+type lineRecorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *lineRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, string(p))
+	return len(p), nil
+}
+
+func TestDateTimeFlags(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.FixedZone("CST", 8*3600))
+	west := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("NST", -(3*3600+30*60)))
+	tests := []struct {
+		name string
+		now  time.Time
+		opts []LogOption
+		want string
+	}{
+		{"iso", now, []LogOption{OFlag(Ldate | Ltime | Ldateiso)}, "2024-05-01T12:00:00+08:00 hi\n"},
+		{"iso micro", now, []LogOption{OFlag(Ldate | Lmicroseconds | Ldateiso)}, "2024-05-01T12:00:00.123456+08:00 hi\n"},
+		{"iso negative offset", west, []LogOption{OFlag(Ldate | Ltime | Ldateiso)}, "2024-05-01T12:00:00-03:30 hi\n"},
+		{"iso utc", now, []LogOption{OFlag(Ldate | Ltime | Ldateiso | LUTC)}, "2024-05-01T04:00:00+00:00 hi\n"},
+		{"iso date only", now, []LogOption{OFlag(Ldate | Ldateiso)}, "2024-05-01 hi\n"},
+		{"weekday", now, []LogOption{OFlag(Ldate | Ltime | Lweekday)}, "Wed 2024/05/01 12:00:00 hi\n"},
+		{"weekday without date", now, []LogOption{OFlag(Ltime | Lweekday)}, "12:00:00 hi\n"},
+		{"layout wins", now, []LogOption{OFlag(Ldate | Ltime | Ldateiso | Lweekday), OTimeLayout(time.RFC1123Z)}, "Wed, 01 May 2024 12:00:00 +0800 hi\n"},
+		{"layout needs a time flag", now, []LogOption{OFlag(Llevel), OTimeLayout(time.Kitchen)}, "INFO hi\n"},
+		{"fixed", now, []LogOption{OFlag(Ldate | Ltime | Lfixedtime)}, "2024/05/01 12:00:00        hi\n"},
+		{"fixed micro", now, []LogOption{OFlag(Ldate | Lmicroseconds | Lfixedtime)}, "2024/05/01 12:00:00.123456 hi\n"},
+		{"fixed iso", now, []LogOption{OFlag(Ldate | Ltime | Ldateiso | Lfixedtime)}, "2024-05-01T12:00:00+08:00        hi\n"},
+		{"fixed layout", now, []LogOption{OFlag(Ldate | Lfixedtime), OTimeLayout("Jan 2 15:04:05.999")}, "May 1 12:00:00.123  hi\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		now := tt.now
+		l := New(InfoLevel, append(tt.opts, OOutput(&buf), ONow(func() time.Time { return now }))...)
+		l.Info("hi")
+		if buf.String() != tt.want {
+			t.Errorf("%s: want %q, got %q", tt.name, tt.want, buf.String())
+		}
+	}
+}
+
+// TestFixedTimeColumns 合并不同 flag 的 logger 的输出，设置 Lfixedtime 后时间之后的各列在同一位置
+func TestFixedTimeColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []int
+		opts  []LogOption
+	}{
+		{"clock", []int{Ltime, Lmicroseconds}, nil},
+		{"date", []int{Ldate | Ltime, Ldate | Lmicroseconds}, nil},
+		{"iso", []int{Ldate | Ltime | Ldateiso, Ldate | Lmicroseconds | Ldateiso}, nil},
+		{"layout", []int{Ltime}, []LogOption{OTimeLayout(time.StampMilli[:len(time.StampMilli)-4] + ".999")}},
+	}
+	times := []time.Time{
+		time.Date(2024, 5, 1, 9, 5, 3, 0, time.UTC),
+		time.Date(2024, 9, 17, 12, 0, 0, 120000000, time.UTC),
+		time.Date(2024, 11, 30, 23, 59, 59, 999999999, time.UTC),
+	}
+	for _, tt := range tests {
+		var merged bytes.Buffer
+		for _, flag := range tt.flags {
+			for _, now := range times {
+				now := now
+				l := New(InfoLevel, append([]LogOption{OOutput(&merged), OFlag(flag | Llevel | Lfixedtime),
+					ONow(func() time.Time { return now })}, tt.opts...)...)
+				l.Info("msg")
+			}
+		}
+		lines := strings.Split(strings.TrimSuffix(merged.String(), "\n"), "\n")
+		col := strings.Index(lines[0], "INFO")
+		for _, line := range lines {
+			if strings.Index(line, "INFO") != col {
+				t.Errorf("%s: columns not aligned:\n%s", tt.name, merged.String())
+				break
+			}
+		}
+	}
+}
+
+// TestPackageFlagsConcurrent 通过包级函数并发修改默认 logger 的 flag 并输出日志，需配合 -race 运行
+func TestPackageFlagsConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	SetDefault(New(InfoLevel, OOutput(&buf), OFlag(Llevel)))
+	defer SetDefault(nil)
+
+	const rounds = 200
+	var wg sync.WaitGroup
+	// 每个 goroutine 反复添加、去掉互不相同的 flag，AddFlag、SubFlag 必须是原子的读-改-写
+	toggles := []int{Lshortfile, Lmsgprefix, Lmsgcolor}
+	for _, f := range toggles {
+		wg.Add(1)
+		go func(f int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				AddFlag(f)
+				SubFlag(f)
+			}
+			AddFlag(f)
+		}(f)
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				Info("msg")
+				Infof("msg")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want := Llevel | Lshortfile | Lmsgprefix | Lmsgcolor; Flag() != want {
+		t.Errorf("concurrent AddFlag/SubFlag lost updates: want %s, got %s", flagNames(want), flagNames(Flag()))
+	}
+	// 每一行的头部要么完整包含文件路径，要么完全没有，不能出现未获取 Caller 信息的路径
+	line := regexp.MustCompile(`^INFO (elog_test\.go:\d+ )?(\x1b\[[0-9;]*m )?msg( \x1b\[0m)?$`)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4*rounds*2 {
+		t.Fatalf("want %d lines, got %d", 4*rounds*2, len(lines))
+	}
+	for _, l := range lines {
+		if !line.MatchString(l) {
+			t.Fatalf("torn header: %q", l)
+		}
+	}
+}
+
+func TestZeroValueLog(t *testing.T) {
+	skipCompiledOut(t, DebugLevel)
+	f, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(stderr *os.File) { os.Stderr = stderr }(os.Stderr)
+	os.Stderr = f
+
+	var l Log
+	if !l.Enabled(InfoLevel) || l.Enabled(DebugLevel) {
+		t.Error("the zero value should log at InfoLevel")
+	}
+	l.Debug("hidden")
+	l.Info("hello")
+	l.Warnf("n=%d", 1)
+	l.With().Str("k", "v").Error().Msg("with")
+	l.Raw("raw")
+	got, _ := os.ReadFile(f.Name())
+	if want := "hello\nn=1\nwith k=v\nraw\n"; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	// 第一次输出之前做的设置保持不变
+	var b bytes.Buffer
+	var l2 Log
+	l2.SetOutput(&b).SetLevel(DebugLevel)
+	l2.Debug("debug")
+	if b.String() != "debug\n" {
+		t.Errorf("settings made before the first output should be kept, got %q", b.String())
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	osExit = func(int) { t.Error("a nil logger should not exit") }
+
+	var l *Log
+	l.Fatal("x")
+	l.Panic("x")
+	l.Error("x")
+	l.Warn("x")
+	l.Info("x")
+	l.Debug("x")
+	l.Trace("x")
+	l.Fatalf("%s", "x")
+	l.Panicf("%s", "x")
+	l.Errorf("%s", "x")
+	l.Warnf("%s", "x")
+	l.Infof("%s", "x")
+	l.Debugf("%s", "x")
+	l.Tracef("%s", "x")
+	l.FatalIf(true, "x")
+	l.PanicIf(true, "x")
+	l.ErrorIf(true, "x")
+	l.WarnIf(true, "x")
+	l.InfoIf(true, "x")
+	l.DebugIf(true, "x")
+	l.TraceIf(true, "x")
+	l.FatalfIf(true, "%s", "x")
+	l.PanicfIf(true, "%s", "x")
+	l.ErrorfIf(true, "%s", "x")
+	l.WarnfIf(true, "%s", "x")
+	l.InfofIf(true, "%s", "x")
+	l.DebugfIf(true, "%s", "x")
+	l.TracefIf(true, "%s", "x")
+	l.Audit("x")
+	l.Auditf("%s", "x")
+	l.Raw("x")
+	if err := l.Out(1, InfoLevel, "x"); err != nil {
+		t.Errorf("Out on a nil logger should return nil, got %v", err)
+	}
+	if err := l.LogE(ErrorLevel, "x"); err != nil {
+		t.Errorf("LogE on a nil logger should return nil, got %v", err)
+	}
+	if err := l.LogfE(ErrorLevel, "%s", "x"); err != nil {
+		t.Errorf("LogfE on a nil logger should return nil, got %v", err)
+	}
+	if l.Enabled(InfoLevel) || l.Enabled(AuditLevel) {
+		t.Error("a nil logger should not be enabled")
+	}
+
+	l.Must(errors.New("x"), "x")
+	l.Mustf(errors.New("x"), "%s", "x")
+	l.Throttle(time.Second).Info("x")
+	l.Banner()
+	if l.Muted() {
+		t.Error("a nil logger should not be muted")
+	}
+	if s := l.Stats(); s.Counts != nil || s.Async != nil {
+		t.Errorf("Stats on a nil logger should be empty, got %+v", s)
+	}
+	if err := l.Flush(); err != nil {
+		t.Errorf("Flush on a nil logger should return nil, got %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close on a nil logger should return nil, got %v", err)
+	}
+	// 退出或 panic 之前在另一个 goroutine 中 Flush、Close l，l 为 nil 时不应 panic
+	l.beforeExit()
+	l.beforePanic()
+}
+
+// orderWriter 把写入记录为自己的名字，用于检查输出的写入顺序
+type orderWriter struct {
+	name  string
+	order *[]string
+}
+
+func (w orderWriter) Write(p []byte) (int, error) {
+	*w.order = append(*w.order, w.name)
+	return len(p), nil
+}
+
+func TestOutputOrderAndNil(t *testing.T) {
+	var order []string
+	a, b, c := orderWriter{"a", &order}, orderWriter{"b", &order}, orderWriter{"c", &order}
+	var nilFile *os.File
+	tests := []struct {
+		name    string
+		w1      io.Writer
+		w       []io.Writer
+		want    []string
+		warning string
+	}{
+		{"single", a, nil, []string{"a"}, ""},
+		{"order", a, []io.Writer{b, c}, []string{"a", "b", "c"}, ""},
+		{"nil first", nil, []io.Writer{b, c}, []string{"b", "c"}, "nil writer at position 0"},
+		{"nil middle", a, []io.Writer{nil, c}, []string{"a", "c"}, "nil writer at position 1"},
+		{"nil last", a, []io.Writer{b, nil}, []string{"a", "b"}, "nil writer at position 2"},
+		{"typed nil", a, []io.Writer{nilFile, c}, []string{"a", "c"}, "nil writer at position 1"},
+		{"nil twice", nil, []io.Writer{b, nil}, []string{"b"}, "nil writer at position 0, 2"},
+		{"all nil", nil, []io.Writer{nil}, nil, ""},
+	}
+	for _, tt := range tests {
+		for _, how := range []string{"SetOutput", "OOutput"} {
+			warnings := captureInternal(t)
+			warnedConfig = sync.Map{}
+			var l *Log
+			if how == "SetOutput" {
+				l = New(InfoLevel, OOutput(orderWriter{"old", &order})).SetOutput(tt.w1, tt.w...)
+			} else {
+				l = New(InfoLevel, OOutput(orderWriter{"old", &order}), OOutput(tt.w1, tt.w...))
+			}
+			if tt.want == nil {
+				if len(l.writers) != 1 || l.writers[0] != os.Stderr {
+					t.Errorf("%s %s: want stderr when every writer is nil, got %v", how, tt.name, l.writers)
+				}
+			} else {
+				order = nil
+				l.Info("x")
+				if !reflect.DeepEqual(order, tt.want) {
+					t.Errorf("%s %s: want %v, got %v", how, tt.name, tt.want, order)
+				}
+			}
+			if got := warnings.String(); tt.warning == "" && got != "" || !strings.Contains(got, tt.warning) {
+				t.Errorf("%s %s: want warning %q, got %q", how, tt.name, tt.warning, got)
+			}
+		}
+	}
+}
+
+func TestCallerMinLevel(t *testing.T) {
+	skipCompiledOut(t, TraceLevel, DebugLevel)
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b), OFlag(Llevel|Lshortfile), OCallerMinLevel(WarnLevel))
+	var seen []Entry
+	l.Use(func(e *Entry) bool { seen = append(seen, *e); return true })
+	l.Trace("trace")
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	l.Audit("audit")
+	l.Extend().Debug("child")
+	want := regexp.MustCompile(`^TRACE trace
+DEBUG debug
+INFO info
+WARN elog_test\.go:\d+ warn
+ERROR elog_test\.go:\d+ error
+AUDIT elog_test\.go:\d+ audit
+DEBUG child
+$`)
+	if !want.MatchString(b.String()) {
+		t.Errorf("unexpected output:\n%s", b.String())
+	}
+	for _, e := range seen {
+		if hasFile := e.File != ""; hasFile != (e.Level >= WarnLevel) {
+			t.Errorf("%v: unexpected caller %q:%d", e.Level, e.File, e.Line)
+		}
+	}
+}
+
+func TestLineColor(t *testing.T) {
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b), OFlag(Llinecolor|Lmsgprefix|Llevel|LlevelLabelColor|LnameColor|Lmsgcolor), OPrefix("[api]"),
+		OOrder(OrderMsg, OrderLevel))
+	l.Warn("hello")
+	l.SetFlag(Llinecolor)
+	l.Info("")
+	l.Info("world ")
+	want := "\x1b[1;33;40mhello WARN [api]\x1b[0m\n" + // 颜色在消息之后的项之后结束
+		"\n" + // 空行不输出颜色
+		"\x1b[1;36;40mworld\x1b[0m\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	var header []byte
+	l = New(TraceLevel, OFlag(Llinecolor|Llevel|LlevelLabelColor))
+	l.FormatHeader(&header, time.Now(), ErrorLevel, "", 0)
+	if got := string(header); got != "ERROR " {
+		t.Errorf("header got %q, want %q", got, "ERROR ")
+	}
+}
+
+func TestSprint(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	l.Info("count=", 5)
+	l.Info(1, 2, "x")
+	p := New(InfoLevel, OOutput(&b), OSprint())
+	p.Info("count=", 5)
+	p.Info(1, 2, "x")
+	p.Ctx(context.Background()).Warn("ctx=", 1)
+	p.BeginScope().Error("scope=", 2)
+	p.InfoIf(true, "if=", 3)
+	p.ReadOnly().Info("ro=", 4)
+	p.Extend().Info("child=", 5)
+	want := "count= 5\n1 2 x\n" + // fmt.Sprintln：参数之间总是有空格
+		"count=5\n1 2x\n" + // fmt.Sprint：只在两个非字符串参数之间加空格
+		"ctx=1\nscope=2\nif=3\nro=4\nchild=5\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if v := recovered(func() { p.Panic("boom=", 6) }); v != "boom=6" {
+		t.Errorf("panic value %q", v)
+	}
+}
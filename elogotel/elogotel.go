@@ -0,0 +1,32 @@
+// Package elogotel 提供从 context 中提取 OpenTelemetry trace_id 和 span_id 的 elog.ContextExtractor，
+// 单独作为一个模块，使 elog 本身不依赖 OpenTelemetry：
+//
+//	l := elog.New(elog.InfoLevel, elog.OContextExtractor(elogotel.Extractor))
+//	l.Ctx(ctx).Info("handled")  // handled trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7
+package elogotel
+
+import (
+	"context"
+
+	"github.com/TCP404/elog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// Extractor 在 ctx 携带有效的 span 时返回 trace_id 和 span_id 两个字段，否则返回 nil
+func Extractor(ctx context.Context) []elog.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []elog.Field{
+		elog.String(TraceIDKey, sc.TraceID().String()),
+		elog.String(SpanIDKey, sc.SpanID().String()),
+	}
+}
+
+var _ elog.ContextExtractor = Extractor
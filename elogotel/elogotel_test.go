@@ -0,0 +1,35 @@
+package elogotel
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/TCP404/elog"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestExtractor(t *testing.T) {
+	var b bytes.Buffer
+	l := elog.New(elog.InfoLevel, elog.OOutput(&b), elog.OContextExtractor(Extractor))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	parent := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	// noop 的 TracerProvider 不会生成新的 id，但会沿用父 span 的有效 id
+	ctx, span := noop.NewTracerProvider().Tracer("test").Start(parent, "op")
+	defer span.End()
+
+	l.Ctx(ctx).Info("handled")
+	l.Ctx(context.Background()).Info("no span")
+
+	want := "handled trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7\nno span\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
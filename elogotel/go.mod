@@ -0,0 +1,15 @@
+module github.com/TCP404/elog/elogotel
+
+go 1.25.0
+
+require (
+	github.com/TCP404/elog v0.0.0
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	go.opentelemetry.io/otel v1.46.0 // indirect
+)
+
+replace github.com/TCP404/elog => ../
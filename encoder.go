@@ -0,0 +1,385 @@
+package elog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Encoder 将一条日志条目编码后追加到 buf 中并返回，编码结果应以换行符结尾。
+// flag 是 logger 当前的属性，Encoder 应按它决定输出哪些头部项；不支持颜色的 Encoder 忽略颜色相关的 flag 即可。
+// Encode 在持有 logger 锁的情况下调用，不能再使用同一个 logger 输出日志。
+type Encoder interface {
+	Encode(buf []byte, e *Entry, flag int) []byte
+}
+
+// JSONEncoder 将日志编码为一行 JSON 对象，如
+//
+//	{"time":"2022-01-02T15:04:05+08:00","level":"INFO","logger":"app","caller":"main.go:12","msg":"hello","user":"tom"}
+//
+// 头部项按 flag 输出，颜色相关的 flag 会被忽略。审计日志在等级之后带有 "audit":true。
+var JSONEncoder = NewJSONEncoder(EncoderConfig{})
+
+// LogfmtEncoder 将日志编码为一行 logfmt 格式的 key=value 序列，如
+//
+//	time=2022-01-02T15:04:05+08:00 level=INFO logger=app caller=main.go:12 msg="hello world" user=tom
+//
+// 含有空格、等号、引号或控制字符的值会加上引号，头部项按 flag 输出，颜色相关的 flag 会被忽略。审计日志带有 audit=true。
+var LogfmtEncoder = NewLogfmtEncoder(EncoderConfig{})
+
+// OutputOption 用于配置 AddOutput 添加的输出
+type OutputOption func(o *encodedOutput)
+
+// WithEncoder 设置输出使用的 Encoder，未设置时使用 logger 自身的文本格式
+func WithEncoder(enc Encoder) OutputOption {
+	return func(o *encodedOutput) {
+		o.enc = enc
+	}
+}
+
+// encodedOutput 是通过 AddOutput 添加、使用独立 Encoder 的输出
+type encodedOutput struct {
+	w      io.Writer
+	enc    Encoder
+	buf    []byte
+	header *uint32   // 非 0 表示已经写入过表头，与 Extend 派生的子 logger 共享，原子读写
+	color  ColorMode // 文本输出的颜色模式，见 Colors
+}
+
+// AddOutput 在已有输出之外再添加一个输出。通过 WithEncoder 指定 Encoder 时，该输出使用独立的格式，
+// 例如控制台使用带颜色的文本格式、文件使用 JSON 格式；每条日志只获取一次 Caller 信息，再分别编码到各个输出中。
+func (l *Log) AddOutput(w io.Writer, options ...OutputOption) *Log {
+	if w == nil {
+		return l
+	}
+	o := encodedOutput{w: w, header: new(uint32)}
+	for _, opt := range options {
+		opt(&o)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if o.enc == nil {
+		w = withColors(w, o.color)
+		l.writers = append(append([]io.Writer(nil), l.writers...), w)
+		l.output = newFanout(l.writers...)
+	} else {
+		l.encoded = append(l.encoded, &o)
+	}
+	l.syncCloser()
+	return l
+}
+
+// entryPool 缓存编码时使用的 Entry，Entry 会经过 Encoder 接口逃逸到堆上
+var entryPool = sync.Pool{
+	New: func() any { return new(Entry) },
+}
+
+// writeEncoded 将日志编码到每个使用独立 Encoder 的输出中，返回遇到的第一个写入错误。调用方需持有锁。
+func (l *Log) writeEncoded(meta *EntryMeta, flag int, entry *Entry) error {
+	e := entryPool.Get().(*Entry)
+	*e = *entry
+	e.Msg = strings.TrimSuffix(e.Msg, "\n")
+	var firstErr error
+	for _, o := range l.encoded {
+		w, ok := l.unwrapFilter(o.w, meta)
+		if !ok {
+			continue
+		}
+		o.buf = o.buf[:0]
+		if h, ok := o.enc.(HeaderEncoder); ok && atomic.CompareAndSwapUint32(o.header, 0, 1) {
+			o.buf = l.encodedLine(h.Header(o.buf), 0, o.enc)
+		}
+		start := len(o.buf)
+		buf, err := callEncoder(o.enc, o.buf, e, flag)
+		if err != nil {
+			l.notePanic(err)
+			buf = append(o.buf[:start], l.buf...) // 改为写入文本格式的日志
+		} else {
+			buf = l.encodedLine(buf, start, o.enc)
+		}
+		o.buf = buf
+		if err := l.writeTo(w, o.buf); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	*e = Entry{}
+	entryPool.Put(e)
+	return firstErr
+}
+
+// encodedLine 把编码器在 buf[start:] 末尾输出的 '\n' 替换为 OLineEnding 设置的行结束符。调用方需持有锁。
+func (l *Log) encodedLine(buf []byte, start int, enc Encoder) []byte {
+	if l.ending == "" || len(buf) == start || buf[len(buf)-1] != '\n' {
+		return buf
+	}
+	if _, ok := enc.(journalEncoder); ok {
+		return buf // journald 协议以 '\n' 分隔字段，不能修改
+	}
+	return appendLineEnding(buf[:len(buf)-1], l.ending)
+}
+
+// structEncoder 是 JSON 和 logfmt 两种结构化格式的共同实现，头部项的键名和格式由 EncoderConfig 决定
+type structEncoder struct {
+	cfg    EncoderConfig
+	logfmt bool
+}
+
+func (enc *structEncoder) Encode(buf []byte, e *Entry, flag int) []byte {
+	c := &enc.cfg
+	first := true
+	key := func(k string) {
+		if first {
+			first = false
+		} else if enc.logfmt {
+			buf = append(buf, ' ')
+		} else {
+			buf = append(buf, ',')
+		}
+		if enc.logfmt {
+			buf = appendLogfmtKey(buf, k)
+			buf = append(buf, '=')
+		} else {
+			buf = appendJSONString(buf, k)
+			buf = append(buf, ':')
+		}
+	}
+	str := func(s string) {
+		if enc.logfmt {
+			buf = appendLogfmtString(buf, s)
+		} else {
+			buf = appendJSONString(buf, s)
+		}
+	}
+
+	if !enc.logfmt {
+		buf = append(buf, '{')
+	}
+	if k := c.TimeKey; k != omitKey && flag&(Ldate|Ltime|Lmicroseconds) != 0 {
+		key(k)
+		buf = c.appendTime(buf, e.Time, flag, !enc.logfmt)
+	}
+	if k := c.LevelKey; k != omitKey && flag&Llevel != 0 {
+		key(k)
+		switch c.LevelEncoder {
+		case LevelNumber:
+			buf = strconv.AppendInt(buf, int64(e.Level), 10)
+		case LevelLowercase:
+			str(strings.ToLower(strings.TrimSpace(levelOf(e.Level).levelLabel)))
+		default:
+			str(strings.TrimSpace(levelOf(e.Level).levelLabel))
+		}
+	}
+	if k := c.SeverityKey; k != omitKey && c.Severity != nil && flag&Llevel != 0 {
+		key(k)
+		buf = strconv.AppendInt(buf, int64(c.Severity(e.Level)), 10)
+	}
+	if e.Level == AuditLevel {
+		key("audit") // 便于按字段筛选审计日志，不必依赖等级的名称
+		buf = append(buf, "true"...)
+	}
+	if k := c.NameKey; k != omitKey && e.LoggerName != "" {
+		key(k)
+		str(e.LoggerName)
+	}
+	if k := c.PrefixKey; k != omitKey && flag&Lmsgprefix != 0 && e.Prefix != "" {
+		key(k)
+		str(e.Prefix)
+	}
+	if k := c.CallerKey; k != omitKey && (flag&(Lshortfile|Llongfile) != 0 || c.CallerEncoder != CallerFlag && e.File != "") {
+		file, fn := e.File, e.Func
+		if c.CallerEncoder == CallerShort || c.CallerEncoder == CallerFlag && flag&Lshortfile != 0 {
+			file, fn = shortPath(file), shortFuncName(fn)
+		}
+		key(k)
+		if c.CallerObject && !enc.logfmt {
+			buf = append(buf, `{"file":`...)
+			buf = appendJSONString(buf, file)
+			buf = append(buf, `,"line":`...)
+			buf = strconv.AppendInt(buf, int64(e.Line), 10)
+			if fn != "" {
+				buf = append(buf, `,"func":`...)
+				buf = appendJSONString(buf, fn)
+			}
+			buf = append(buf, '}')
+		} else {
+			str(file + ":" + strconv.Itoa(e.Line))
+		}
+	}
+	if k := c.MessageKey; k != omitKey {
+		key(k)
+		str(e.Msg)
+	}
+	fields := e.Fields
+	if c.SortFields {
+		fields = sortedFields(fields)
+	}
+	for _, f := range fields {
+		key(f.Key)
+		if !enc.logfmt {
+			buf = appendFieldJSON(buf, f)
+			if errs := fieldErrors(f); errs != nil {
+				key(f.Key + "s") // 合并错误的各个错误另外以数组输出，如 Err 的 "errors"
+				buf = appendErrorsJSON(buf, errs, 0)
+			}
+			continue
+		}
+		switch f.Kind {
+		case KindString:
+			buf = appendLogfmtString(buf, f.str)
+		case KindInt64, KindFloat64, KindBool, KindDuration, KindTime:
+			buf = appendFieldText(buf, f) // 这些类型的文本形式不会包含需要加引号的字符
+		default:
+			start := len(buf)
+			buf = appendFieldText(buf, f)
+			if v := string(buf[start:]); logfmtNeedsQuote(v) {
+				buf = appendJSONString(buf[:start], v)
+			}
+		}
+	}
+	if !enc.logfmt {
+		buf = append(buf, '}')
+	}
+	return append(buf, '\n')
+}
+
+// appendLogfmtKey 追加键，键中的空格、等号、引号和控制字符替换为 '_'
+func appendLogfmtKey(buf []byte, k string) []byte {
+	if k == "" {
+		return append(buf, '_')
+	}
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendLogfmtString 追加值，需要时以 JSON 字符串的规则加引号和转义
+func appendLogfmtString(buf []byte, s string) []byte {
+	if logfmtNeedsQuote(s) {
+		return appendJSONString(buf, s)
+	}
+	return append(buf, s...)
+}
+
+func logfmtNeedsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+			return true
+		}
+	}
+	return !utf8.ValidString(s)
+}
+
+// appendJSONValue 将 v 编码为 JSON 值追加到 buf 中，无法编码的值以 fmt.Sprint 的结果作为字符串输出
+func appendJSONValue(buf []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, v)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case float64:
+		return appendJSONFloat(buf, v, 64)
+	case float32:
+		return appendJSONFloat(buf, float64(v), 32)
+	case time.Duration:
+		return appendJSONString(buf, v.String())
+	case time.Time:
+		return appendJSONString(buf, v.Format(time.RFC3339Nano))
+	case error:
+		return appendJSONString(buf, v.Error())
+	case fmt.Stringer:
+		return appendJSONString(buf, v.String())
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return appendJSONString(buf, fmt.Sprint(v))
+	}
+	return append(buf, b...)
+}
+
+// appendJSONFloat 追加浮点数，NaN 和 ±Inf 在 JSON 中没有对应的表示，以字符串输出
+func appendJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, bitSize))
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// jsonNeedsEscape 报告 b 放进 JSON 字符串时是否需要转义或替换
+func jsonNeedsEscape(b []byte) bool {
+	for _, c := range b {
+		if c < 0x20 || c == '"' || c == '\\' || c >= utf8.RuneSelf {
+			return true // 非 ASCII 字符交给 appendJSONString 处理非法的 UTF-8 和行分隔符
+		}
+	}
+	return false
+}
+
+// appendJSONString 将 s 按 RFC 8259 编码为带引号的 JSON 字符串追加到 buf 中：引号、反斜杠和控制字符被转义
+// （没有简写形式的控制字符写为 \u00XX），非法的 UTF-8 字节逐个替换为 U+FFFD，结果总是合法的 JSON 和 UTF-8
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < 0x20:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf = append(buf, `\ufffd`...)
+		case r == '\u2028' || r == '\u2029': // 某些 JavaScript 解析器不接受未转义的行分隔符
+			buf = append(buf, `\u202`...)
+			buf = append(buf, hexDigits[r&0xf])
+		default:
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
@@ -0,0 +1,214 @@
+package elog
+
+import (
+	"strconv"
+	"time"
+)
+
+// omitKey 作为 EncoderConfig 中的键名时表示不输出该项
+const omitKey = "-"
+
+// EncoderConfig 配置 JSON 和 logfmt 编码器输出的键名以及时间、等级、文件路径的格式，零值即为 JSONEncoder、
+// LogfmtEncoder 使用的默认配置。键名为空时使用默认键名，为 "-" 时不输出该项。
+// 各头部项是否输出仍由 logger 的 flag 决定，如没有 Llevel 时不输出等级。
+type EncoderConfig struct {
+	TimeKey     string // 默认为 "time"
+	LevelKey    string // 默认为 "level"
+	NameKey     string // 默认为 "logger"
+	PrefixKey   string // 默认为 "prefix"
+	CallerKey   string // 默认为 "caller"
+	MessageKey  string // 默认为 "msg"
+	SeverityKey string // 默认为 "severity"
+
+	TimeEncoder  TimeEncoder
+	TimeLayout   string // TimeEncoder 为 TimeLayoutFormat 时使用的格式
+	LevelEncoder LevelEncoder
+	// Severity 不为空时在等级之后以数字输出它返回的严重程度，如 SyslogSeverity、OTelSeverity；
+	// 只需要数字形式时可以把 LevelKey 设置为 "-"
+	Severity      SeverityMapper
+	CallerEncoder CallerEncoder
+	// CallerObject 为 true 时 JSON 编码器把文件路径输出为 {"file":"server.go","line":42,"func":"main.handleLogin"}，
+	// 便于按文件、函数查询；默认输出为 "server.go:42"。函数名的长短与文件路径一致，logfmt 编码器忽略此项。
+	CallerObject bool
+	// SortFields 为 true 时字段按键排序后输出（键相同的字段保持原来的顺序），不论字段来自哪里；
+	// 默认按字段追加的顺序输出
+	SortFields bool
+}
+
+// TimeEncoder 决定时间的输出格式
+type TimeEncoder int
+
+const (
+	TimeFlag         TimeEncoder = iota // 按 flag 决定格式：Ldate 输出日期，Ltime 输出时间，都有时为 RFC 3339
+	TimeRFC3339                         // RFC 3339，精确到秒
+	TimeRFC3339Nano                     // RFC 3339，精确到纳秒
+	TimeEpochSeconds                    // Unix 时间戳，单位为秒，输出为数字
+	TimeEpochMillis                     // Unix 时间戳，单位为毫秒，输出为数字
+	TimeEpochNanos                      // Unix 时间戳，单位为纳秒，输出为数字
+	TimeLayoutFormat                    // 使用 EncoderConfig.TimeLayout 格式化
+)
+
+// LevelEncoder 决定等级的输出格式
+type LevelEncoder int
+
+const (
+	LevelString    LevelEncoder = iota // 大写的等级名称，如 "INFO"
+	LevelLowercase                     // 小写的等级名称，如 "info"
+	LevelNumber                        // 等级的数值，如 InfoLevel 为 30（早期版本中为 3，见 InfoLevel 等常量的说明）
+)
+
+// CallerEncoder 决定文件路径的输出格式
+type CallerEncoder int
+
+const (
+	CallerFlag  CallerEncoder = iota // 按 flag 决定，Lshortfile 输出文件名，Llongfile 输出完整路径
+	CallerShort                      // 总是输出文件名，只要获取了文件路径（设置了 Lshortfile 或 Llongfile）
+	CallerLong                       // 总是输出完整路径，只要获取了文件路径（设置了 Lshortfile 或 Llongfile）
+)
+
+// NewJSONEncoder 返回按 cfg 配置的 JSON 编码器
+func NewJSONEncoder(cfg EncoderConfig) Encoder {
+	return &structEncoder{cfg: cfg.withDefaults()}
+}
+
+// NewLogfmtEncoder 返回按 cfg 配置的 logfmt 编码器
+func NewLogfmtEncoder(cfg EncoderConfig) Encoder {
+	return &structEncoder{cfg: cfg.withDefaults(), logfmt: true}
+}
+
+func (c EncoderConfig) withDefaults() EncoderConfig {
+	for _, k := range []struct {
+		key *string
+		def string
+	}{
+		{&c.TimeKey, "time"},
+		{&c.LevelKey, "level"},
+		{&c.NameKey, "logger"},
+		{&c.PrefixKey, "prefix"},
+		{&c.CallerKey, "caller"},
+		{&c.MessageKey, "msg"},
+		{&c.SeverityKey, "severity"},
+	} {
+		if *k.key == "" {
+			*k.key = k.def
+		}
+	}
+	if c.TimeEncoder == TimeLayoutFormat && c.TimeLayout == "" {
+		c.TimeEncoder = TimeFlag
+	}
+	return c
+}
+
+// appendTime 按配置追加时间，quote 为 true 时字符串形式的时间会加上引号
+func (c *EncoderConfig) appendTime(buf []byte, t time.Time, flag int, quote bool) []byte {
+	layout, ok := c.timeLayout(flag)
+	if !ok {
+		return c.appendEpoch(buf, t)
+	}
+	if !quote {
+		if s := t.Format(layout); logfmtNeedsQuote(s) {
+			return appendJSONString(buf, s)
+		}
+		return t.AppendFormat(buf, layout)
+	}
+	start := len(buf)
+	buf = append(buf, '"')
+	buf = t.AppendFormat(buf, layout)
+	if jsonNeedsEscape(buf[start+1:]) { // 自定义的格式中可能含有引号或控制字符
+		return appendJSONString(buf[:start], string(buf[start+1:]))
+	}
+	return append(buf, '"')
+}
+
+// appendTimeText 按配置追加不加引号、不转义的时间
+func (c *EncoderConfig) appendTimeText(buf []byte, t time.Time, flag int) []byte {
+	layout, ok := c.timeLayout(flag)
+	if !ok {
+		return c.appendEpoch(buf, t)
+	}
+	return t.AppendFormat(buf, layout)
+}
+
+// timeLayout 返回时间的格式，时间以数字形式的 Unix 时间戳输出时 ok 为 false
+func (c *EncoderConfig) timeLayout(flag int) (layout string, ok bool) {
+	switch c.TimeEncoder {
+	case TimeEpochSeconds, TimeEpochMillis, TimeEpochNanos:
+		return "", false
+	case TimeRFC3339:
+		return time.RFC3339, true
+	case TimeRFC3339Nano:
+		return time.RFC3339Nano, true
+	case TimeLayoutFormat:
+		return c.TimeLayout, true
+	}
+	return jsonTimeLayout(flag), true
+}
+
+func (c *EncoderConfig) appendEpoch(buf []byte, t time.Time) []byte {
+	switch c.TimeEncoder {
+	case TimeEpochSeconds:
+		return strconv.AppendInt(buf, t.Unix(), 10)
+	case TimeEpochMillis:
+		return strconv.AppendInt(buf, t.UnixNano()/int64(time.Millisecond), 10)
+	}
+	return strconv.AppendInt(buf, t.UnixNano(), 10)
+}
+
+// jsonTimeLayout 按 flag 返回时间的格式，日期和时间都没有设置时返回空字符串
+func jsonTimeLayout(flag int) string {
+	clock := "15:04:05"
+	if flag&Lmicroseconds != 0 {
+		clock = "15:04:05.000000"
+	}
+	switch {
+	case flag&Ldate != 0 && flag&(Ltime|Lmicroseconds) != 0:
+		return "2006-01-02T" + clock + "Z07:00"
+	case flag&Ldate != 0:
+		return "2006-01-02"
+	case flag&(Ltime|Lmicroseconds) != 0:
+		return clock
+	}
+	return ""
+}
+
+// SeverityMapper 把日志等级映射为数字形式的严重程度，用于 EncoderConfig.Severity
+type SeverityMapper func(level logLevel) int
+
+// SyslogSeverity 返回 level 对应的 RFC 5424 严重程度，数字越小越严重：
+// Fatal、Panic 为 2（Critical），Error 为 3，Warn 为 4，Audit 为 5（Notice），Info 为 6，Debug、Trace 为 7。
+// 其它等级（如 RegisterLevel 注册的自定义等级）按不高于它的内置等级处理：低于 Trace 的视为 Trace，高于 Audit 的视为 Fatal。
+func SyslogSeverity(level logLevel) int {
+	switch {
+	case level < InfoLevel:
+		return 7
+	case level < WarnLevel:
+		return 6
+	case level < ErrorLevel:
+		return 4
+	case level < PanicLevel:
+		return 3
+	case level == AuditLevel:
+		return 5
+	}
+	return 2
+}
+
+// OTelSeverity 返回 level 对应的 OpenTelemetry SeverityNumber（1–24，数字越大越严重）：
+// Trace 为 1，Debug 为 5，Info 为 9，Warn 为 13，Error 为 17，Panic 为 21，Fatal 为 22，Audit 为 10（INFO2）。
+// 其它等级（如 RegisterLevel 注册的自定义等级）在所在区间内按与内置等级的距离递增，如 Info 与 Warn 之间的等级为 10–12：
+// 低于 Trace 的视为 Trace，高于 Audit 的视为 Fatal。
+func OTelSeverity(level logLevel) int {
+	switch {
+	case level <= TraceLevel:
+		return 1
+	case level == AuditLevel:
+		return 10
+	case level >= FatalLevel:
+		return 22
+	case level >= PanicLevel:
+		return 21
+	}
+	// Trace、Debug、Info、Warn、Error 各占 4 个数字，间隔中的等级取区间内的后 3 个
+	base := level / levelStep
+	return int(base-1)*4 + 1 + int(level%levelStep*3+levelStep-1)/levelStep
+}
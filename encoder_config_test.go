@@ -0,0 +1,167 @@
+package elog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoderConfigJSON(t *testing.T) {
+	now := time.Date(2024, 3, 5, 6, 7, 8, 9000000, time.UTC)
+	cfg := EncoderConfig{
+		TimeKey:       "@timestamp",
+		LevelKey:      "severity",
+		MessageKey:    "message",
+		CallerKey:     "src",
+		NameKey:       "-",
+		TimeEncoder:   TimeEpochMillis,
+		LevelEncoder:  LevelNumber,
+		CallerEncoder: CallerLong,
+	}
+	var buf bytes.Buffer
+	l := New(InfoLevel, OOutput(io.Discard), ONow(func() time.Time { return now }), OName("svc"),
+		OFlag(Ldate|Ltime|Llevel|Lshortfile)).AddOutput(&buf, WithEncoder(NewJSONEncoder(cfg)))
+	l.With().Str("user", "tom").Msg("hello")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if got["@timestamp"] != float64(now.UnixNano()/int64(time.Millisecond)) {
+		t.Errorf("@timestamp: got %v", got["@timestamp"])
+	}
+	if got["severity"] != float64(InfoLevel) {
+		t.Errorf("severity: got %v", got["severity"])
+	}
+	if got["message"] != "hello" || got["user"] != "tom" {
+		t.Errorf("message and fields should be kept, got %v", got)
+	}
+	if src, _ := got["src"].(string); !filepath.IsAbs(src) || !strings.Contains(src, "encoder_config_test.go:") {
+		t.Errorf("src should be the long path despite Lshortfile, got %q", src)
+	}
+	for _, k := range []string{"time", "level", "msg", "caller", "logger"} {
+		if _, ok := got[k]; ok {
+			t.Errorf("default key %q should not be present, got %v", k, got)
+		}
+	}
+}
+
+func TestEncoderConfigLogfmt(t *testing.T) {
+	now := time.Date(2024, 3, 5, 6, 7, 8, 0, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		name string
+		cfg  EncoderConfig
+		want string
+	}{
+		{"default", EncoderConfig{}, "time=2024-03-05T06:07:08+08:00 level=WARN msg=hi\n"},
+		{"rfc3339", EncoderConfig{TimeEncoder: TimeRFC3339, LevelEncoder: LevelLowercase}, "time=2024-03-05T06:07:08+08:00 level=warn msg=hi\n"},
+		{"epoch", EncoderConfig{TimeKey: "ts", TimeEncoder: TimeEpochSeconds}, "ts=1709590028 level=WARN msg=hi\n"},
+		{"layout", EncoderConfig{TimeEncoder: TimeLayoutFormat, TimeLayout: "Jan 2 15:04"}, `time="Mar 5 06:07" level=WARN msg=hi` + "\n"},
+		{"omit", EncoderConfig{TimeKey: "-", LevelKey: "lvl", MessageKey: "m"}, "lvl=WARN m=hi\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(InfoLevel, OOutput(io.Discard), ONow(func() time.Time { return now }), OFlag(Ldate|Ltime|Llevel)).
+				AddOutput(&buf, WithEncoder(NewLogfmtEncoder(tt.cfg)))
+			l.Warn("hi")
+			if buf.String() != tt.want {
+				t.Errorf("want %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestEncoderConfigCallerObject(t *testing.T) {
+	var nested, flat bytes.Buffer
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(Lshortfile)).
+		AddOutput(&nested, WithEncoder(NewJSONEncoder(EncoderConfig{CallerObject: true}))).
+		AddOutput(&flat, WithEncoder(JSONEncoder))
+	l.Info("hello")
+	_, _, line, _ := runtime.Caller(0)
+	line--
+
+	var got struct {
+		Caller struct {
+			File string `json:"file"`
+			Line int    `json:"line"`
+			Func string `json:"func"`
+		} `json:"caller"`
+	}
+	if err := json.Unmarshal(nested.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", nested.String(), err)
+	}
+	want := "elog.TestEncoderConfigCallerObject"
+	if got.Caller.File != "encoder_config_test.go" || got.Caller.Line != line || got.Caller.Func != want {
+		t.Errorf("want {encoder_config_test.go %d %s}, got %+v", line, want, got.Caller)
+	}
+
+	var flatGot map[string]any
+	if err := json.Unmarshal(flat.Bytes(), &flatGot); err != nil {
+		t.Fatalf("invalid JSON %q: %v", flat.String(), err)
+	}
+	if want := "encoder_config_test.go:" + strconv.Itoa(line); flatGot["caller"] != want {
+		t.Errorf("flat caller: want %q, got %v", want, flatGot["caller"])
+	}
+
+	// 中间件看到的 Entry 同样带有函数名
+	var fn string
+	l.Use(func(e *Entry) bool { fn = e.Func; return true })
+	l.Info("again")
+	if !strings.HasSuffix(fn, "/elog.TestEncoderConfigCallerObject") {
+		t.Errorf("Entry.Func should be the full function name, got %q", fn)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		level        logLevel
+		syslog, otel int
+	}{
+		{Discard, 7, 1}, // 未定义的低等级视为 Trace
+		{TraceLevel, 7, 1},
+		{DebugLevel, 7, 5},
+		{InfoLevel, 6, 9},
+		{WarnLevel, 4, 13},
+		{ErrorLevel, 3, 17},
+		{PanicLevel, 2, 21},
+		{FatalLevel, 2, 22},
+		{AuditLevel, 5, 10},
+		{AuditLevel + 1, 2, 22}, // 未定义的高等级视为 Fatal
+		{InfoLevel + 5, 6, 11},  // 内置等级之间的自定义等级
+		{ErrorLevel + 1, 3, 18},
+		{PanicLevel + 5, 2, 21},
+	}
+	for _, tt := range tests {
+		if got := SyslogSeverity(tt.level); got != tt.syslog {
+			t.Errorf("SyslogSeverity(%d): want %d, got %d", tt.level, tt.syslog, got)
+		}
+		if got := OTelSeverity(tt.level); got != tt.otel {
+			t.Errorf("OTelSeverity(%d): want %d, got %d", tt.level, tt.otel, got)
+		}
+	}
+
+	var js, lf bytes.Buffer
+	custom := func(level logLevel) int { return int(level) * 10 }
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(Llevel)).
+		AddOutput(&js, WithEncoder(NewJSONEncoder(EncoderConfig{Severity: SyslogSeverity}))).
+		AddOutput(&lf, WithEncoder(NewLogfmtEncoder(EncoderConfig{Severity: custom, SeverityKey: "sev", LevelKey: "-"})))
+	l.Warn("disk almost full")
+
+	var got map[string]any
+	if err := json.Unmarshal(js.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", js.String(), err)
+	}
+	if got["level"] != "WARN" || got["severity"] != float64(4) {
+		t.Errorf("want level WARN and severity 4, got %v", got)
+	}
+	if want := "sev=400 msg=\"disk almost full\"\n"; lf.String() != want {
+		t.Errorf("want %q, got %q", want, lf.String())
+	}
+}
@@ -0,0 +1,228 @@
+package elog
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HeaderEncoder 由需要在输出开头写入表头的 Encoder 实现。AddOutput 添加的每个输出在写入第一条日志时
+// 先调用一次 Header，表头与第一条日志在同一次 Write 中写入；通过 Extend 派生的子 logger 与父 logger 共享这一状态，
+// 同一个输出只会写入一次表头。
+type HeaderEncoder interface {
+	Encoder
+	Header(buf []byte) []byte
+}
+
+// CSVColumn 是 CSV 编码器的一列
+type CSVColumn string
+
+const (
+	CSVTime   CSVColumn = "ts"     // 时间，格式由 CSVConfig.TimeEncoder 决定，按 flag 决定时没有日期和时间时为空
+	CSVLevel  CSVColumn = "level"  // 等级名称，如 INFO，没有 Llevel 时为空
+	CSVName   CSVColumn = "name"   // logger 名称
+	CSVPrefix CSVColumn = "prefix" // 前缀，没有 Lmsgprefix 时为空
+	CSVFile   CSVColumn = "file"   // 文件路径，长短按 Lshortfile、Llongfile 决定，没有获取文件路径时为空
+	CSVLine   CSVColumn = "line"   // 行号，没有获取文件路径时为空
+	CSVMsg    CSVColumn = "msg"    // 消息
+	CSVFields CSVColumn = "fields" // 没有在 CSVConfig.FieldKeys 中单独成列的字段，以 logfmt 格式的 key=value 放在同一格
+)
+
+// defaultCSVColumns 是 CSVConfig.Columns 为空时使用的列
+var defaultCSVColumns = []CSVColumn{CSVTime, CSVLevel, CSVName, CSVFile, CSVLine, CSVMsg, CSVFields}
+
+// CSVConfig 配置 CSV 编码器，零值为：逗号分隔，列为 ts、level、name、file、line、msg、fields，不输出表头。
+type CSVConfig struct {
+	Columns []CSVColumn // 输出的列及其顺序
+	// FieldKeys 中的每个键单独成为一列，列名即键名，排在 Columns 之后；日志中没有该字段时为空。
+	// 同一条日志中重复的键以最后一个为准。
+	FieldKeys []string
+	Comma     rune // 分隔符，默认为 ','，不能是引号、换行符或 utf8.RuneError
+	Header    bool // 是否在每个输出的开头写入一行列名
+	// SortFields 为 true 时 fields 列中的字段按键排序，见 EncoderConfig.SortFields
+	SortFields bool
+
+	TimeEncoder TimeEncoder
+	TimeLayout  string // TimeEncoder 为 TimeLayoutFormat 时使用的格式
+}
+
+// csvEncoder 按 encoding/csv 的规则转义：含有分隔符、引号、换行符或以空白开头的值加上引号，引号写为两个引号
+type csvEncoder struct {
+	columns []CSVColumn
+	keys    []string
+	comma   rune
+	header  bool
+	sort    bool
+	time    EncoderConfig // 只使用其中的 TimeEncoder 和 TimeLayout
+}
+
+var _ HeaderEncoder = &csvEncoder{}
+
+// NewCSVEncoder 返回按 cfg 配置的 CSV 编码器，每条日志编码为一行，以 '\n' 结尾，从不输出颜色。
+// 输出可以直接被 encoding/csv 的 Reader 或电子表格软件读取：
+//
+//	l.AddOutput(f, elog.WithEncoder(elog.NewCSVEncoder(elog.CSVConfig{Header: true, FieldKeys: []string{"user"}})))
+func NewCSVEncoder(cfg CSVConfig) Encoder {
+	enc := &csvEncoder{
+		columns: cfg.Columns,
+		keys:    append([]string(nil), cfg.FieldKeys...),
+		comma:   cfg.Comma,
+		header:  cfg.Header,
+		sort:    cfg.SortFields,
+		time:    EncoderConfig{TimeEncoder: cfg.TimeEncoder, TimeLayout: cfg.TimeLayout}.withDefaults(),
+	}
+	if len(enc.columns) == 0 {
+		enc.columns = defaultCSVColumns
+	}
+	enc.columns = append([]CSVColumn(nil), enc.columns...)
+	if enc.comma == 0 || enc.comma == '"' || enc.comma == '\r' || enc.comma == '\n' || enc.comma == utf8.RuneError {
+		enc.comma = ','
+	}
+	return enc
+}
+
+// Header 追加列名所在的一行，未开启 CSVConfig.Header 时不追加任何内容
+func (enc *csvEncoder) Header(buf []byte) []byte {
+	if !enc.header {
+		return buf
+	}
+	for i, c := range enc.columns {
+		buf = enc.sep(buf, i)
+		buf = enc.appendCell(buf, string(c))
+	}
+	for i, k := range enc.keys {
+		buf = enc.sep(buf, len(enc.columns)+i)
+		buf = enc.appendCell(buf, k)
+	}
+	return append(buf, '\n')
+}
+
+func (enc *csvEncoder) Encode(buf []byte, e *Entry, flag int) []byte {
+	for i, c := range enc.columns {
+		buf = enc.sep(buf, i)
+		switch c {
+		case CSVTime:
+			if flag&(Ldate|Ltime|Lmicroseconds) != 0 || enc.time.TimeEncoder != TimeFlag {
+				buf = enc.appendCell(buf, string(enc.time.appendTimeText(nil, e.Time, flag)))
+			}
+		case CSVLevel:
+			if flag&Llevel != 0 {
+				buf = enc.appendCell(buf, strings.TrimSpace(levelOf(e.Level).levelLabel))
+			}
+		case CSVName:
+			buf = enc.appendCell(buf, e.LoggerName)
+		case CSVPrefix:
+			if flag&Lmsgprefix != 0 {
+				buf = enc.appendCell(buf, e.Prefix)
+			}
+		case CSVFile:
+			if e.File != "" {
+				file := e.File
+				if flag&Lshortfile != 0 {
+					file = shortPath(file)
+				}
+				buf = enc.appendCell(buf, file)
+			}
+		case CSVLine:
+			if e.File != "" {
+				buf = strconv.AppendInt(buf, int64(e.Line), 10)
+			}
+		case CSVMsg:
+			buf = enc.appendCell(buf, e.Msg)
+		case CSVFields:
+			buf = enc.appendCell(buf, string(enc.appendRest(nil, e.Fields)))
+		}
+	}
+	for i, k := range enc.keys {
+		buf = enc.sep(buf, len(enc.columns)+i)
+		for j := len(e.Fields) - 1; j >= 0; j-- {
+			if e.Fields[j].Key == k {
+				buf = enc.appendCell(buf, string(appendFieldText(nil, e.Fields[j])))
+				break
+			}
+		}
+	}
+	return append(buf, '\n')
+}
+
+// appendRest 以 logfmt 格式追加没有单独成列的字段
+func (enc *csvEncoder) appendRest(buf []byte, fields []Field) []byte {
+	if enc.sort {
+		fields = sortedFields(fields)
+	}
+	for _, f := range fields {
+		if enc.hasKey(f.Key) {
+			continue
+		}
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = appendLogfmtKey(buf, f.Key)
+		buf = append(buf, '=')
+		start := len(buf)
+		buf = appendFieldText(buf, f)
+		if v := string(buf[start:]); logfmtNeedsQuote(v) {
+			buf = appendJSONString(buf[:start], v)
+		}
+	}
+	return buf
+}
+
+func (enc *csvEncoder) hasKey(key string) bool {
+	for _, k := range enc.keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sep 在第 i 列（从 0 开始）之前追加分隔符
+func (enc *csvEncoder) sep(buf []byte, i int) []byte {
+	if i == 0 {
+		return buf
+	}
+	return utf8.AppendRune(buf, enc.comma)
+}
+
+// appendCell 追加一格，需要时加上引号并把引号写为两个引号
+func (enc *csvEncoder) appendCell(buf []byte, s string) []byte {
+	if !enc.needsQuotes(s) {
+		return append(buf, s...)
+	}
+	buf = append(buf, '"')
+	for {
+		i := strings.IndexByte(s, '"')
+		if i < 0 {
+			break
+		}
+		buf = append(buf, s[:i+1]...)
+		buf = append(buf, '"')
+		s = s[i+1:]
+	}
+	buf = append(buf, s...)
+	return append(buf, '"')
+}
+
+// needsQuotes 与 encoding/csv 的 Writer 规则一致
+func (enc *csvEncoder) needsQuotes(s string) bool {
+	if s == "" {
+		return false
+	}
+	if s == `\.` {
+		return true
+	}
+	if enc.comma < utf8.RuneSelf {
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			if c == '\n' || c == '\r' || c == '"' || c == byte(enc.comma) {
+				return true
+			}
+		}
+	} else if strings.ContainsRune(s, enc.comma) || strings.ContainsAny(s, "\"\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(r)
+}
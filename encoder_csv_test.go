@@ -0,0 +1,72 @@
+package elog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVEncoder(t *testing.T) {
+	var file bytes.Buffer
+	now := time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+	enc := NewCSVEncoder(CSVConfig{Header: true, FieldKeys: []string{"user"}, TimeEncoder: TimeRFC3339})
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}), OFlag(Llevel|Lshortfile|Lmsgcolor), OName("svc"), ONow(func() time.Time { return now })).
+		AddOutput(&file, WithEncoder(enc))
+	l.With().Str("user", `tom "the cat", jr.`).Int("n", 3).Msg("hello, world")
+	l.Warn("line one\nline two")
+	l.Extend(OName("child")).Info(" leading space")
+
+	if strings.Contains(file.String(), "\x1b") {
+		t.Errorf("CSV output should not contain color codes, got %q", file.String())
+	}
+	records, err := csv.NewReader(&file).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("want a header and 3 records, got %q", records)
+	}
+	if want := []string{"ts", "level", "name", "file", "line", "msg", "fields", "user"}; !reflect.DeepEqual(records[0], want) {
+		t.Errorf("header: want %q, got %q", want, records[0])
+	}
+	want := [][]string{
+		{"2022-01-02T15:04:05Z", "INFO", "svc", "encoder_csv_test.go", "", "hello, world", "n=3", `tom "the cat", jr.`},
+		{"2022-01-02T15:04:05Z", "WARN", "svc", "encoder_csv_test.go", "", "line one\nline two", "", ""},
+		{"2022-01-02T15:04:05Z", "INFO", "child", "encoder_csv_test.go", "", " leading space", "", ""},
+	}
+	for i, w := range want {
+		got := records[i+1]
+		if got[4] == "" {
+			t.Errorf("record %d: missing line number", i)
+		}
+		got[4] = ""
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("record %d:\n got:  %q\n want: %q", i, got, w)
+		}
+	}
+}
+
+func TestCSVEncoderColumns(t *testing.T) {
+	enc := NewCSVEncoder(CSVConfig{Columns: []CSVColumn{CSVLevel, CSVMsg, CSVFields}, Comma: ';'})
+	e := &Entry{Level: ErrorLevel, Msg: "a;b", Fields: []Field{String("k", "v w"), Bool("ok", false)}}
+	got := string(enc.Encode(nil, e, Llevel))
+	if want := "ERROR;\"a;b\";\"k=\"\"v w\"\" ok=false\"\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+	if h := enc.(HeaderEncoder).Header(nil); len(h) != 0 {
+		t.Errorf("header should be empty unless enabled, got %q", h)
+	}
+
+	r := csv.NewReader(strings.NewReader(got))
+	r.Comma = ';'
+	record, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ERROR", "a;b", `k="v w" ok=false`}; !reflect.DeepEqual(record, want) {
+		t.Errorf("want %q, got %q", want, record)
+	}
+}
@@ -0,0 +1,241 @@
+package elog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddOutputEncoder(t *testing.T) {
+	var console, file bytes.Buffer
+	l := New(InfoLevel, OOutput(&console), OFlag(Llevel|Lshortfile|Lmsgcolor|LlevelLabelColor|Lmsgprefix), OPrefix("[app]"), OName("svc")).
+		AddOutput(&file, WithEncoder(JSONEncoder))
+	l.Use(func(e *Entry) bool {
+		e.Fields = append(e.Fields, String("user", "tom"), Field{Key: "n", Value: 3}, Err(errors.New("boom")))
+		return true
+	})
+	l.Warn("hello \"world\"\n")
+
+	text := console.String()
+	if !strings.Contains(text, "\x1b[") {
+		t.Errorf("console output should be colored, got %q", text)
+	}
+	if strings.Contains(file.String(), "\x1b") {
+		t.Errorf("JSON output should not contain color codes, got %q", file.String())
+	}
+	if !strings.HasSuffix(file.String(), "}\n") || strings.Count(file.String(), "\n") != 1 {
+		t.Errorf("JSON output should be a single line, got %q", file.String())
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(file.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", file.String(), err)
+	}
+	want := map[string]any{
+		"level":  "WARN",
+		"logger": "svc",
+		"prefix": "[app]",
+		"msg":    `hello "world"`,
+		"user":   "tom",
+		"n":      float64(3),
+		"error":  "boom",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: want %v, got %v", k, v, got[k])
+		}
+	}
+	caller, _ := got["caller"].(string)
+	if !strings.HasPrefix(caller, "encoder_test.go:") || !strings.Contains(text, caller+" ") {
+		t.Errorf("both outputs should share the caller, JSON %q, text %q", caller, text)
+	}
+	if _, ok := got["time"]; ok {
+		t.Errorf("time should follow the flags, got %v", got["time"])
+	}
+}
+
+func TestAddOutputPlain(t *testing.T) {
+	var a, b bytes.Buffer
+	l := New(InfoLevel, OOutput(&a)).AddOutput(&b).AddOutput(nil)
+	l.Info("hello")
+	if a.String() != "hello\n" || b.String() != "hello\n" {
+		t.Errorf("got %q and %q", a.String(), b.String())
+	}
+}
+
+func TestAddOutputExtendAndClose(t *testing.T) {
+	var console bytes.Buffer
+	file := &closeRecorder{}
+	parent := New(InfoLevel, OOutput(&console)).AddOutput(file, WithEncoder(JSONEncoder))
+	son := parent.Extend(OName("son"))
+	son.Info("from son")
+	if !strings.Contains(file.String(), `"logger":"son","msg":"from son"`) {
+		t.Errorf("Extend should keep encoded outputs, got %q", file.String())
+	}
+	if err := parent.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if file.flushed != 1 || file.closed != 1 {
+		t.Errorf("encoded output should be flushed and closed once, got %d %d", file.flushed, file.closed)
+	}
+}
+
+func TestAddOutputAudit(t *testing.T) {
+	var file, audit bytes.Buffer
+	l := New(ErrorLevel, OOutput(io.Discard)).AddOutput(&file, WithEncoder(JSONEncoder))
+	l.Audit("login")
+	if !strings.Contains(file.String(), `"level":"AUDIT"`) {
+		t.Errorf("audit entry should carry the AUDIT level, got %q", file.String())
+	}
+
+	file.Reset()
+	l = New(ErrorLevel, OOutput(io.Discard), OAuditOutput(&audit)).AddOutput(&file, WithEncoder(JSONEncoder))
+	l.Audit("login")
+	if file.Len() != 0 || audit.Len() == 0 {
+		t.Errorf("audit entries should only go to the audit output, got %q and %q", file.String(), audit.String())
+	}
+}
+
+// 结构化格式中审计日志带有 audit 标记，普通日志没有
+func TestAuditTag(t *testing.T) {
+	var js, lf bytes.Buffer
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(0)).
+		AddOutput(&js, WithEncoder(JSONEncoder)).
+		AddOutput(&lf, WithEncoder(LogfmtEncoder))
+	l.Info("normal")
+	l.Audit("login")
+
+	lines := strings.Split(strings.TrimSpace(js.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 JSON lines, got %q", js.String())
+	}
+	for i, want := range []any{nil, true} {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(lines[i]), &m); err != nil {
+			t.Fatalf("invalid JSON %q: %v", lines[i], err)
+		}
+		if m["audit"] != want {
+			t.Errorf("line %d: audit = %v, want %v in %q", i, m["audit"], want, lines[i])
+		}
+	}
+	if got, want := lf.String(), "msg=normal\nlevel=AUDIT audit=true msg=login\n"; got != want {
+		t.Errorf("logfmt:\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestJSONTimeLayout(t *testing.T) {
+	tests := []struct {
+		flag int
+		want string
+	}{
+		{0, ""},
+		{Ldate, "2006-01-02"},
+		{Ltime, "15:04:05"},
+		{Ltime | Lmicroseconds, "15:04:05.000000"},
+		{Ldate | Ltime, "2006-01-02T15:04:05Z07:00"},
+	}
+	for _, tc := range tests {
+		if got := jsonTimeLayout(tc.flag); got != tc.want {
+			t.Errorf("flag %b: want %q, got %q", tc.flag, tc.want, got)
+		}
+	}
+}
+
+func TestAppendJSONValue(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{nil, `null`},
+		{"a\tb\x01\u2028", `"a\tb\u0001\u2028"`},
+		{"\xff", `"\ufffd"`},
+		{true, `true`},
+		{-7, `-7`},
+		{uint64(7), `7`},
+		{1.5, `1.5`},
+		{math.Inf(1), `"+Inf"`},
+		{[]int{1, 2}, `[1,2]`},
+		{make(chan int), ``}, // 无法编码时退化为字符串
+	}
+	for _, tc := range tests {
+		got := string(appendJSONValue(nil, tc.v))
+		if tc.want == "" {
+			if !json.Valid([]byte(got)) {
+				t.Errorf("%v: invalid JSON %q", tc.v, got)
+			}
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%v: want %s, got %s", tc.v, tc.want, got)
+		}
+	}
+}
+
+func TestJSONTimeLayoutEscaped(t *testing.T) {
+	enc := NewJSONEncoder(EncoderConfig{TimeEncoder: TimeLayoutFormat, TimeLayout: "2006-01-02 \"15\"\t04"})
+	e := &Entry{Time: time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC), Msg: "m"}
+	got := enc.Encode(nil, e, Ldate)
+	if want := `{"time":"2022-01-02 \"15\"\t04","msg":"m"}` + "\n"; string(got) != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+// FuzzJSONEncoder 把任意字节作为消息、前缀、名称和字段传给 Info，JSON 输出的每一行都必须是合法的 JSON，
+// 且消息与 encoding/json 编码的结果一致：
+//
+//	go test -fuzz FuzzJSONEncoder -run xxx .
+func FuzzJSONEncoder(f *testing.F) {
+	for _, seed := range []string{"hello", "quote \" and \\", "ctl \x00\x01\x1f\x7f", "bad \xff\xfe utf8", "\u2028\u2029", "line\nbreak\r\n", "\xed\xa0\x80", ""} {
+		f.Add([]byte(seed), seed)
+	}
+	f.Fuzz(func(t *testing.T, msg []byte, s string) {
+		var out bytes.Buffer
+		l := New(InfoLevel, OOutput(io.Discard), OFlag(LstdFlags|Lmsgprefix), OPrefix(s), OName(s)).
+			AddOutput(&out, WithEncoder(JSONEncoder))
+		defer l.Close()
+		l.With().Str(s, s).Any("any", s).Msg(string(msg))
+		l.Info(string(msg))
+
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("want 2 lines, got %q", out.String())
+		}
+		// Msg 去掉消息末尾的一个换行符，Info 的消息末尾由 Sprintln 补上的换行符同样被去掉
+		for i, m := range []string{strings.TrimSuffix(string(msg), "\n"), string(msg)} {
+			line := lines[i]
+			if !json.Valid([]byte(line)) {
+				t.Fatalf("invalid JSON line %q", line)
+			}
+			want, _ := json.Marshal(m)
+			var wantMsg string
+			json.Unmarshal(want, &wantMsg)
+			var got struct{ Msg string }
+			json.Unmarshal([]byte(line), &got)
+			if got.Msg != wantMsg {
+				t.Errorf("msg: want %q, got %q", wantMsg, got.Msg)
+			}
+		}
+	})
+}
+
+// 两个基准对比单一文本输出和文本加 JSON 输出，Caller 信息只获取一次，额外开销只有 JSON 编码本身
+func BenchmarkOutputText(b *testing.B) {
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(LstdFlags))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("Hello")
+	}
+}
+
+func BenchmarkOutputTextJSON(b *testing.B) {
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(LstdFlags)).AddOutput(io.Discard, WithEncoder(JSONEncoder))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("Hello")
+	}
+}
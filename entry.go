@@ -0,0 +1,47 @@
+package elog
+
+import "time"
+
+// Entry 是一条日志的结构化表示。Out 为每条日志填充一个 Entry，中间件、Reporter、Encoder 拿到的都是它，
+// 文本格式的各项也由它渲染，因此中间件对任意字段（包括 LoggerName、Prefix）的修改都会反映到所有输出中。
+type Entry struct {
+	Time       time.Time
+	Level      logLevel
+	LoggerName string
+	Prefix     string
+	File       string // 未设置 Lshortfile 或 Llongfile 时为空
+	Line       int
+	Func       string // 调用处的完整函数名，如 github.com/TCP404/elog.TestEntry，与 File 同时获取
+	Msg        string // 不含末尾的换行符
+	Fields     []Field
+	Stack      string // 调用栈，只有交给 Reporter 的日志条目才会填充
+}
+
+// Middleware 在日志格式化之前、不持有锁的情况下执行，可以修改 e（改写消息、追加字段等），
+// 返回 false 时丢弃这条日志。审计日志不会被丢弃，但仍会经过中间件。
+type Middleware func(e *Entry) bool
+
+// Use 按顺序注册中间件，多个中间件按注册顺序依次执行，任意一个返回 false 都会丢弃日志
+func (l *Log) Use(middlewares ...Middleware) *Log {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// 总是分配新的切片，Out 中拿到的旧切片不会被修改
+	mws := make([]Middleware, 0, len(l.middlewares)+len(middlewares))
+	mws = append(mws, l.middlewares...)
+	l.middlewares = append(mws, middlewares...)
+	return l
+}
+
+// apply 依次执行中间件，返回 false 表示日志被丢弃
+func (e *Entry) apply(middlewares []Middleware) (bool, error) {
+	for _, mw := range middlewares {
+		ok, err := callMiddleware(mw, e)
+		if err != nil {
+			return true, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,83 @@
+package elog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestUseDrop(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	l.Use(func(e *Entry) bool {
+		return !strings.Contains(e.Msg, "context canceled")
+	})
+	l.Info("request failed: context canceled")
+	l.Info("request done")
+	l.Audit("context canceled") // 审计日志不会被丢弃
+	if got, want := b.String(), "request done\nAUDIT context canceled\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestUseMutate(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OName("app"), OFlag(Lshortfile))
+	l.Use(
+		func(e *Entry) bool {
+			e.Msg = strings.ReplaceAll(e.Msg, "hunter2", "******")
+			return true
+		},
+		func(e *Entry) bool {
+			// 中间件在锁外执行，可以安全地调用 logger 的方法
+			e.Fields = append(e.Fields, Field{Key: "logger", Value: l.Name()}, Field{Key: "line", Value: e.Line})
+			return true
+		},
+	)
+	l.Warn("password", "hunter2")
+	if got, want := b.String(), "entry_test.go:37 password ****** logger=app line=37\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestUseOrderAndExtend(t *testing.T) {
+	var b bytes.Buffer
+	var calls []string
+	l := New(InfoLevel, OOutput(&b)).Use(
+		func(e *Entry) bool { calls = append(calls, "first"); return true },
+		func(e *Entry) bool { calls = append(calls, "second"); return true },
+	)
+	child := l.Extend()
+	child.Use(func(e *Entry) bool { calls = append(calls, "child"); return false })
+
+	l.Info("parent")
+	child.Info("child")
+	if got, want := strings.Join(calls, ","), "first,second,first,second,child"; got != want {
+		t.Errorf("middlewares run in wrong order: got %s, want %s", got, want)
+	}
+	if got, want := b.String(), "parent\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestUseRendersEntry(t *testing.T) {
+	var text, js bytes.Buffer
+	l := New(InfoLevel, OOutput(&text), OPrefix("[app]"), OFlag(Llevel|Lmsgprefix|Lshortfile)).
+		AddOutput(&js, WithEncoder(JSONEncoder))
+	l.Use(func(e *Entry) bool {
+		if !strings.HasSuffix(e.Func, ".TestUseRendersEntry") {
+			t.Errorf("Entry.Func should be the caller, got %q", e.Func)
+		}
+		e.Prefix = "[tenant-1]"
+		e.File, e.Line = "/src/handler.go", 7
+		e.Level = WarnLevel
+		return true
+	})
+	l.Info("hello")
+	if want := "WARN handler.go:7 [tenant-1] hello\n"; text.String() != want {
+		t.Errorf("text: want %q, got %q", want, text.String())
+	}
+	if want := `{"level":"WARN","prefix":"[tenant-1]","caller":"handler.go:7","msg":"hello"}` + "\n"; js.String() != want {
+		t.Errorf("JSON: want %q, got %q", want, js.String())
+	}
+}
@@ -0,0 +1,105 @@
+package elog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrorStack 以 ErrorLevel 输出 err 及其完整的错误链，err 为 nil 时什么也不做。
+//
+// 首行为 msg 和 err 的错误信息，之后沿 errors.Unwrap 每一层错误单独缩进一行，只显示这一层自己添加的内容。
+// 带有调用栈的错误（如 github.com/pkg/errors 创建的错误，通过 StackTrace 方法识别）会在行尾标出创建位置；
+// 实现了 fmt.Formatter 但没有调用栈的错误以 %+v 的格式输出详细信息，并认为它已包含了之后的错误链。
+// 合并错误（如 errors.Join 的结果）在首行以 "; " 连接，之后合并前的每个错误单独一行，以 "- " 开头，嵌套的合并错误递归缩进。
+//
+//	ERROR main.go:20 load config: open app.yaml: permission denied
+//	    load config [at main.load main.go:12]
+//	    open app.yaml
+//	    permission denied
+func (l *Log) ErrorStack(err error, msg ...any) {
+	if err == nil || !l.enabled(ErrorLevel) {
+		return
+	}
+	l.Out(defaultCallDepth, ErrorLevel, errorStack(err, msg))
+}
+
+func errorStack(err error, msg []any) string {
+	var sb strings.Builder
+	if len(msg) > 0 {
+		sb.WriteString(strings.TrimSuffix(fmt.Sprintln(msg...), "\n"))
+		sb.WriteString(": ")
+	}
+	sb.WriteString(flatError(err))
+
+	var (
+		lines  []string
+		origin string // 没有自身内容的一层（如 pkg/errors 的 withStack）的创建位置，交给下一层显示
+	)
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if o := errorOrigin(e); o != "" {
+			origin = o
+		}
+		if _, multi := e.(multiError); multi {
+			// 合并前的每个错误单独一行，以 "- " 开头，嵌套的合并错误继续缩进
+			_, errs, _ := splitMulti(e)
+			for _, sub := range errs {
+				lines = append(lines, "- "+string(appendError(nil, sub, 1)))
+			}
+			break
+		}
+		if _, ok := e.(fmt.Formatter); ok && origin == "" {
+			if detail := fmt.Sprintf("%+v", e); detail != e.Error() {
+				lines = append(lines, strings.Split(strings.TrimSuffix(detail, "\n"), "\n")...)
+				break
+			}
+		}
+		text := e.Error()
+		if cause := errors.Unwrap(e); cause != nil {
+			text = strings.TrimSuffix(strings.TrimSuffix(text, cause.Error()), ": ")
+		}
+		if text == "" {
+			continue
+		}
+		if origin != "" {
+			text += " [at " + origin + "]"
+			origin = ""
+		}
+		lines = append(lines, text)
+	}
+	// 没有包装、没有调用栈的普通错误，首行已经包含了全部信息
+	if len(lines) > 1 || len(lines) == 1 && lines[0] != err.Error() {
+		for _, line := range lines {
+			sb.WriteString("\n    ")
+			sb.WriteString(line)
+		}
+	}
+	return sb.String()
+}
+
+// errorOrigin 返回带调用栈的错误的创建位置，形如 "main.load main.go:12"，没有调用栈时返回空字符串。
+// StackTrace 方法需返回以 uintptr 为元素的切片，pkg/errors 的 errors.StackTrace 就是这样，每个元素是返回地址加一。
+func errorOrigin(err error) string {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return ""
+	}
+	t := m.Type().Out(0)
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uintptr {
+		return ""
+	}
+	st := m.Call(nil)[0]
+	if st.Len() == 0 {
+		return ""
+	}
+	pc := uintptr(st.Index(0).Uint()) - 1
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+	file, line := fn.FileLine(pc)
+	return shortFuncName(fn.Name()) + " " + shortPath(file) + ":" + strconv.Itoa(line)
+}
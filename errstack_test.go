@@ -0,0 +1,79 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"testing"
+)
+
+// stackFrame 和 stackErr 模仿 github.com/pkg/errors 的 Frame 和 withStack
+type stackFrame uintptr
+
+type stackErr struct {
+	error
+	stack []stackFrame
+}
+
+func withStack(err error) error {
+	pcs := make([]uintptr, 8)
+	n := runtime.Callers(2, pcs)
+	st := make([]stackFrame, n)
+	for i, pc := range pcs[:n] {
+		st[i] = stackFrame(pc)
+	}
+	return &stackErr{err, st}
+}
+
+func (e *stackErr) Unwrap() error              { return e.error }
+func (e *stackErr) StackTrace() []stackFrame   { return e.stack }
+func (e *stackErr) Format(s fmt.State, c rune) { fmt.Fprint(s, e.Error()) }
+
+// detailErr 实现了 fmt.Formatter，%+v 时输出更多细节
+type detailErr struct{}
+
+func (detailErr) Error() string { return "disk full" }
+func (detailErr) Format(s fmt.State, c rune) {
+	if s.Flag('+') {
+		fmt.Fprint(s, "disk full\ndevice=/dev/sda1 free=0")
+		return
+	}
+	fmt.Fprint(s, "disk full")
+}
+
+func loadConfig() error {
+	return withStack(fmt.Errorf("load config: %w", fmt.Errorf("open app.yaml: %w", errors.New("permission denied"))))
+}
+
+func TestErrorStack(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Llevel))
+
+	l.ErrorStack(nil, "nothing")
+	l.ErrorStack(errors.New("plain"))
+	l.ErrorStack(fmt.Errorf("save: %w", detailErr{}), "saving", "state")
+	got := b.String()
+	want := "ERROR plain\n" +
+		"ERROR saving state: save: disk full\n    save\n    disk full\n    device=/dev/sda1 free=0\n"
+	if got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+
+	b.Reset()
+	l.ErrorStack(loadConfig(), "startup failed")
+	pattern := `^ERROR startup failed: load config: open app.yaml: permission denied\n` +
+		`    load config \[at elog.loadConfig errstack_test.go:\d+\]\n` +
+		`    open app.yaml\n` +
+		`    permission denied\n$`
+	if ok, _ := regexp.MatchString(pattern, b.String()); !ok {
+		t.Errorf("\n got:     %q\n pattern: %q", b.String(), pattern)
+	}
+
+	b.Reset()
+	l.SetLevel(FatalLevel).ErrorStack(loadConfig())
+	if b.Len() != 0 {
+		t.Errorf("disabled level should not write, got %q", b.String())
+	}
+}
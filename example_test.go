@@ -1,8 +1,12 @@
+//go:build !elog_notrace && !elog_nodebug
+
+// 部分示例的输出包含 Trace、Debug 日志，以 elog_notrace、elog_nodebug 构建时不运行这些示例
 package elog
 
 import (
 	"bytes"
 	"fmt"
+	"time"
 )
 
 func ExamplePrint() {
@@ -17,11 +21,11 @@ func ExamplePrint() {
 	fmt.Println(b.String())
 
 	// Output:
-	// example_test.go:12 This is the TRACE level. It is often used to print loop variables.
-	// example_test.go:13 This is the DEBUG level. It is usually used for sequential debugging.
-	// example_test.go:14 This is the INFO level. It is often used to print some infomation such as database connected.
-	// example_test.go:15 This is the WARN level. It is usually used to print some warning infomation.
-	// example_test.go:16 This is the ERROR level. It is often used for print some error infomation.
+	// example_test.go:16 This is the TRACE level. It is often used to print loop variables.
+	// example_test.go:17 This is the DEBUG level. It is usually used for sequential debugging.
+	// example_test.go:18 This is the INFO level. It is often used to print some infomation such as database connected.
+	// example_test.go:19 This is the WARN level. It is usually used to print some warning infomation.
+	// example_test.go:20 This is the ERROR level. It is often used for print some error infomation.
 }
 
 func ExampleSetOrder() {
@@ -32,10 +36,28 @@ func ExampleSetOrder() {
 		OOrder(OrderPrefix, OrderMsg, OrderPath),
 	)
 	l.Info("You can set the output order by SetOrder().")
-	fmt.Println(b.String())
+	fmt.Print(b.String())
+
+	// 消息末尾的换行符会被去掉，排在消息之后的文件路径仍在同一行
+	b.Reset()
+	l.SetOrder(OrderMsg, OrderPath, OrderPrefix).AddFlag(Llevel)
+	l.Infof("Message ends with a newline.\n")
+	l.Info("Sprintln", "message")
+	fmt.Print(b.String())
+
+	// OrderDateTime 把日期和时间放在一起，设置了 Lmicroseconds 时时间带有微秒
+	b.Reset()
+	now := time.Date(2022, 1, 2, 15, 4, 5, 123456000, time.UTC)
+	l = New(InfoLevel, OOutput(&b), ONow(func() time.Time { return now }),
+		OFlag(Ldate|Lmicroseconds|Llevel), OOrder(OrderLevel, OrderDateTime))
+	l.Info("Time-bearing order.")
+	fmt.Print(b.String())
 
 	// Output:
-	// Test: You can set the output order by SetOrder(). example_test.go:34
+	// Test: You can set the output order by SetOrder(). example_test.go:38
+	// Message ends with a newline. example_test.go:44 Test: INFO
+	// Sprintln message example_test.go:45 Test: INFO
+	// INFO 2022/01/02 15:04:05.123456 Time-bearing order.
 }
 
 func ExampleSetLevel() {
@@ -49,9 +71,9 @@ func ExampleSetLevel() {
 	fmt.Println(b.String())
 
 	// Output:
-	// example_test.go:45 Info level message will be printed when you set the "InfoLevel"
-	// example_test.go:47 Warn level message will be printed because it is higher than Info level
-	// example_test.go:48 Error level as well
+	// example_test.go:67 Info level message will be printed when you set the "InfoLevel"
+	// example_test.go:69 Warn level message will be printed because it is higher than Info level
+	// example_test.go:70 Error level as well
 }
 
 func ExampleSetOutput() {
@@ -63,7 +85,7 @@ func ExampleSetOutput() {
 	l.Info(`This is single output example`)
 	fmt.Println(b1.String())
 
-	// Multiple output
+	// Multiple output, replacing the previous output as OOutput does
 	b1.Reset()
 	l.SetOutput(&b1, &b2)
 	l.Info(`This is multiple output example`)
@@ -71,11 +93,11 @@ func ExampleSetOutput() {
 	fmt.Println(b2.String())
 
 	// Output:
-	// example_test.go:63 This is single output example
+	// example_test.go:85 This is single output example
 	//
-	// example_test.go:69 This is multiple output example
+	// example_test.go:91 This is multiple output example
 	//
-	// example_test.go:69 This is multiple output example
+	// example_test.go:91 This is multiple output example
 }
 
 func ExampleDefault() {
@@ -87,6 +109,49 @@ func ExampleDefault() {
 
 	fmt.Println(b1.String())
 	// Output:
-	// example_test.go:84 This is the default logger. It is often used for global logging.
-	// example_test.go:86 You can change the level of default logger by SetLevel().
+	// example_test.go:106 This is the default logger. It is often used for global logging.
+	// example_test.go:108 You can change the level of default logger by SetLevel().
+}
+
+// 库通过注入的 Logger 输出调试信息时，先检查 Debug 是否开启，避免无谓地构造很大的字符串
+func ExampleEnabled() {
+	var b bytes.Buffer
+	dumps := 0
+	dump := func() []byte {
+		dumps++
+		return bytes.Repeat([]byte("x"), 1<<20)
+	}
+	handle := func(lg Logger) {
+		if Enabled(lg, DebugLevel) {
+			lg.Debug("response:", len(dump()))
+		}
+		lg.Info("request handled")
+	}
+
+	handle(New(InfoLevel, OOutput(&b)))
+	handle(New(DebugLevel, OOutput(&b)))
+	fmt.Print(dumps, "\n", b.String())
+
+	// Output:
+	// 1
+	// request handled
+	// response: 1048576
+	// request handled
+}
+
+// OCompact 适合很窄的终端：只有时间，单字母的等级标签，头部与消息以 "|" 分隔
+func ExampleOCompact() {
+	var b bytes.Buffer
+	now := func() time.Time { return time.Date(2024, 5, 6, 15, 4, 5, 0, time.Local) }
+	l := New(InfoLevel, OOutput(&b), ONow(now), OCompact())
+
+	l.Warn("disk almost full")
+	l.Error("write failed")
+	l.With().Info().Int("port", 8080).Msg("listening")
+	fmt.Print(b.String())
+
+	// Output:
+	// 15:04:05 W example_test.go:148|disk almost full
+	// 15:04:05 E example_test.go:149|write failed
+	// 15:04:05 I example_test.go:150|listening port=8080
 }
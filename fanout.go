@@ -0,0 +1,112 @@
+package elog
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// fanout 把每条日志依次写入所有 Writer，与 io.MultiWriter 不同，某个 Writer 失败时仍会写入其余的 Writer，
+// 例如日志文件所在的磁盘已满时控制台照常输出。
+type fanout struct {
+	writers  []io.Writer
+	failures []uint64 // 各 Writer 连续失败的次数，写入成功时清零，原子读写
+}
+
+func newFanout(writers ...io.Writer) *fanout {
+	return &fanout{writers: writers, failures: make([]uint64, len(writers))}
+}
+
+// Write 写入所有 Writer，返回的错误由各个失败的 Writer 的错误合并而成，见 joinErrors
+func (f *fanout) Write(p []byte) (int, error) {
+	var errs []error
+	for i, w := range f.writers {
+		n, err := realStdout(w).Write(p)
+		if err == nil && n < len(p) {
+			err = io.ErrShortWrite
+		}
+		if err := f.record(i, err); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return 0, joinErrors(errs)
+	}
+	return len(p), nil
+}
+
+// record 记录第 i 个 Writer 的写入结果，更新它的连续失败次数，返回 err
+func (f *fanout) record(i int, err error) error {
+	if err != nil {
+		atomic.AddUint64(&f.failures[i], 1)
+	} else {
+		atomic.StoreUint64(&f.failures[i], 0)
+	}
+	return err
+}
+
+// joinErrors 合并多个输出的写入错误，只有一个时直接返回它。
+// 合并后的错误与 errors.Join 的结果一样以换行分隔各个错误，errors.Is 和 errors.As 会逐一检查它们。
+func joinErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return &joinError{errs: errs}
+}
+
+type joinError struct {
+	errs []error
+}
+
+func (e *joinError) Error() string {
+	s := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+func (e *joinError) Unwrap() []error { return e.errs }
+
+// Is 和 As 使 go.mod 中较低的 Go 版本（errors 包不识别 Unwrap() []error）也能检查合并前的各个错误
+func (e *joinError) Is(target error) bool {
+	for _, err := range e.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *joinError) As(target any) bool {
+	for _, err := range e.errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitErrors 返回 err 合并前的各个错误，供错误处理函数逐个接收
+func splitErrors(err error) []error {
+	if j, ok := err.(*joinError); ok {
+		return j.errs
+	}
+	return []error{err}
+}
+
+// WriterFailures 返回各文本输出连续写入失败的次数，按 SetOutput（或 OOutput）的参数顺序排列，AddOutput 添加的输出在其后，写入成功时清零。可以据此在某个输出持续失败时切换到备用输出。
+func (l *Log) WriterFailures() []uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	f, ok := l.output.(*fanout)
+	if !ok {
+		return nil
+	}
+	n := make([]uint64, len(f.failures))
+	for i := range f.failures {
+		n[i] = atomic.LoadUint64(&f.failures[i])
+	}
+	return n
+}
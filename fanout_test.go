@@ -0,0 +1,76 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFanoutIsolatesFailures(t *testing.T) {
+	errDiskFull := errors.New("disk full")
+	var console bytes.Buffer
+	var handled []error
+	l := New(InfoLevel, OOutput(failWriter{errDiskFull}, &console), OFlag(0),
+		OErrorHandler(func(err error) { handled = append(handled, err) }))
+	l.Info("one")
+	l.Errorf("two")
+	l.Raw("three")
+	if got, want := console.String(), "one\ntwo\nthree\n"; got != want {
+		t.Errorf("the healthy writer should receive every entry\n got:  %q\n want: %q", got, want)
+	}
+	if len(handled) != 3 || handled[0] != errDiskFull {
+		t.Errorf("want the disk error reported once per entry, got %v", handled)
+	}
+	if l.WriteErrors() != 3 {
+		t.Errorf("want 3 write errors, got %d", l.WriteErrors())
+	}
+
+	// 路由到各个输出分别写入时同样隔离
+	console.Reset()
+	l.AddOutput(FilterWriter(&bytes.Buffer{}, func(EntryMeta) bool { return true }))
+	l.Warn("four")
+	if console.String() != "four\n" {
+		t.Errorf("routed writes should reach the healthy writer, got %q", console.String())
+	}
+}
+
+func TestFanoutJoinedErrors(t *testing.T) {
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+	var handled []error
+	l := New(InfoLevel, OOutput(failWriter{errA}, &bytes.Buffer{}, failWriter{errB}),
+		OErrorHandler(func(err error) { handled = append(handled, err) }))
+	err := l.LogE(InfoLevel, "x")
+	if !errors.Is(err, errA) || !errors.Is(err, errB) || err.Error() != "a failed\nb failed" {
+		t.Errorf("want both errors joined, got %q", err)
+	}
+	if len(handled) != 2 {
+		t.Errorf("each failing writer should be reported separately, got %v", handled)
+	}
+}
+
+func TestWriterFailures(t *testing.T) {
+	w := &toggleWriter{err: errors.New("broken")}
+	var ok bytes.Buffer
+	l := New(InfoLevel, OOutput(w), OErrorHandler(func(error) {}))
+	l.AddOutput(&ok)
+	l.Info("1")
+	l.Info("2")
+	if got := l.WriterFailures(); !reflect.DeepEqual(got, []uint64{2, 0}) {
+		t.Errorf("want [2 0], got %v", got)
+	}
+	w.err = nil
+	l.Info("3")
+	if got := l.WriterFailures(); !reflect.DeepEqual(got, []uint64{0, 0}) {
+		t.Errorf("a successful write should reset the count, got %v", got)
+	}
+}
+
+type toggleWriter struct{ err error }
+
+func (w *toggleWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	return len(p), nil
+}
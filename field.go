@@ -0,0 +1,200 @@
+package elog
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// FieldKind 表示 Field 中值的类型
+type FieldKind uint8
+
+const (
+	KindAny FieldKind = iota // 值保存在 Value 中，以 fmt 或 encoding/json 格式化
+	KindString
+	KindInt64
+	KindFloat64
+	KindBool
+	KindError
+	KindDuration
+	KindTime
+)
+
+// Field 是附加在日志条目上的键值对，文本格式下以 key=value 的形式追加在消息之后。
+//
+// 直接构造的 Field（如 Field{Key: "k", Value: v}）的类型为 KindAny；通过 String、Int 等构造函数创建的
+// Field 把值保存在内部的类型化字段中，编码时不需要经过 interface 装箱和 fmt 格式化。
+// 读取任意类型 Field 的值应使用 Interface 方法。
+type Field struct {
+	Key   string
+	Value any // KindAny 和 KindError 的值，KindTime 的时区
+	Kind  FieldKind
+	num   int64  // KindInt64、KindFloat64（位模式）、KindBool、KindDuration、KindTime（UnixNano）的值
+	str   string // KindString 的值
+}
+
+// String 创建一个字符串字段
+func String(key, value string) Field {
+	return Field{Key: key, Kind: KindString, str: value}
+}
+
+// Int 创建一个整数字段
+func Int(key string, value int) Field {
+	return Field{Key: key, Kind: KindInt64, num: int64(value)}
+}
+
+// Int64 创建一个 int64 字段
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Kind: KindInt64, num: value}
+}
+
+// Float64 创建一个浮点数字段
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Kind: KindFloat64, num: int64(math.Float64bits(value))}
+}
+
+// Bool 创建一个布尔字段
+func Bool(key string, value bool) Field {
+	var n int64
+	if value {
+		n = 1
+	}
+	return Field{Key: key, Kind: KindBool, num: n}
+}
+
+// Err 以 "error" 为键创建一个错误字段，err 为 nil 时值为 <nil>
+// 错误链中有合并错误（如 errors.Join 的结果）时，文本格式把合并前的每个错误展开为单独的一行，见 appendError；
+// JSONEncoder 另外以 "errors" 为键输出各个错误组成的数组（键为字段的键加上 "s"）。
+func Err(err error) Field {
+	return Field{Key: "error", Kind: KindError, Value: err}
+}
+
+// Dur 创建一个时长字段，以 time.Duration.String 的格式输出
+func Dur(key string, value time.Duration) Field {
+	return Field{Key: key, Kind: KindDuration, num: int64(value)}
+}
+
+// Time 创建一个时间字段，以 RFC 3339 格式输出。超出 UnixNano 表示范围的时间以 KindAny 保存。
+func Time(key string, value time.Time) Field {
+	if y := value.Year(); y < 1678 || y > 2261 {
+		return Field{Key: key, Value: value}
+	}
+	return Field{Key: key, Kind: KindTime, num: value.UnixNano(), Value: value.Location()}
+}
+
+// Any 创建一个任意类型的字段
+func Any(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// MapFields 把 m 转换为按键排序的字段，map 的遍历顺序是随机的，排序后同样的 m 总是得到同样的输出
+func MapFields(m map[string]any) []Field {
+	fields := make([]Field, 0, len(m))
+	for k, v := range m {
+		fields = append(fields, Any(k, v))
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}
+
+// sortedFields 返回按键稳定排序的字段，已经有序时直接返回 fields，否则返回排序后的副本，不修改 fields
+func sortedFields(fields []Field) []Field {
+	less := func(f []Field) func(i, j int) bool {
+		return func(i, j int) bool { return f[i].Key < f[j].Key }
+	}
+	if sort.SliceIsSorted(fields, less(fields)) {
+		return fields
+	}
+	sorted := append([]Field(nil), fields...)
+	sort.SliceStable(sorted, less(sorted))
+	return sorted
+}
+
+// Interface 返回字段的值
+func (f Field) Interface() any {
+	switch f.Kind {
+	case KindString:
+		return f.str
+	case KindInt64:
+		return f.num
+	case KindFloat64:
+		return math.Float64frombits(uint64(f.num))
+	case KindBool:
+		return f.num != 0
+	case KindDuration:
+		return time.Duration(f.num)
+	case KindTime:
+		return f.time()
+	}
+	return f.Value
+}
+
+func (f Field) time() time.Time {
+	t := time.Unix(0, f.num)
+	if loc, ok := f.Value.(*time.Location); ok && loc != nil {
+		t = t.In(loc)
+	}
+	return t
+}
+
+// appendFieldText 将字段的值以文本格式追加到 buf 中
+func appendFieldText(buf []byte, f Field) []byte {
+	switch f.Kind {
+	case KindString:
+		return append(buf, f.str...)
+	case KindInt64:
+		return strconv.AppendInt(buf, f.num, 10)
+	case KindFloat64:
+		return strconv.AppendFloat(buf, math.Float64frombits(uint64(f.num)), 'g', -1, 64)
+	case KindBool:
+		return strconv.AppendBool(buf, f.num != 0)
+	case KindError:
+		if err, ok := f.Value.(error); ok && err != nil {
+			return appendError(buf, err, 0)
+		}
+		return append(buf, "<nil>"...)
+	case KindDuration:
+		return append(buf, time.Duration(f.num).String()...)
+	case KindTime:
+		return f.time().AppendFormat(buf, time.RFC3339Nano)
+	}
+	switch v := f.Value.(type) {
+	case string:
+		return append(buf, v...)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case error:
+		return appendError(buf, v, 0)
+	case humanValue:
+		return v.appendText(buf)
+	}
+	return append(buf, fmt.Sprint(f.Value)...)
+}
+
+// appendFieldJSON 将字段的值编码为 JSON 值追加到 buf 中
+func appendFieldJSON(buf []byte, f Field) []byte {
+	switch f.Kind {
+	case KindString:
+		return appendJSONString(buf, f.str)
+	case KindInt64:
+		return strconv.AppendInt(buf, f.num, 10)
+	case KindFloat64:
+		return appendJSONFloat(buf, math.Float64frombits(uint64(f.num)), 64)
+	case KindBool:
+		return strconv.AppendBool(buf, f.num != 0)
+	case KindError:
+		if err, ok := f.Value.(error); ok && err != nil {
+			return appendJSONString(buf, err.Error())
+		}
+		return append(buf, "null"...)
+	case KindDuration:
+		return appendJSONString(buf, time.Duration(f.num).String())
+	case KindTime:
+		buf = append(buf, '"')
+		buf = f.time().AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"')
+	}
+	return appendJSONValue(buf, f.Value)
+}
@@ -0,0 +1,154 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestFieldKinds(t *testing.T) {
+	ts := time.Date(2022, 1, 2, 15, 4, 5, 6, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		f              Field
+		value          any
+		text, json, lf string
+	}{
+		{String("k", "a b"), "a b", `a b`, `"a b"`, `"a b"`},
+		{Int("k", -3), int64(-3), `-3`, `-3`, `-3`},
+		{Int64("k", 1<<40), int64(1 << 40), `1099511627776`, `1099511627776`, `1099511627776`},
+		{Float64("k", 1.5), 1.5, `1.5`, `1.5`, `1.5`},
+		{Bool("k", true), true, `true`, `true`, `true`},
+		{Err(errors.New("no such file")), errors.New("no such file"), `no such file`, `"no such file"`, `"no such file"`},
+		{Err(nil), nil, `<nil>`, `null`, `<nil>`},
+		{Dur("k", 1500*time.Millisecond), 1500 * time.Millisecond, `1.5s`, `"1.5s"`, `1.5s`},
+		{Time("k", ts), ts, `2022-01-02T15:04:05.000000006+08:00`, `"2022-01-02T15:04:05.000000006+08:00"`, `2022-01-02T15:04:05.000000006+08:00`},
+		{Any("k", []int{1, 2}), []int{1, 2}, `[1 2]`, `[1,2]`, `"[1 2]"`},
+		{Field{Key: "k", Value: "raw"}, "raw", `raw`, `"raw"`, `raw`},
+	}
+	for _, tc := range tests {
+		got := tc.f.Interface()
+		if err, ok := got.(error); ok {
+			got = err.Error()
+			tc.value = tc.value.(error).Error()
+		}
+		if tm, ok := got.(time.Time); ok {
+			if !tm.Equal(ts) || tm.Location().String() != "CST" {
+				t.Errorf("Interface: want %v, got %v", ts, tm)
+			}
+		} else if !equalValue(got, tc.value) {
+			t.Errorf("Interface: want %#v, got %#v", tc.value, got)
+		}
+		if got := string(appendFieldText(nil, tc.f)); got != tc.text {
+			t.Errorf("text: want %s, got %s", tc.text, got)
+		}
+		if got := string(appendFieldJSON(nil, tc.f)); got != tc.json {
+			t.Errorf("json: want %s, got %s", tc.json, got)
+		}
+		e := &Entry{Fields: []Field{tc.f}}
+		if got, want := string(LogfmtEncoder.Encode(nil, e, 0)), `msg="" `+tc.f.Key+`=`+tc.lf+"\n"; got != want {
+			t.Errorf("logfmt: want %q, got %q", want, got)
+		}
+	}
+}
+
+func equalValue(a, b any) bool {
+	if s, ok := a.([]int); ok {
+		t, ok := b.([]int)
+		return ok && len(s) == len(t) && s[0] == t[0] && s[1] == t[1]
+	}
+	return a == b
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(Llevel|Lmsgprefix), OName("app"), OPrefix("[x]")).
+		AddOutput(&b, WithEncoder(LogfmtEncoder))
+	l.With().Warn().Str("user", "tom").Str("a=b", `say "hi"`).Msg("login failed")
+	if got, want := b.String(), `level=WARN logger=app prefix=[x] msg="login failed" user=tom a_b="say \"hi\""`+"\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestEntryBuilderTypedFields(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	l.With().Int64("n", 7).Float64("f", 0.25).Bool("ok", true).Dur("d", time.Second).
+		Time("t", time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)).Fields(String("s", "v"), Int("i", 1)).Msg("typed")
+	if got, want := b.String(), "typed n=7 f=0.25 ok=true d=1s t=2022-01-02T00:00:00Z s=v i=1\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+// 每种类型的字段分别经过文本和 JSON 编码，除 Any 外都不应产生内存分配
+func BenchmarkFieldKinds(b *testing.B) {
+	err := errors.New("boom")
+	now := time.Now()
+	kinds := []struct {
+		name string
+		add  func(e *EntryBuilder) *EntryBuilder
+	}{
+		{"String", func(e *EntryBuilder) *EntryBuilder { return e.Str("k", "value") }},
+		{"Int", func(e *EntryBuilder) *EntryBuilder { return e.Int("k", 12345) }},
+		{"Float64", func(e *EntryBuilder) *EntryBuilder { return e.Float64("k", 3.14) }},
+		{"Bool", func(e *EntryBuilder) *EntryBuilder { return e.Bool("k", true) }},
+		{"Err", func(e *EntryBuilder) *EntryBuilder { return e.Err(err) }},
+		{"Dur", func(e *EntryBuilder) *EntryBuilder { return e.Dur("k", time.Second) }},
+		{"Time", func(e *EntryBuilder) *EntryBuilder { return e.Time("k", now) }},
+		{"Any", func(e *EntryBuilder) *EntryBuilder { return e.Any("k", struct{ A int }{1}) }},
+	}
+	l := New(InfoLevel, OOutput(io.Discard)).AddOutput(io.Discard, WithEncoder(JSONEncoder))
+	for _, k := range kinds {
+		b.Run(k.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				k.add(l.With()).Msg("hello")
+			}
+		})
+	}
+}
+
+func TestMapFieldsDeterministic(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	m := map[string]any{"user": "tom", "ip": "10.0.0.1", "attempt": 3, "zone": "eu", "agent": "curl"}
+	var first string
+	for i := 0; i < 50; i++ {
+		b.Reset()
+		l.WithFields(m).Str("last", "x").Msg("login")
+		if i == 0 {
+			first = b.String()
+			continue
+		}
+		if b.String() != first {
+			t.Fatalf("output differs between calls:\n%q\n%q", first, b.String())
+		}
+	}
+	if want := "login agent=curl attempt=3 ip=10.0.0.1 user=tom zone=eu last=x\n"; first != want {
+		t.Errorf("\n got:  %q\n want: %q", first, want)
+	}
+}
+
+func TestSortFieldsEncoder(t *testing.T) {
+	e := &Entry{Msg: "m", Fields: []Field{String("b", "1"), String("a", "2"), String("b", "3")}}
+	fields := e.Fields
+	for _, tt := range []struct {
+		enc  Encoder
+		want string
+	}{
+		{NewJSONEncoder(EncoderConfig{SortFields: true}), `{"msg":"m","a":"2","b":"1","b":"3"}` + "\n"},
+		{NewLogfmtEncoder(EncoderConfig{SortFields: true}), "msg=m a=2 b=1 b=3\n"},
+		{NewLogfmtEncoder(EncoderConfig{}), "msg=m b=1 a=2 b=3\n"},
+		{NewCSVEncoder(CSVConfig{Columns: []CSVColumn{CSVFields}, SortFields: true}), "a=2 b=1 b=3\n"},
+	} {
+		for i := 0; i < 20; i++ {
+			if got := string(tt.enc.Encode(nil, e, 0)); got != tt.want {
+				t.Fatalf("\n got:  %q\n want: %q", got, tt.want)
+			}
+		}
+	}
+	if fields[0].Key != "b" || fields[1].Key != "a" {
+		t.Error("sorting should not modify the entry")
+	}
+}
@@ -0,0 +1,89 @@
+package elog
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// FilterFunc 在日志格式化之前执行，返回 false 时这条日志被抑制
+type FilterFunc func(level logLevel, msg string) bool
+
+type filter struct {
+	id    uint64
+	fn    FilterFunc
+	byKey bool // 为 true 时 fn 收到的是去重键而不是消息，见 WithKey
+}
+
+var filterID uint64
+
+func nextFilterID() uint64 {
+	return atomic.AddUint64(&filterID, 1)
+}
+
+// AddFilter 添加一个过滤器，返回的函数用于移除该过滤器。
+// 过滤器在格式化之前、不持有锁的情况下执行，因此被抑制的日志代价很低；审计日志不受过滤器影响。
+// 通过 Extend 派生的子 logger 会继承父 logger 当时的过滤器，移除函数只作用于调用 AddFilter 的 logger。
+func (l *Log) AddFilter(fn FilterFunc) (remove func()) {
+	id := nextFilterID()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// 总是分配新的切片，Out 中拿到的旧切片不会被修改
+	filters := make([]filter, 0, len(l.filters)+1)
+	filters = append(filters, l.filters...)
+	l.filters = append(filters, filter{id: id, fn: fn})
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		filters := make([]filter, 0, len(l.filters))
+		for _, f := range l.filters {
+			if f.id != id {
+				filters = append(filters, f)
+			}
+		}
+		l.filters = filters
+	}
+}
+
+// SuppressPattern 抑制消息匹配 re 的日志，返回的函数用于移除该过滤器
+func (l *Log) SuppressPattern(re *regexp.Regexp) (remove func()) {
+	return l.AddFilter(func(level logLevel, msg string) bool {
+		return !re.MatchString(strings.TrimSuffix(msg, "\n"))
+	})
+}
+
+// Suppressed 返回被过滤器抑制的日志条数
+func (l *Log) Suppressed() uint64 {
+	return atomic.LoadUint64(&l.suppressed)
+}
+
+// SuppressedByLevel 返回各等级被过滤器（包括采样）抑制的日志条数，没有被抑制过的等级不会出现在结果中
+func (l *Log) SuppressedByLevel() map[logLevel]uint64 {
+	return loadCounters(&l.suppressedBy)
+}
+
+// filtered 报告 msg 是否被过滤器抑制，fields 中的去重键只交给按键分组的过滤器
+func (l *Log) filtered(filters []filter, level logLevel, msg string, fields []Field) bool {
+	if level == AuditLevel {
+		return false
+	}
+	for _, f := range filters {
+		s := msg
+		if f.byKey {
+			s = dedupKey(msg, fields)
+		}
+		ok, err := callFilter(f.fn, level, s)
+		if err != nil {
+			l.callbackFailed(err) // 视为不抑制
+			continue
+		}
+		if !ok {
+			atomic.AddUint64(&l.suppressed, 1)
+			if level >= 0 && level < logLevel(len(l.suppressedBy)) {
+				atomic.AddUint64(&l.suppressedBy[level], 1)
+			}
+			return true
+		}
+	}
+	return false
+}
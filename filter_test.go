@@ -0,0 +1,60 @@
+package elog
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestAddFilter(t *testing.T) {
+	skipCompiledOut(t, DebugLevel)
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b))
+	remove := l.AddFilter(func(level logLevel, msg string) bool {
+		return level != DebugLevel
+	})
+	l.Debug("hidden")
+	l.Info("shown")
+	l.Audit("audit is never filtered")
+	remove()
+	l.Debug("shown after remove")
+
+	want := "shown\nAUDIT audit is never filtered\nshown after remove\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+	if n := l.Suppressed(); n != 1 {
+		t.Errorf("expected 1 suppressed entry, got %d", n)
+	}
+}
+
+func TestSuppressPattern(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	remove := l.SuppressPattern(regexp.MustCompile(`context canceled$`))
+	child := l.Extend()
+
+	l.Error("rpc failed: context canceled")
+	child.Errorf("rpc failed: %s", "context canceled")
+	l.Error("rpc failed: deadline exceeded")
+	remove()
+	l.Error("rpc failed: context canceled")
+	child.Error("child keeps inherited filter: context canceled")
+
+	want := "rpc failed: deadline exceeded\nrpc failed: context canceled\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+	if l.Suppressed() != 1 || child.Suppressed() != 2 {
+		t.Errorf("unexpected suppressed counters, parent: %d, child: %d", l.Suppressed(), child.Suppressed())
+	}
+}
+
+func BenchmarkFilteredOut(b *testing.B) {
+	var buf bytes.Buffer
+	l := New(InfoLevel, OOutput(&buf), OFlag(LstdFlags))
+	l.AddFilter(func(level logLevel, msg string) bool { return false })
+	for i := 0; i < b.N; i++ {
+		l.Info("noisy")
+	}
+}
@@ -0,0 +1,129 @@
+package elog
+
+import (
+	"io"
+	"sync"
+)
+
+// followBuffer 是每个 Follow 订阅者缓存的日志条数，缓存满时订阅者被自动移除
+const followBuffer = 64
+
+// follower 是 Follow 或 StreamHandler 添加的一个订阅者，只接收等级不低于 min 的日志
+type follower struct {
+	w   io.Writer // Follow 的后台 goroutine 写入的目标，StreamHandler 直接读取 ch 时为空
+	ch  chan []byte
+	min logLevel
+	// 保护 stopped，避免向已关闭的 ch 发送：CloseAll 停止订阅者时不持有 logger 的锁
+	mu      sync.Mutex
+	stopped bool
+}
+
+func newFollower(w io.Writer, min logLevel) *follower {
+	return &follower{w: w, ch: make(chan []byte, followBuffer), min: min}
+}
+
+// stop 关闭 f.ch，之后后台 goroutine 写完缓存的日志后退出。可以重复调用。
+func (f *follower) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.stopped {
+		f.stopped = true
+		close(f.ch)
+	}
+}
+
+// send 把 p 放入 f 的缓存，缓存已满或 f 已经停止时返回 false
+func (f *follower) send(p []byte) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stopped {
+		return false
+	}
+	select {
+	case f.ch <- p:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *follower) run(l *Log) {
+	for p := range f.ch {
+		if _, err := f.w.Write(p); err != nil {
+			l.unfollow(f)
+			break
+		}
+	}
+	for range f.ch { // 退出前排空，避免持有已经无人读取的日志
+	}
+}
+
+// Follow 把之后的每条日志（文本格式，与写入输出的内容相同）同时写到 w，直到调用返回的 unsub，不影响 logger 的其它配置，
+// 适合用于管理页面的实时日志等临时的镜像输出。
+//
+// 日志复制后放入每个订阅者独立的小缓存，由后台 goroutine 写入 w，缓慢的 w 不会拖慢日志输出；
+// 缓存已满（w 跟不上日志的速度）或写入 w 失败时订阅者会被自动移除，之后调用 unsub 是空操作。
+// unsub 可以重复调用，返回时不再有新的日志交给 w，但已经缓存的日志仍会写完。
+// Close 会移除所有订阅者；通过 Extend 派生的子 logger 不会继承订阅者。
+func (l *Log) Follow(w io.Writer) (unsub func()) {
+	f := newFollower(w, Discard)
+	if !l.follow(f) {
+		return func() {}
+	}
+	go f.run(l)
+	return func() { l.unfollow(f) }
+}
+
+// follow 添加订阅者 f，logger 已经 Close 时返回 false
+func (l *Log) follow(f *follower) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	followers := l.followers
+	// 总是分配新的切片，移除订阅者时不修改旧切片
+	l.followers = append(append([]*follower(nil), followers...), f)
+	if !l.syncCloser() {
+		l.followers = followers
+		return false
+	}
+	return true
+}
+
+// unfollow 移除订阅者 f，f 已被移除时什么也不做
+func (l *Log) unfollow(f *follower) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.removeFollower(f)
+}
+
+// removeFollower 移除订阅者 f 并停止它。调用方需持有锁。
+func (l *Log) removeFollower(f *follower) {
+	for i, o := range l.followers {
+		if o == f {
+			followers := make([]*follower, 0, len(l.followers)-1)
+			followers = append(followers, l.followers[:i]...)
+			l.followers = append(followers, l.followers[i+1:]...)
+			l.syncCloser()
+			break
+		}
+	}
+	f.stop()
+}
+
+// mirror 把 level 等级的日志 p 的副本交给订阅者，缓存已满或已经停止的订阅者被移除。调用方需持有锁。
+func (l *Log) mirror(level logLevel, p []byte) {
+	if len(l.followers) == 0 {
+		return
+	}
+	var data []byte // 所有订阅者共享同一份只读的副本
+	for _, f := range l.followers {
+		if level < f.min {
+			continue
+		}
+		if data == nil {
+			data = append([]byte(nil), p...)
+		}
+		if !f.send(data) {
+			l.removeFollower(f) // 遍历的是旧切片，不受移除影响
+		}
+	}
+}
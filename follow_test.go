@@ -0,0 +1,94 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestFollow(t *testing.T) {
+	var main bytes.Buffer
+	var mu sync.Mutex
+	var mirror bytes.Buffer
+	l := New(InfoLevel, OOutput(&main), OFlag(Llevel))
+	l.Info("before")
+	unsub := l.Follow(&lockedWriter{mu: &mu, w: &mirror})
+	l.Info("during")
+	l.Raw("raw")
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return mirror.Len() == len("INFO during\nraw\n")
+	})
+	unsub()
+	unsub()
+	l.Info("after")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := mirror.String(), "INFO during\nraw\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+	if got, want := main.String(), "INFO before\nINFO during\nraw\nINFO after\n"; got != want {
+		t.Errorf("main output should be unaffected\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestFollowEvictsSlowSubscriber(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	l := New(InfoLevel, OOutput(io.Discard))
+	l.Follow(w)
+	for i := 0; i < followBuffer+2; i++ {
+		l.Info("x")
+	}
+	l.mu.RLock()
+	n := len(l.followers)
+	l.mu.RUnlock()
+	if n != 0 {
+		t.Errorf("a subscriber that falls behind should be evicted, %d left", n)
+	}
+	close(w.release)
+}
+
+func TestFollowEvictsFailingSubscriber(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	unsub := l.Follow(failWriter{errors.New("client gone")})
+	l.Info("x")
+	waitFor(t, func() bool {
+		l.mu.RLock()
+		defer l.mu.RUnlock()
+		return len(l.followers) == 0
+	})
+	unsub()
+}
+
+func TestFollowRace(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				unsub := l.Follow(io.Discard)
+				l.Info("x")
+				unsub()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				l.Info("y")
+			}
+		}()
+	}
+	wg.Wait()
+	l.Follow(io.Discard)
+	l.Close()
+	if len(l.followers) != 0 {
+		t.Errorf("Close should remove all subscribers, %d left", len(l.followers))
+	}
+	l.Follow(io.Discard)() // 关闭后订阅是空操作
+}
@@ -0,0 +1,131 @@
+package elog
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// inMsg 标记由 %w 捕获的错误字段：错误已经出现在消息中，文本格式不再以 key=value 的形式重复输出，
+// JSON 等结构化的编码器仍然输出该字段
+const inMsg = 1
+
+// sprintf 与 fmt.Sprintf 相同，但像 fmt.Errorf 一样接受 %w 动词：%w 按 %v 输出，
+// 不会得到 "%!w(...)"；对应的参数不是 error 时同样按 %v 输出。
+// 返回的字段是 %w 对应的错误，键为 "error"，有多个 %w 时合并为一个错误（JSONEncoder 另外输出 "errors" 数组），
+// 没有 %w 或对应的错误都为 nil 时返回 nil。
+func sprintf(format string, v []any) (string, []Field) {
+	if strings.IndexByte(format, 'w') < 0 {
+		return fmt.Sprintf(format, v...), nil
+	}
+	format, wrapped := rewriteWrap(format)
+	msg := fmt.Sprintf(format, v...)
+	var errs []error
+	for _, i := range wrapped {
+		if i < 0 || i >= len(v) {
+			continue
+		}
+		if err, ok := v[i].(error); ok && err != nil {
+			errs = append(errs, err)
+		}
+	}
+	switch len(errs) {
+	case 0:
+		return msg, nil
+	case 1:
+		return msg, []Field{{Key: "error", Kind: KindError, Value: errs[0], num: inMsg}}
+	}
+	return msg, []Field{{Key: "error", Kind: KindError, Value: joinErrors(errs), num: inMsg}}
+}
+
+// rewriteWrap 把 format 中的 %w 替换为 %v，并返回 %w 对应的参数下标。同一个参数被多个 %w 引用（如 "%[1]w %[1]w"）时
+// 下标只出现一次，与 fmt.Errorf 相同按下标而不是按错误的值去重，错误的动态类型不可比较时也不会 panic。
+// 参数的编号规则与 fmt 相同：每个动词以及作为宽度或精度的 '*' 各消耗一个参数，"[n]" 把下一个参数改为第 n 个，"%%" 不消耗参数。
+func rewriteWrap(format string) (string, []int) {
+	var b []byte
+	var wrapped []int
+	argNum := 0
+	for i := 0; i < len(format); {
+		if format[i] != '%' {
+			i++
+			continue
+		}
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		argNum, i = argIndex(format, i, argNum)
+		argNum, i = skipWidth(format, i, argNum)
+		if i < len(format) && format[i] == '.' {
+			i++
+			argNum, i = argIndex(format, i, argNum)
+			argNum, i = skipWidth(format, i, argNum)
+		}
+		argNum, i = argIndex(format, i, argNum)
+		if i >= len(format) {
+			break
+		}
+		switch format[i] {
+		case '%':
+			i++
+			continue
+		case 'w':
+			if b == nil {
+				b = []byte(format)
+			}
+			b[i] = 'v'
+			if !hasIndex(wrapped, argNum) {
+				wrapped = append(wrapped, argNum)
+			}
+		}
+		_, size := utf8.DecodeRuneInString(format[i:])
+		i += size
+		argNum++
+	}
+	if b == nil {
+		return format, nil
+	}
+	return string(b), wrapped
+}
+
+func hasIndex(indexes []int, i int) bool {
+	for _, x := range indexes {
+		if x == i {
+			return true
+		}
+	}
+	return false
+}
+
+// argIndex 读取 format[i:] 开头的 "[n]"，返回新的参数编号（从 0 开始）；格式不正确时保持不变，交给 fmt 报告
+func argIndex(format string, i, argNum int) (int, int) {
+	if i >= len(format) || format[i] != '[' {
+		return argNum, i
+	}
+	n := 0
+	for j := i + 1; j < len(format); j++ {
+		c := format[j]
+		if c == ']' {
+			if j == i+1 {
+				return argNum, i
+			}
+			return n - 1, j + 1
+		}
+		if c < '0' || c > '9' {
+			return argNum, i
+		}
+		n = n*10 + int(c-'0')
+	}
+	return argNum, i
+}
+
+// skipWidth 跳过宽度或精度：'*' 消耗一个参数，数字不消耗参数
+func skipWidth(format string, i, argNum int) (int, int) {
+	if i < len(format) && format[i] == '*' {
+		return argNum + 1, i + 1
+	}
+	for i < len(format) && '0' <= format[i] && format[i] <= '9' {
+		i++
+	}
+	return argNum, i
+}
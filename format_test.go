@@ -0,0 +1,141 @@
+package elog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSprintfWrap(t *testing.T) {
+	e1, e2 := errors.New("e1"), errors.New("e2")
+	tests := []struct {
+		format string
+		v      []any
+		msg    string
+		errs   []error
+	}{
+		{"no verbs", nil, "no verbs", nil},
+		{"%w", []any{e1}, "e1", []error{e1}},
+		{"%w: retrying", []any{e1}, "e1: retrying", []error{e1}},
+		{"open %s: %w (attempt %d)", []any{"a.txt", e1, 3}, "open a.txt: e1 (attempt 3)", []error{e1}},
+		{"failed: %w", []any{e1}, "failed: e1", []error{e1}},
+		{"%w and %w", []any{e1, e2}, "e1 and e2", []error{e1, e2}},
+		{"%d%% done, %5.2f%%: %w", []any{50, 1.5, e1}, "50% done,  1.50%: e1", []error{e1}},
+		{"%*d %-*s %w", []any{4, 7, 3, "x", e1}, "   7 x   e1", []error{e1}},
+		{"%.*f %w", []any{2, 3.14159, e1}, "3.14 e1", []error{e1}},
+		{"%[2]w after %[1]s", []any{"start", e1}, "e1 after start", []error{e1}},
+		{"%[1]w %[1]w %w", []any{e1, e2}, "e1 e1 e2", []error{e1, e2}},
+		{"%+w %4w", []any{e1, e2}, "e1   e2", []error{e1, e2}},
+		{"100%%w", nil, "100%w", nil},
+		{"%w", []any{42}, "42", nil},
+		{"%w", []any{error(nil)}, "<nil>", nil},
+		{"%s", []any{e1}, "e1", nil},
+		{"%w %w", []any{e1}, "e1 %!v(MISSING)", []error{e1}},
+		{"wrap %w", nil, "wrap %!v(MISSING)", nil},
+		{"%[3]w", []any{e1}, "%!v(BADINDEX)", nil},
+	}
+	for _, tt := range tests {
+		msg, fields := sprintf(tt.format, tt.v)
+		if msg != tt.msg {
+			t.Errorf("sprintf(%q): msg %q, want %q", tt.format, msg, tt.msg)
+		}
+		var errs []error
+		if len(fields) == 1 {
+			f := fields[0]
+			if f.Key != "error" || f.Kind != KindError || f.num != inMsg {
+				t.Errorf("sprintf(%q): field %+v", tt.format, f)
+			}
+			err := f.Value.(error)
+			if _, errs, _ = splitMulti(err); errs == nil {
+				errs = []error{err}
+			}
+		} else if len(fields) > 1 {
+			t.Errorf("sprintf(%q): %d fields", tt.format, len(fields))
+		}
+		if !reflect.DeepEqual(errs, tt.errs) {
+			t.Errorf("sprintf(%q): errors %v, want %v", tt.format, errs, tt.errs)
+		}
+	}
+}
+
+func TestErrorfWrap(t *testing.T) {
+	var text, js bytes.Buffer
+	l := New(TraceLevel, OOutput(&text), OFlag(Llevel))
+	l.AddOutput(&js, WithEncoder(JSONEncoder))
+	err := errors.New("connection refused")
+
+	l.Errorf("dial %s: %w", "db:5432", err)
+	if got, want := text.String(), "ERROR dial db:5432: connection refused\n"; got != want {
+		t.Errorf("text: got %q, want %q", got, want)
+	}
+	if got := js.String(); !strings.Contains(got, `"msg":"dial db:5432: connection refused","error":"connection refused"}`) {
+		t.Errorf("json: got %s", got)
+	}
+
+	// 已有的字段仍然以 key=value 输出，%w 的错误不重复
+	text.Reset()
+	js.Reset()
+	l.With().Warn().Int("attempt", 2).Msgf("retry: %w", err)
+	if got, want := text.String(), "WARN retry: connection refused attempt=2\n"; got != want {
+		t.Errorf("builder text: got %q, want %q", got, want)
+	}
+	if got := js.String(); !strings.Contains(got, `"attempt":2,"error":"connection refused"}`) {
+		t.Errorf("builder json: got %s", got)
+	}
+
+	js.Reset()
+	l.Infof("%w, then %w", err, errors.New("timeout"))
+	if got := js.String(); !strings.Contains(got, `"errors":["connection refused","timeout"]`) {
+		t.Errorf("multiple: got %s", got)
+	}
+}
+
+// 包装 *Log 的各个 logger 同样捕获 %w 的错误
+func TestErrorfWrapLoggers(t *testing.T) {
+	var js bytes.Buffer
+	l := New(TraceLevel, OOutput(&bytes.Buffer{}))
+	l.AddOutput(&js, WithEncoder(JSONEncoder))
+	err := errors.New("boom")
+	loggers := map[string]Logger{
+		"scope":    l.BeginScope(String("id", "s")),
+		"ctx":      l.Ctx(context.Background()),
+		"readonly": l.ReadOnly(),
+		"multi":    Multi(l),
+	}
+	for name, lg := range loggers {
+		js.Reset()
+		lg.Warnf("failed: %w", err)
+		if got := js.String(); !strings.Contains(got, `"msg":"failed: boom"`) || !strings.Contains(got, `"error":"boom"`) {
+			t.Errorf("%s: got %s", name, got)
+		}
+	}
+}
+
+// sliceErr 的动态类型不可比较，用 == 比较两个这样的错误会 panic
+type sliceErr []string
+
+func (e sliceErr) Error() string { return strings.Join(e, ",") }
+
+func TestSprintfUncomparableError(t *testing.T) {
+	msg, fields := sprintf("%[1]w %[1]w %[2]w", []any{sliceErr{"a"}, sliceErr{"b", "c"}})
+	if msg != "a a b,c" || len(fields) != 1 {
+		t.Fatalf("got %q %v", msg, fields)
+	}
+	if _, errs, _ := splitMulti(fields[0].Value.(error)); len(errs) != 2 {
+		t.Errorf("want 2 errors, got %v", errs)
+	}
+
+	var text, js bytes.Buffer
+	l := New(InfoLevel, OOutput(&text))
+	l.AddOutput(&js, WithEncoder(JSONEncoder))
+	l.Infof("%w %w", sliceErr{"a"}, sliceErr{"b"})
+	if got := text.String(); got != "a b\n" {
+		t.Errorf("text: got %q", got)
+	}
+	if got := js.String(); !strings.Contains(got, `"errors":["a","b"]`) {
+		t.Errorf("json: got %s", got)
+	}
+}
@@ -0,0 +1,190 @@
+package elog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGzipFlushBytes    = 32 << 10
+	defaultGzipFlushInterval = time.Second
+)
+
+// ErrGzipClosed 在 GzipWriter 关闭后由 Write 返回
+var ErrGzipClosed = errors.New("elog: gzip writer is closed")
+
+// GzipWriter 把写入的日志以 gzip 格式压缩后写入底层的 Writer，适合只在事后读取的归档日志。
+//
+// 压缩的数据在内部缓冲，写入超过一定字节数（默认 32 KiB，见 GzipFlushBytes）或者距离第一条未刷新的日志超过一定时间
+// （默认 1 秒，见 GzipFlushInterval）时刷新到底层的 Writer，进程崩溃时最多丢失最后一个刷新窗口内的日志，
+// 已经刷新的部分可以被 gzip 正常解压（以 unexpected EOF 结束）。Close 写入 gzip 流的结尾。
+//
+// 底层的 Writer 是 RotatingFile 时，轮转由 GzipWriter 在两条日志之间进行：先结束当前的 gzip 流再轮转，
+// 每个文件都是独立完整的 gzip 流，可以单独解压；文件大小按压缩后的字节计算，会超出 FileMaxSize 最多一个刷新窗口。
+// 追加到已存在的文件时新的 gzip 流接在原有内容之后，gzip 和 compress/gzip 会依次解压它们。
+type GzipWriter struct {
+	mu         sync.Mutex
+	w          io.Writer
+	gz         *gzip.Writer
+	rot        *RotatingFile // w 是 RotatingFile 时不为空
+	flushBytes int
+	interval   time.Duration
+	pending    int         // 上次刷新之后写入的未压缩字节数
+	timer      *time.Timer // 有未刷新的日志时等待刷新
+	closed     bool
+}
+
+var (
+	_ io.WriteCloser = &GzipWriter{}
+	_ Flusher        = &GzipWriter{}
+)
+
+// GzipOption 用于配置 NewGzipWriter 创建的 GzipWriter
+type GzipOption func(g *GzipWriter)
+
+// GzipFlushBytes 设置上次刷新之后写入多少字节（压缩前）时刷新，n <= 0 时不按字节数刷新
+func GzipFlushBytes(n int) GzipOption {
+	return func(g *GzipWriter) {
+		g.flushBytes = n
+	}
+}
+
+// GzipFlushInterval 设置第一条未刷新的日志写入之后多久刷新，d <= 0 时不按时间刷新
+func GzipFlushInterval(d time.Duration) GzipOption {
+	return func(g *GzipWriter) {
+		g.interval = d
+	}
+}
+
+// NewGzipWriter 返回以 level 压缩级别（如 gzip.BestSpeed，见 compress/gzip）压缩后写入 w 的 GzipWriter，level 无效时返回错误：
+//
+//	f, err := elog.NewRotatingFile("logs/app.log.gz", elog.FileMaxSize(100<<20))
+//	if err != nil {
+//		return err
+//	}
+//	gz, err := elog.NewGzipWriter(f, gzip.BestSpeed)
+//	if err != nil {
+//		return err
+//	}
+//	l := elog.New(elog.InfoLevel, elog.OOutput(gz))
+func NewGzipWriter(w io.Writer, level int, options ...GzipOption) (*GzipWriter, error) {
+	gz, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, err
+	}
+	g := &GzipWriter{w: w, gz: gz, flushBytes: defaultGzipFlushBytes, interval: defaultGzipFlushInterval}
+	for _, opt := range options {
+		opt(g)
+	}
+	if f, ok := w.(*RotatingFile); ok {
+		g.rot = f
+		f.setManualRotation()
+	}
+	return g, nil
+}
+
+// Write 压缩 p，需要时先轮转底层的 RotatingFile。每次 Write 应是一条完整的日志，logger 总是这样写入。
+func (g *GzipWriter) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return 0, ErrGzipClosed
+	}
+	if g.rot != nil && g.rot.rotateDue() {
+		if err := g.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := g.gz.Write(p)
+	g.pending += n
+	if err != nil {
+		return n, err
+	}
+	if g.flushBytes > 0 && g.pending >= g.flushBytes {
+		return n, g.flush()
+	}
+	if g.interval > 0 && g.timer == nil {
+		g.timer = time.AfterFunc(g.interval, g.timedFlush)
+	}
+	return n, nil
+}
+
+// rotate 结束当前的 gzip 流，轮转文件后在新文件中开始新的 gzip 流。调用方需持有锁。
+func (g *GzipWriter) rotate() error {
+	g.stopTimer()
+	g.pending = 0
+	if err := g.gz.Close(); err != nil {
+		return err
+	}
+	if err := g.rot.Rotate(); err != nil {
+		return err
+	}
+	g.gz.Reset(g.w)
+	return nil
+}
+
+// flush 把已压缩的数据刷新到底层的 Writer。调用方需持有锁。
+func (g *GzipWriter) flush() error {
+	g.stopTimer()
+	g.pending = 0
+	return g.gz.Flush()
+}
+
+func (g *GzipWriter) stopTimer() {
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+}
+
+// timedFlush 在 GzipFlushInterval 到期时刷新，错误报告给诊断 logger
+func (g *GzipWriter) timedFlush() {
+	g.mu.Lock()
+	g.timer = nil
+	var err error
+	if !g.closed && g.pending > 0 {
+		err = g.flush()
+	}
+	g.mu.Unlock()
+	if err != nil {
+		internalf(ErrorLevel, "gzip writer: flush failed: %v", err)
+	}
+}
+
+// Flush 把已压缩的数据刷新到底层的 Writer，底层的 Writer 实现了 Flusher 时再调用它的 Flush
+func (g *GzipWriter) Flush() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return nil
+	}
+	if err := g.flush(); err != nil {
+		return err
+	}
+	if f, ok := g.w.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close 写入 gzip 流的结尾，并关闭实现了 io.Closer 的底层 Writer（标准输出和标准错误除外），之后的 Write 返回 ErrGzipClosed
+func (g *GzipWriter) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return nil
+	}
+	g.closed = true
+	g.stopTimer()
+	err := g.gz.Close()
+	if c, ok := g.w.(io.Closer); ok && g.w != io.Writer(os.Stdout) && g.w != io.Writer(os.Stderr) {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
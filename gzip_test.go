@@ -0,0 +1,157 @@
+package elog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gunzip 解压 b，b 是被截断的流时返回已经能够解压的部分
+func gunzip(t *testing.T, b []byte) string {
+	t.Helper()
+	if len(b) == 0 {
+		return ""
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("gzip header: %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("gunzip: %v", err)
+	}
+	return string(out)
+}
+
+func TestGzipWriter(t *testing.T) {
+	var buf bytes.Buffer
+	gw, err := NewGzipWriter(&buf, gzip.BestSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(InfoLevel, OOutput(gw), OFlag(Llevel))
+	var want strings.Builder
+	for i := 0; i < 100; i++ {
+		l.Info("entry", i)
+		fmt.Fprintf(&want, "INFO entry %d\n", i)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := gunzip(t, buf.Bytes()); got != want.String() {
+		t.Errorf("got %q", got)
+	}
+	if _, err := gw.Write([]byte("x")); err != ErrGzipClosed {
+		t.Errorf("write after close: %v", err)
+	}
+	if _, err := NewGzipWriter(&buf, 42); err == nil {
+		t.Error("an invalid level should fail")
+	}
+}
+
+func TestGzipFlushWindow(t *testing.T) {
+	const window = 1000
+	var buf bytes.Buffer
+	gw, _ := NewGzipWriter(&buf, gzip.DefaultCompression, GzipFlushBytes(window), GzipFlushInterval(0))
+	var written strings.Builder
+	for i := 0; i < 500; i++ {
+		line := fmt.Sprintf("entry %d %s\n", i, strings.Repeat("x", i%50))
+		if _, err := gw.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		written.WriteString(line)
+		// 模拟此时崩溃：已经写入底层的部分能够解压，丢失的部分不超过一个刷新窗口
+		got := gunzip(t, buf.Bytes())
+		if !strings.HasPrefix(written.String(), got) {
+			t.Fatalf("after entry %d: decoded data is not a prefix of the input", i)
+		}
+		if lost := written.Len() - len(got); lost >= window {
+			t.Fatalf("after entry %d: lost %d bytes, want < %d", i, lost, window)
+		}
+	}
+}
+
+// syncBuffer 是可以并发读写的 bytes.Buffer
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+func TestGzipFlushInterval(t *testing.T) {
+	var buf syncBuffer
+	gw, _ := NewGzipWriter(&buf, gzip.BestSpeed, GzipFlushInterval(10*time.Millisecond))
+	defer gw.Close()
+	_, _ = gw.Write([]byte("hello\n"))
+	deadline := time.Now().Add(5 * time.Second)
+	for gunzip(t, buf.Bytes()) != "hello\n" {
+		if time.Now().After(deadline) {
+			t.Fatal("the entry was not flushed after the interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGzipRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	f, err := NewRotatingFile(path, FileMaxSize(2000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw, _ := NewGzipWriter(f, gzip.BestSpeed, GzipFlushBytes(1000), GzipFlushInterval(0))
+	l := New(InfoLevel, OOutput(gw), OFlag(Llevel))
+	var want strings.Builder
+	for i := 0; i < 2000; i++ {
+		l.Info("entry", i, strings.Repeat(fmt.Sprint(i%7), i%40+1))
+		fmt.Fprintf(&want, "INFO entry %d %s\n", i, strings.Repeat(fmt.Sprint(i%7), i%40+1))
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每个文件都是独立完整的 gzip 流，从最旧的文件开始依次拼接得到全部日志
+	files, _ := filepath.Glob(path + ".*")
+	if len(files) < 2 {
+		t.Fatalf("want several rotated files, got %d", len(files)+1)
+	}
+	var got strings.Builder
+	for n := len(files); n >= 0; n-- {
+		b, err := os.ReadFile(f.backup(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		br := bytes.NewReader(b)
+		r, err := gzip.NewReader(br)
+		if err != nil {
+			t.Fatalf("%s: %v", f.backup(n), err)
+		}
+		r.Multistream(false)
+		data, err := io.ReadAll(r)
+		if err != nil || br.Len() != 0 {
+			t.Fatalf("%s is not a single complete gzip stream: %v, %d bytes left", f.backup(n), err, br.Len())
+		}
+		got.Write(data)
+	}
+	if got.String() != want.String() {
+		t.Errorf("decoded %d bytes, want %d", got.Len(), want.Len())
+	}
+}
@@ -0,0 +1,29 @@
+package elog
+
+import "time"
+
+// FormatHeader 把一条日志的头部追加到 *buf 中，与 l 输出的日志中消息之前的部分逐字节相同，
+// 类似标准库 log 中的 formatHeader，供自己实现传输方式、不经过 Out 的调用方使用：
+//
+//	var buf []byte
+//	l.FormatHeader(&buf, time.Now(), elog.WarnLevel, file, line)
+//	buf = append(buf, msg...)
+//
+// 头部遵循 l 当前的 flag、输出顺序、时间格式、对齐以及颜色设置，由输出日志时使用的同一组函数生成；
+// 输出顺序中位于消息之后的项不属于头部。没有设置 Lshortfile 或 Llongfile 时 file 和 line 被忽略。
+// 头部以空格结尾（头部为空时除外，设置了 OMsgSeparator 时以该分隔符结尾），这一格式是稳定的，之后的版本不会改变。
+func (l *Log) FormatHeader(buf *[]byte, t time.Time, level logLevel, file string, line int) {
+	l.lazyInit()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	flag := l.flag
+	if flag&LUTC != 0 {
+		t = t.UTC()
+	}
+	e := Entry{Time: t, Level: level, LoggerName: l.name, Prefix: l.prefix, File: file, Line: line}
+	l.buf = l.buf[:0]
+	l.colorForced = !colorEnabled() && l.forcesColor()
+	l.format(flag, l.steps, &e, true)
+	*buf = append(*buf, l.buf...)
+	l.shrinkBuffers()
+}
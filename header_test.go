@@ -0,0 +1,57 @@
+package elog
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatHeader(t *testing.T) {
+	now := time.Date(2024, 3, 5, 14, 7, 9, 123456000, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		name    string
+		options []LogOption
+	}{
+		{"std", []LogOption{OFlag(LstdFlags)}},
+		{"none", []LogOption{OFlag(0)}},
+		{"path", []LogOption{OFlag(Ldate | Lmicroseconds | Llevel | Lshortfile), OPrefix("[app]")}},
+		{"msgprefix", []LogOption{OFlag(Ltime | Llevel | Lmsgprefix | LUTC), OPrefix("[app]")}},
+		{"iso", []LogOption{OFlag(Ldate | Ldateiso | Ltime | Llongfile)}},
+		{"layout", []LogOption{OFlag(Ldate | Llevel), OTimeLayout(time.RFC1123Z)}},
+		{"order", []LogOption{OFlag(LstdFlags | Lshortfile), OOrder(OrderLevel, OrderPath, OrderMsg, OrderDate)}},
+		{"align", []LogOption{OFlag(Llevel), OAlign(10)}},
+		{"color", []LogOption{OFlag(Llevel | LlevelLabelColor | Lmsgprefix | LnameColor), OPrefix("[app]"), OName("app")}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			l := New(InfoLevel, append([]LogOption{OOutput(&b), ONow(func() time.Time { return now })}, tc.options...)...)
+			_, file, line, _ := runtime.Caller(0)
+			l.Warn("msg")
+			line++
+
+			header := []byte("keep:")
+			l.FormatHeader(&header, now, WarnLevel, file, line)
+			// 头部之后紧跟消息，order 中位于消息之后的项在消息之后输出
+			if !strings.HasPrefix(b.String(), string(header[5:])+"msg") {
+				t.Errorf("\n header: %q\n entry:  %q", header[5:], b.String())
+			}
+			if string(header[:5]) != "keep:" {
+				t.Errorf("FormatHeader should append to buf, got %q", header)
+			}
+		})
+	}
+}
+
+func TestFormatHeaderOrder(t *testing.T) {
+	now := time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}), OFlag(LstdFlags), OOrder(OrderLevel, OrderMsg, OrderDate))
+	var header []byte
+	l.FormatHeader(&header, now, ErrorLevel, "", 0)
+	// 位于消息之后的日期和时间不属于头部
+	if got, want := string(header), "ERROR "; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
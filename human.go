@@ -0,0 +1,143 @@
+package elog
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// humanValue 由 HumanDuration 和 HumanBytes 实现
+type humanValue interface {
+	appendText(buf []byte) []byte
+}
+
+// HumanDuration 是以易读形式输出的时长，见 Duration
+type HumanDuration time.Duration
+
+// Duration 返回以易读形式输出的 d，如 "1.25s"、"430ms"、"2.5h"，而不是纳秒数或 time.Duration 的完整精度。
+// 它实现了 fmt.Stringer，可以直接作为 Info 等方法的参数，也可以作为字段的值，JSON 中输出为字符串：
+//
+//	l.Info("request done in", elog.Duration(time.Since(start)))
+//	l.With().Any("elapsed", elog.Duration(d)).Msg("done")
+//
+// 小于 1µs 时输出整数纳秒数，否则按 µs、ms、s、m、h 中使数值不小于 1 的最大单位输出，最多保留两位小数并去掉末尾的 0；
+// 四舍五入后达到下一个单位时进位，如 999.996ms 输出为 "1s"。
+func Duration(d time.Duration) HumanDuration {
+	return HumanDuration(d)
+}
+
+var durationUnits = [...]struct {
+	size time.Duration
+	name string
+}{
+	{time.Hour, "h"},
+	{time.Minute, "m"},
+	{time.Second, "s"},
+	{time.Millisecond, "ms"},
+	{time.Microsecond, "µs"},
+}
+
+func (d HumanDuration) String() string {
+	return string(d.appendText(nil))
+}
+
+// appendText 把 d 的易读形式追加到 buf 中，作为字段的值时不经过 fmt 直接追加
+func (d HumanDuration) appendText(buf []byte) []byte {
+	v := float64(d)
+	if v < 0 {
+		buf = append(buf, '-')
+		v = -v
+	}
+	if v < float64(time.Microsecond) {
+		return append(strconv.AppendFloat(buf, v, 'f', 0, 64), "ns"...)
+	}
+	// 从大到小选择单位，四舍五入后不小于 1 即可，59.999s 在 m 这一级就已经是 1m
+	for _, u := range durationUnits {
+		if f := v / float64(u.size); round2(f) >= 1 {
+			return appendDecimal(buf, f, u.name)
+		}
+	}
+	return buf
+}
+
+// MarshalText 实现 encoding.TextMarshaler，使 encoding/json 等以字符串输出 d
+func (d HumanDuration) MarshalText() ([]byte, error) {
+	return d.appendText(nil), nil
+}
+
+// HumanBytes 是以易读形式输出的字节数，见 Bytes
+type HumanBytes struct {
+	n  int64
+	si bool
+}
+
+// Bytes 返回以易读形式输出的字节数 n，默认使用 IEC 单位（按 1024 进位），如 "512B"、"2.3MiB"，
+// 调用 SI 方法可以改为 SI 单位（按 1000 进位），如 "2.4MB"。与 Duration 一样实现了 fmt.Stringer，
+// 可以直接作为 Info 等方法的参数或字段的值：
+//
+//	l.Info("uploaded", elog.Bytes(n))
+//	l.With().Any("size", elog.Bytes(n).SI()).Msg("uploaded")
+//
+// 小于 1 个单位时输出整数字节数，否则最多保留两位小数并去掉末尾的 0，四舍五入后达到下一个单位时进位。
+func Bytes(n int64) HumanBytes {
+	return HumanBytes{n: n}
+}
+
+// SI 返回使用 SI 单位（kB、MB、GB……）输出的 b
+func (b HumanBytes) SI() HumanBytes {
+	b.si = true
+	return b
+}
+
+var (
+	iecUnits = [...]string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siUnits  = [...]string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+)
+
+func (b HumanBytes) String() string {
+	return string(b.appendText(nil))
+}
+
+// appendText 把 b 的易读形式追加到 buf 中
+func (b HumanBytes) appendText(buf []byte) []byte {
+	base, units := 1024.0, iecUnits[:]
+	if b.si {
+		base, units = 1000, siUnits[:]
+	}
+	v := float64(b.n)
+	if v < 0 {
+		buf = append(buf, '-')
+		v = -v
+	}
+	if v < base {
+		return append(strconv.AppendFloat(buf, v, 'f', 0, 64), units[0]...)
+	}
+	i := 0
+	for i < len(units)-1 && round2(v) >= base {
+		v /= base
+		i++
+	}
+	return appendDecimal(buf, v, units[i])
+}
+
+// MarshalText 实现 encoding.TextMarshaler，使 encoding/json 等以字符串输出 b
+func (b HumanBytes) MarshalText() ([]byte, error) {
+	return b.appendText(nil), nil
+}
+
+// round2 把 v 四舍五入到两位小数
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// appendDecimal 追加最多保留两位小数、去掉末尾 0 的 v 以及单位 unit
+func appendDecimal(buf []byte, v float64, unit string) []byte {
+	buf = strconv.AppendFloat(buf, round2(v), 'f', 2, 64)
+	for buf[len(buf)-1] == '0' {
+		buf = buf[:len(buf)-1]
+	}
+	if buf[len(buf)-1] == '.' {
+		buf = buf[:len(buf)-1]
+	}
+	return append(buf, unit...)
+}
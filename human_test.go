@@ -0,0 +1,77 @@
+package elog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0ns"},
+		{999, "999ns"},
+		{999995, "1ms"}, // 999.995µs 四舍五入后进位
+		{1500, "1.5µs"},
+		{430 * time.Millisecond, "430ms"},
+		{1250 * time.Millisecond, "1.25s"},
+		{1234567 * time.Microsecond, "1.23s"},
+		{999996 * time.Microsecond, "1s"},
+		{59999 * time.Millisecond, "1m"},
+		{90 * time.Second, "1.5m"},
+		{150 * time.Minute, "2.5h"},
+		{-1250 * time.Millisecond, "-1.25s"},
+	}
+	for _, tc := range tests {
+		if got := Duration(tc.d).String(); got != tc.want {
+			t.Errorf("Duration(%d): want %q, got %q", tc.d, tc.want, got)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		b    HumanBytes
+		want string
+	}{
+		{Bytes(0), "0B"},
+		{Bytes(1023), "1023B"},
+		{Bytes(1024), "1KiB"},
+		{Bytes(1536), "1.5KiB"},
+		{Bytes(1048575), "1MiB"}, // 1023.999KiB 四舍五入后进位
+		{Bytes(2411725), "2.3MiB"},
+		{Bytes(5 << 30), "5GiB"},
+		{Bytes(1 << 62), "4EiB"},
+		{Bytes(-2048), "-2KiB"},
+		{Bytes(999).SI(), "999B"},
+		{Bytes(1000).SI(), "1kB"},
+		{Bytes(2411725).SI(), "2.41MB"},
+		{Bytes(999999).SI(), "1MB"},
+	}
+	for _, tc := range tests {
+		if got := tc.b.String(); got != tc.want {
+			t.Errorf("Bytes(%d): want %q, got %q", tc.b.n, tc.want, got)
+		}
+	}
+}
+
+func TestHumanValues(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(0))
+	l.Info("took", Duration(1250*time.Millisecond), "for", Bytes(2411725))
+	l.With().Any("elapsed", Duration(430*time.Millisecond)).Any("size", Bytes(1000).SI()).Msg("done")
+	if got, want := b.String(), "took 1.25s for 2.3MiB\ndone elapsed=430ms size=1kB\n"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	got, _ := json.Marshal(map[string]any{"d": Duration(time.Second), "b": Bytes(1024)})
+	if string(got) != `{"b":"1KiB","d":"1s"}` {
+		t.Errorf("unexpected JSON %s", got)
+	}
+	if got := string(appendFieldJSON(nil, Any("d", Duration(time.Second)))); got != `"1s"` {
+		t.Errorf("unexpected JSON field %s", got)
+	}
+}
@@ -0,0 +1,105 @@
+package elog
+
+import (
+	"io"
+	"os"
+	"reflect"
+)
+
+// Inherit 系列常量是 OInherit 的参数，表示 Extend 创建的子 logger 从父 logger 继承哪些配置，可以按位组合
+const (
+	InheritOutput      = 1 << iota // 输出，包括 OAuditOutput 设置的审计日志输出和 AddOutput 添加的输出
+	InheritLevel                   // 最低等级和等级范围
+	InheritFlags                   // flag
+	InheritPrefix                  // 前缀
+	InheritOrder                   // 输出顺序
+	InheritMiddlewares             // 中间件、过滤器和 context 提取器
+	InheritAll         = InheritOutput | InheritLevel | InheritFlags | InheritPrefix | InheritOrder | InheritMiddlewares
+)
+
+// OInherit 使 Extend 创建的子 logger 只继承 mask 中的配置，其余的配置与 New 创建的 logger 相同：
+// 输出为标准错误，等级为 InfoLevel，没有 flag、前缀、输出顺序和中间件。
+// 时钟、时间格式、错误处理函数等不在 Inherit 系列常量之内的配置总是继承。
+// 不传 OInherit 时与 OInherit(InheritAll) 相同，继承全部配置。例如只继承输出和等级，从干净的 flag、前缀和输出顺序开始：
+//
+//	child := parent.Extend(elog.OInherit(elog.InheritOutput|elog.InheritLevel), elog.OFlag(elog.Lmsgprefix))
+//
+// 需要去掉少数几项时可以写作 OInherit(InheritAll &^ (InheritFlags | InheritOrder))。
+// OInherit 与其它选项的先后顺序无关：同时传入的 OFlag 等选项设置的配置总是保留。只在 Extend 中生效，New 会忽略它。
+func OInherit(mask int) LogOption {
+	return func(logger *Log) {
+		logger.reset = InheritAll &^ mask
+	}
+}
+
+// inheritSnapshot 是 Extend 应用选项之前子 logger 从父 logger 复制来的配置，应用选项之后与它相同的配置没有被选项修改，
+// 可以按 OInherit 恢复为默认值。中间件等列表类的选项总是追加，只记录长度，不继承时去掉列表开头继承来的元素。
+type inheritSnapshot struct {
+	output, audit          io.Writer
+	writers, order         uintptr
+	nWriters, nOrder       int
+	level, upper           logLevel
+	flag                   int
+	prefix                 string
+	nEncoded, nMiddlewares int
+	nFilters, nExtractors  int
+}
+
+func (l *Log) inheritSnapshot() inheritSnapshot {
+	min, max := l.levelRange()
+	return inheritSnapshot{
+		output: l.output, audit: l.audit,
+		writers: slicePtr(l.writers), nWriters: len(l.writers),
+		order: slicePtr(l.order), nOrder: len(l.order),
+		level: min, upper: max, flag: l.flag, prefix: l.prefix,
+		nEncoded: len(l.encoded), nMiddlewares: len(l.middlewares),
+		nFilters: len(l.filters), nExtractors: len(l.extractors),
+	}
+}
+
+// slicePtr 返回切片底层数组的地址，与长度一起判断切片是否被选项替换过
+func slicePtr(s any) uintptr {
+	return reflect.ValueOf(s).Pointer()
+}
+
+// sameWriter 报告 a 和 b 是否为同一个 Writer，无法比较的 Writer 视为不同
+func sameWriter(a, b io.Writer) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if t := reflect.TypeOf(a); t != reflect.TypeOf(b) || !t.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// resetInherited 把 l.reset 中没有被选项修改过的配置恢复为默认值，snap 是应用选项之前的快照
+func (l *Log) resetInherited(snap inheritSnapshot) {
+	if l.reset&InheritOutput != 0 {
+		if sameWriter(l.output, snap.output) && slicePtr(l.writers) == snap.writers && len(l.writers) == snap.nWriters {
+			l.writers = []io.Writer{os.Stderr}
+			l.output = newFanout(l.writers...)
+		}
+		if sameWriter(l.audit, snap.audit) {
+			l.audit = nil
+		}
+		l.encoded = append([]*encodedOutput(nil), l.encoded[snap.nEncoded:]...)
+	}
+	if min, max := l.levelRange(); l.reset&^l.touched&InheritLevel != 0 && min == snap.level && max == snap.upper {
+		l.storeLevelRange(InfoLevel, FatalLevel)
+	}
+	if l.reset&^l.touched&InheritFlags != 0 && l.flag == snap.flag {
+		l.flag = 0
+	}
+	if l.reset&^l.touched&InheritPrefix != 0 && l.prefix == snap.prefix {
+		l.prefix = ""
+	}
+	if l.reset&InheritOrder != 0 && slicePtr(l.order) == snap.order && len(l.order) == snap.nOrder {
+		l.order, l.steps = nil, nil
+	}
+	if l.reset&InheritMiddlewares != 0 {
+		l.middlewares = append([]Middleware(nil), l.middlewares[snap.nMiddlewares:]...)
+		l.filters = append([]filter(nil), l.filters[snap.nFilters:]...)
+		l.extractors = append([]ContextExtractor(nil), l.extractors[snap.nExtractors:]...)
+	}
+}
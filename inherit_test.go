@@ -0,0 +1,75 @@
+package elog
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestInheritMasks(t *testing.T) {
+	var out, audit bytes.Buffer
+	parent := New(WarnLevel, OOutput(&out), OAuditOutput(&audit), OFlag(Llevel|Lmsgprefix), OPrefix("[p]"), OOrder(OrderMsg))
+	parent.Use(func(e *Entry) bool { return true })
+	parent.AddOutput(&bytes.Buffer{}, WithEncoder(JSONEncoder))
+
+	for mask := 0; mask <= InheritAll; mask++ {
+		c := parent.Extend(OInherit(mask))
+		has := func(bit int) bool { return mask&bit != 0 }
+		if got := c.writers[0] == &out && c.audit == &audit && len(c.encoded) == 1; got != has(InheritOutput) {
+			t.Errorf("mask %06b: output inherited = %v", mask, got)
+		}
+		if !has(InheritOutput) && (len(c.writers) != 1 || c.writers[0] != os.Stderr || c.audit != nil || c.encoded != nil) {
+			t.Errorf("mask %06b: output should be reset to stderr", mask)
+		}
+		if got := c.Level() == WarnLevel; got != has(InheritLevel) {
+			t.Errorf("mask %06b: level = %v", mask, c.Level())
+		}
+		if got := c.Flag() == parent.Flag(); got != has(InheritFlags) || !got && c.Flag() != 0 {
+			t.Errorf("mask %06b: flag = %b", mask, c.Flag())
+		}
+		if got := c.Prefix() == "[p]"; got != has(InheritPrefix) || !got && c.Prefix() != "" {
+			t.Errorf("mask %06b: prefix = %q", mask, c.Prefix())
+		}
+		if got := reflect.DeepEqual(c.Order(), parent.Order()); got != has(InheritOrder) || !got && len(c.Order()) != 0 {
+			t.Errorf("mask %06b: order = %v", mask, c.Order())
+		}
+		if got := len(c.middlewares) == 1; got != has(InheritMiddlewares) {
+			t.Errorf("mask %06b: %d middlewares", mask, len(c.middlewares))
+		}
+	}
+
+	// 不传 OInherit 时继承全部配置
+	if c := parent.Extend(); c.Level() != WarnLevel || c.Prefix() != "[p]" || len(c.middlewares) != 1 || c.writers[0] != &out {
+		t.Error("Extend without OInherit should inherit everything")
+	}
+}
+
+func TestInheritWithOptions(t *testing.T) {
+	var out bytes.Buffer
+	parent := New(WarnLevel, OOutput(&out), OFlag(Llevel|Lmsgprefix), OPrefix("[p]"))
+	parent.Use(func(e *Entry) bool { e.Msg += " parent"; return true })
+
+	// 同时传入的选项总是保留，与顺序无关，设置为与父 logger 相同的值也不例外
+	c := parent.Extend(
+		OFlag(Llevel|Lmsgprefix),
+		OInherit(InheritOutput),
+		OPrefix("[c]"),
+		func(l *Log) { l.Use(func(e *Entry) bool { e.Msg += " child"; return true }) },
+	)
+	if c.Flag() != Llevel|Lmsgprefix || c.Prefix() != "[c]" || c.Level() != InfoLevel {
+		t.Errorf("flag %b, prefix %q, level %v", c.Flag(), c.Prefix(), c.Level())
+	}
+	c.Info("hi")
+	if got, want := out.String(), "INFO [c] hi child\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// 只去掉少数几项
+	c = parent.Extend(OInherit(InheritAll &^ InheritPrefix))
+	out.Reset()
+	c.Warn("hi")
+	if got, want := out.String(), "WARN hi parent\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
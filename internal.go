@@ -0,0 +1,130 @@
+package elog
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	internalRate  = 10 // 内部诊断日志长期平均每秒最多输出的条数
+	internalBurst = 10 // 空闲之后允许连续输出的条数
+)
+
+var (
+	internalOnce    sync.Once
+	internalLog     atomic.Value // *Log，见 Internal
+	internalLimiter = tokenBucket{rate: internalRate, burst: internalBurst}
+	// internalNop 是 SetInternal(nil) 使用的不输出任何日志的诊断 logger，所有调用共用一个
+	internalNop = New(Discard, OName("elog"), OOutput(io.Discard)).Mute()
+)
+
+// Internal 返回 elog 自身的诊断 logger。elog 的各个组件遇到无法返回给调用方的问题时都通过它报告，如：
+//
+//   - 没有设置错误处理函数的 logger 写入失败
+//   - 回调 panic 且没有设置错误处理函数（见 CallbackPanicError）
+//   - AsyncWriter 写入失败或因队列已满丢弃日志（没有设置 AsyncDropReport 时）
+//   - RotatingFile 轮转失败
+//   - 被忽略的无效配置，如 OLineEnding 的参数不以 '\n' 结尾
+//
+// 默认以 "[elog]" 为前缀输出到标准错误。诊断日志是限流的，平均每秒最多输出 10 条，空闲之后允许一次突发 10 条，
+// 超出的条数及其持续的时间会在下一次输出时汇总报告，避免写入持续失败时刷屏。可以通过 SetInternal 替换或关闭。
+func Internal() *Log {
+	internalOnce.Do(func() {
+		internalLog.CompareAndSwap(nil, New(InfoLevel, OName("elog"), OPrefix("[elog]"), OFlag(Ldate|Ltime|Llevel|Lmsgprefix), OOutput(os.Stderr)))
+	})
+	return internalLog.Load().(*Log)
+}
+
+// SetInternal 替换 elog 的诊断 logger，如把诊断日志写到应用自己的 logger；l 为 nil 时不再输出诊断日志。
+// l 写入失败时不会再报告给它自己。
+func SetInternal(l *Log) {
+	if l == nil {
+		l = internalNop
+	}
+	internalOnce.Do(func() {})
+	internalLog.Store(l)
+}
+
+// internalf 以 level 等级输出一条诊断日志，超出限流时丢弃并计数。调用方不能持有诊断 logger 可能用到的锁。
+func internalf(level logLevel, format string, v ...any) {
+	suppressed, window, ok := internalLimiter.allow(time.Now())
+	if !ok {
+		return
+	}
+	l := Internal()
+	if suppressed > 0 {
+		l.Out(defaultCallDepth, WarnLevel, fmt.Sprintf("suppressed %d internal messages in the last %v", suppressed, window))
+	}
+	// 诊断日志已经带有 "[elog]" 前缀，去掉错误信息中重复的 "elog: "
+	msg, fs := sprintf(format, v)
+	l.out(defaultCallDepth, level, strings.TrimPrefix(msg, "elog: "), fs)
+}
+
+// tokenBucket 是令牌桶限流器：桶中最多有 burst 个令牌，每秒补充 rate 个，每次输出消耗一个。
+// 与固定窗口不同，窗口边界处的突发不会被误伤，持续的刷屏则被限制在 rate 以内。
+// 时间使用 time.Now 的单调读数，不受系统时钟调整的影响。
+type tokenBucket struct {
+	mu         sync.Mutex
+	rate       float64
+	burst      float64
+	tokens     float64
+	last       time.Time // 上一次补充令牌的时刻，零值表示桶是满的
+	suppressed int
+	since      time.Time // 尚未报告的抑制中第一次发生的时刻
+}
+
+// allow 报告 now 时刻是否允许输出，允许时一并返回之前被抑制、尚未报告的次数，以及从第一次抑制到现在的时长
+func (b *tokenBucket) allow(now time.Time) (suppressed int, window time.Duration, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.last.IsZero() {
+		b.tokens = b.burst
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		if b.suppressed == 0 {
+			b.since = now
+		}
+		b.suppressed++
+		return 0, 0, false
+	}
+	b.tokens--
+	if b.suppressed > 0 {
+		suppressed, window = b.suppressed, now.Sub(b.since)
+		b.suppressed = 0
+	}
+	return suppressed, window, true
+}
+
+// warnedConfig 记录已经报告过的无效配置，同样的无效配置只报告一次
+var warnedConfig sync.Map
+
+// checkConfig 报告应用选项时遇到的无效配置，在 New 和 Extend 应用完选项后调用
+func (l *Log) checkConfig() {
+	errs := l.configErrs
+	l.configErrs = nil
+	for _, err := range errs {
+		l.configFailed(err)
+	}
+}
+
+// configFailed 报告被忽略的无效配置，设置了 OStrictConfig 时 panic。不能持有锁调用。
+func (l *Log) configFailed(err error) {
+	l.mu.RLock()
+	strict := l.strictConfig
+	l.mu.RUnlock()
+	if strict {
+		panic(err)
+	}
+	if _, warned := warnedConfig.LoadOrStore(err.Error(), true); !warned {
+		internalf(WarnLevel, "%v, ignored", err)
+	}
+}
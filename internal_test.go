@@ -0,0 +1,183 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureInternal 把诊断日志重定向到返回的 buffer，测试结束时恢复
+func captureInternal(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	prev := Internal()
+	var b bytes.Buffer
+	SetInternal(New(DebugLevel, OOutput(&b), OPrefix("[elog]"), OFlag(Llevel|Lmsgprefix)))
+	resetInternalLimiter()
+	t.Cleanup(func() {
+		SetInternal(prev)
+		resetInternalLimiter()
+	})
+	return &b
+}
+
+func resetInternalLimiter() {
+	internalLimiter.mu.Lock()
+	internalLimiter.last, internalLimiter.suppressed = time.Time{}, 0
+	internalLimiter.mu.Unlock()
+}
+
+func TestInternalWriteError(t *testing.T) {
+	b := captureInternal(t)
+	l := New(InfoLevel, OName("app"), OOutput(failWriter{errors.New("disk full")}))
+	l.Info("hello")
+	if got, want := b.String(), "ERROR [elog] logger \"app\": write failed: disk full\n"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	// 设置了错误处理函数时只交给它
+	b.Reset()
+	l.SetErrorHandler(func(error) {})
+	l.Info("hello")
+	if b.Len() != 0 {
+		t.Errorf("errors handled by the logger should not be reported, got %q", b.String())
+	}
+}
+
+func TestInternalDoesNotReportItself(t *testing.T) {
+	captureInternal(t)
+	l := New(InfoLevel, OOutput(failWriter{errors.New("disk full")}))
+	SetInternal(l)
+	internalf(ErrorLevel, "boom")
+	if l.WriteErrors() != 1 {
+		t.Errorf("want exactly one failed write, got %d", l.WriteErrors())
+	}
+}
+
+func TestInternalCallbackPanic(t *testing.T) {
+	b := captureInternal(t)
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}))
+	l.Use(func(e *Entry) bool { panic("boom") })
+	l.Info("hello")
+	if got := b.String(); !strings.HasPrefix(got, "ERROR [elog] middleware panicked: boom\n") || !strings.Contains(got, "internal_test.go") {
+		t.Errorf("unexpected report %q", got)
+	}
+}
+
+func TestInternalRotateError(t *testing.T) {
+	b := captureInternal(t)
+	f, err := NewRotatingFile(t.TempDir()+"/logs/app.log", FileMaxSize(8), FileMaxBackups(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Write([]byte("0123456789"))
+	f.file.Close() // 让轮转时关闭文件失败
+	if _, err := f.Write([]byte("next")); err == nil {
+		t.Fatal("write should fail when rotation fails")
+	}
+	if got := b.String(); !strings.HasPrefix(got, "ERROR [elog] rotate log file: ") {
+		t.Errorf("unexpected report %q", got)
+	}
+}
+
+func TestInternalAsyncDrops(t *testing.T) {
+	b := captureInternal(t)
+	w := &blockingWriter{release: make(chan struct{})}
+	a := NewAsyncWriter(w, 1)
+	a.Write([]byte("first\n")) // 被后台 goroutine 取走后阻塞
+	waitFor(t, func() bool { return a.Len() == 0 })
+	a.Write([]byte("queued\n"))
+	a.Write([]byte("dropped\n"))
+	close(w.release)
+	a.Close()
+	if got := b.String(); !strings.Contains(got, "WARN [elog] async writer: queue full, dropped 1 entries\n") {
+		t.Errorf("unexpected report %q", got)
+	}
+}
+
+func TestInternalRateLimit(t *testing.T) {
+	r := tokenBucket{rate: internalRate, burst: internalBurst}
+	now := time.Now()
+	for i := 0; i < internalBurst; i++ {
+		if _, _, ok := r.allow(now); !ok {
+			t.Fatalf("message %d should be allowed", i)
+		}
+	}
+	for i := 1; i <= 5; i++ {
+		if _, _, ok := r.allow(now.Add(time.Duration(i) * time.Millisecond)); ok {
+			t.Fatal("messages beyond the burst should be suppressed")
+		}
+	}
+	suppressed, window, ok := r.allow(now.Add(time.Second / internalRate))
+	if !ok || suppressed != 5 || window != time.Second/internalRate-time.Millisecond {
+		t.Errorf("the next token should report 5 suppressed messages in 99ms, got %d %v %v", suppressed, window, ok)
+	}
+}
+
+func TestTokenBucketBurstAcrossBoundary(t *testing.T) {
+	// 固定窗口会在窗口边界处放行两倍的突发，或者丢弃刚进入新窗口的突发；令牌桶只看空闲了多久
+	r := tokenBucket{rate: 10, burst: 10}
+	now := time.Now()
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		if _, _, ok := r.allow(now.Add(time.Duration(i) * time.Microsecond)); ok {
+			allowed++
+		}
+	}
+	if allowed != 10 {
+		t.Errorf("a burst should pass up to the bucket size, got %d", allowed)
+	}
+	if _, _, ok := r.allow(now.Add(2 * time.Second)); !ok {
+		t.Error("the bucket should refill after being idle")
+	}
+}
+
+func TestTokenBucketAverageRate(t *testing.T) {
+	for _, tc := range []struct {
+		rate, burst float64
+		step        time.Duration // 调用间隔，远小于 1/rate 时为持续刷屏
+	}{
+		{10, 10, time.Millisecond},
+		{10, 2, 7 * time.Millisecond},
+		{100, 5, 3 * time.Millisecond},
+		{0.5, 3, 50 * time.Millisecond},
+	} {
+		r := tokenBucket{rate: tc.rate, burst: tc.burst}
+		now := time.Now()
+		const duration = 200 * time.Second
+		calls, allowed, suppressed := 0, 0, 0
+		for d := time.Duration(0); d < duration; d += tc.step {
+			calls++
+			s, _, ok := r.allow(now.Add(d))
+			suppressed += s
+			if ok {
+				allowed++
+			}
+		}
+		if allowed+suppressed+r.suppressed != calls {
+			t.Errorf("%v: every suppressed message should be reported, got %d+%d+%d of %d", tc, allowed, suppressed, r.suppressed, calls)
+		}
+		// 长期平均不超过 rate，开头的突发之外最多多出一个令牌
+		want := tc.rate*duration.Seconds() + tc.burst
+		if got := float64(allowed); math.Abs(got-want) > want*0.01+1 {
+			t.Errorf("%v: allowed %v messages, want about %v", tc, got, want)
+		}
+	}
+}
+
+func TestSetInternalNil(t *testing.T) {
+	captureInternal(t)
+	SetInternal(nil)
+	if !Internal().Muted() {
+		t.Error("SetInternal(nil) should silence diagnostics")
+	}
+	internalf(ErrorLevel, "boom")
+	nop := Internal()
+	SetInternal(nil)
+	if Internal() != nop {
+		t.Error("SetInternal(nil) should not create a new logger every time")
+	}
+}
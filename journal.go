@@ -0,0 +1,116 @@
+package elog
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// journalSocket 是 systemd-journald 接收原生协议的套接字
+const journalSocket = "/run/systemd/journal/socket"
+
+// JournalEncoder 将日志编码为 systemd-journald 原生协议的一个数据报：PRIORITY 为 SyslogSeverity 映射的等级，
+// MESSAGE 为消息（设置了 Lmsgprefix 时带上前缀），SYSLOG_IDENTIFIER 为 logger 名称（没有名称时为程序名），
+// 获取了文件路径时附带 CODE_FILE、CODE_LINE、CODE_FUNC，字段以大写的键名作为 journal 字段，如 user 为 USER。
+// 键名中字母、数字、下划线之外的字符替换为 '_'，开头的下划线会被去掉（journal 保留给可信字段），以数字开头时加上 X 前缀。
+// 一般通过 AddJournal 使用。
+var JournalEncoder Encoder = journalEncoder{}
+
+type journalEncoder struct{}
+
+// journalIdentifier 是 logger 没有名称时使用的 SYSLOG_IDENTIFIER
+var journalIdentifier = filepath.Base(os.Args[0])
+
+func (journalEncoder) Encode(buf []byte, e *Entry, flag int) []byte {
+	buf = appendJournalField(buf, "PRIORITY", strconv.Itoa(SyslogSeverity(e.Level)))
+	msg := e.Msg
+	if flag&Lmsgprefix != 0 && e.Prefix != "" {
+		msg = e.Prefix + " " + msg
+	}
+	buf = appendJournalField(buf, "MESSAGE", msg)
+	id := e.LoggerName
+	if id == "" {
+		id = journalIdentifier
+	}
+	buf = appendJournalField(buf, "SYSLOG_IDENTIFIER", id)
+	if e.File != "" {
+		buf = appendJournalField(buf, "CODE_FILE", e.File)
+		buf = appendJournalField(buf, "CODE_LINE", strconv.Itoa(e.Line))
+		if e.Func != "" {
+			buf = appendJournalField(buf, "CODE_FUNC", e.Func)
+		}
+	}
+	for _, f := range e.Fields {
+		key := journalKey(f.Key)
+		if key == "" {
+			continue
+		}
+		buf = appendJournalField(buf, key, string(appendFieldText(nil, f)))
+	}
+	return buf
+}
+
+// appendJournalField 追加一个 journal 字段，值中含有换行符时使用带长度的二进制格式
+func appendJournalField(buf []byte, key, value string) []byte {
+	buf = append(buf, key...)
+	if !strings.Contains(value, "\n") {
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+		return append(buf, '\n')
+	}
+	buf = append(buf, '\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf = append(buf, size[:]...)
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
+
+// journalKey 把字段的键名转换为合法的 journal 字段名，无法转换时返回空字符串
+func journalKey(key string) string {
+	key = strings.TrimLeft(key, "_")
+	if key == "" {
+		return ""
+	}
+	b := make([]byte, 0, len(key)+1)
+	if key[0] >= '0' && key[0] <= '9' {
+		b = append(b, 'X')
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+		default:
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+// AddJournal 把日志以原生协议写入 systemd-journald，保留等级、文件路径和字段等元数据，见 JournalEncoder。
+// 只在 Linux 上可用；套接字 /run/systemd/journal/socket 不存在（如没有 systemd 的容器）或不是 Linux 时，
+// 改为以文本格式输出到标准错误（已经输出到标准错误时不再重复添加）。Close 时会关闭与 journald 的连接。
+//
+// 超过套接字缓冲区上限的单条日志（通常约 200 KiB）会写入失败并交给错误处理函数。
+func (l *Log) AddJournal() *Log {
+	return l.addJournal(journalSocket)
+}
+
+// addStderr 添加标准错误作为文本输出，已经输出到标准错误时不重复添加
+func (l *Log) addStderr() *Log {
+	l.mu.RLock()
+	for _, w := range l.writers {
+		if unwrapWriter(w) == io.Writer(os.Stderr) {
+			l.mu.RUnlock()
+			return l
+		}
+	}
+	l.mu.RUnlock()
+	return l.AddOutput(os.Stderr)
+}
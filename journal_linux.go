@@ -0,0 +1,14 @@
+//go:build linux
+
+package elog
+
+import "net"
+
+// addJournal 连接 path 上的 journald 套接字并添加为使用 JournalEncoder 的输出，连接失败时添加标准错误
+func (l *Log) addJournal(path string) *Log {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return l.addStderr()
+	}
+	return l.AddOutput(conn, WithEncoder(JournalEncoder))
+}
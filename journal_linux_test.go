@@ -0,0 +1,87 @@
+//go:build linux
+
+package elog
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.socket")
+	ln, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram not supported: %v", err)
+	}
+	defer ln.Close()
+
+	var text bytes.Buffer
+	l := New(InfoLevel, OOutput(&text), OFlag(Lshortfile), OName("svc")).addJournal(path)
+	l.With().Warn().Str("user", "tom").Msg("hello")
+	l.Close()
+
+	buf := make([]byte, 4096)
+	ln.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := ln.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(buf[:n])
+	for _, want := range []string{"PRIORITY=4\n", "MESSAGE=hello\n", "SYSLOG_IDENTIFIER=svc\n", "CODE_LINE=", "CODE_FILE=", "USER=tom\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("datagram should contain %q, got %q", want, got)
+		}
+	}
+	if text.String() == "" {
+		t.Error("existing outputs should still be written")
+	}
+}
+
+func TestAddJournalFallback(t *testing.T) {
+	l := New(InfoLevel, OOutput(os.Stderr)).addJournal(filepath.Join(t.TempDir(), "missing.socket"))
+	if len(l.writers) != 1 || len(l.encoded) != 0 {
+		t.Errorf("should fall back to the existing stderr output, got %d writers and %d encoded outputs", len(l.writers), len(l.encoded))
+	}
+	var buf bytes.Buffer
+	l = New(InfoLevel, OOutput(&buf)).addJournal(filepath.Join(t.TempDir(), "missing.socket"))
+	if len(l.writers) != 2 || len(l.encoded) != 0 {
+		t.Errorf("should add stderr as a text output, got %d writers and %d encoded outputs", len(l.writers), len(l.encoded))
+	}
+}
+
+// TestJournalIntegration 只在运行着 systemd-journald 并且可以使用 journalctl 的主机上执行
+func TestJournalIntegration(t *testing.T) {
+	if _, err := os.Stat(journalSocket); err != nil {
+		t.Skip("systemd-journald is not running")
+	}
+	journalctl, err := exec.LookPath("journalctl")
+	if err != nil {
+		t.Skip("journalctl not found")
+	}
+	id := "elog-test-" + strconv.Itoa(os.Getpid())
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}), OName(id)).AddJournal()
+	l.With().Error().Str("run", id).Msg("integration")
+	l.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		out, err := exec.Command(journalctl, "-q", "-o", "export", "SYSLOG_IDENTIFIER="+id).Output()
+		if err == nil && bytes.Contains(out, []byte("RUN="+id)) {
+			if !bytes.Contains(out, []byte("PRIORITY=3\n")) || !bytes.Contains(out, []byte("MESSAGE=integration\n")) {
+				t.Errorf("unexpected journal entry %q", out)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Skipf("entry not visible through journalctl (%v), the test user may lack permission", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package elog
+
+// addJournal 在 Linux 之外的平台上总是添加标准错误
+func (l *Log) addJournal(path string) *Log {
+	return l.addStderr()
+}
@@ -0,0 +1,51 @@
+package elog
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestJournalEncoder(t *testing.T) {
+	e := &Entry{
+		Level:      ErrorLevel,
+		LoggerName: "svc",
+		Prefix:     "[db]",
+		File:       "/src/app/db.go",
+		Line:       42,
+		Func:       "main.query",
+		Msg:        "query failed",
+		Fields:     []Field{String("user-id", "tom"), Int("2fa", 1), String("__secret", "x"), String("sql", "SELECT 1\nFROM t")},
+	}
+	got := string(JournalEncoder.Encode(nil, e, Lmsgprefix))
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len("SELECT 1\nFROM t")))
+	want := "PRIORITY=3\n" +
+		"MESSAGE=[db] query failed\n" +
+		"SYSLOG_IDENTIFIER=svc\n" +
+		"CODE_FILE=/src/app/db.go\n" +
+		"CODE_LINE=42\n" +
+		"CODE_FUNC=main.query\n" +
+		"USER_ID=tom\n" +
+		"X2FA=1\n" +
+		"SECRET=x\n" +
+		"SQL\n" + string(size[:]) + "SELECT 1\nFROM t\n"
+	if got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestJournalKey(t *testing.T) {
+	for key, want := range map[string]string{
+		"user":        "USER",
+		"http.status": "HTTP_STATUS",
+		"_":           "",
+		"_pid":        "PID",
+		"9lives":      "X9LIVES",
+		"Mixed_9":     "MIXED_9",
+	} {
+		if got := journalKey(key); got != want {
+			t.Errorf("journalKey(%q): want %q, got %q", key, want, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package elog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LevelStyle 是自定义等级的颜色，取值为 ANSI SGR 参数，如 "1;33;40"，为空时使用与审计日志相同的黑白配色
+type LevelStyle struct {
+	Label string // LlevelLabelColor 时等级标签的颜色，如内置的 Warn 为 "0;30;43"
+	Msg   string // Lmsgcolor、Llinecolor 时消息或整行的颜色，如内置的 Warn 为 "1;33;40"
+}
+
+var levelsMu sync.Mutex // 串行化 RegisterLevel，输出日志时读取等级表不加锁
+
+// RegisterLevel 注册一个自定义等级，之后 Log、Logf、ParseLevel、logLevel.String 以及各个 Encoder 都能识别它：
+//
+//	const NoticeLevel = elog.InfoLevel + 5
+//
+//	func init() {
+//		if err := elog.RegisterLevel(NoticeLevel, "NOTICE", elog.LevelStyle{Label: "0;30;44", Msg: "1;34;40"}); err != nil {
+//			panic(err)
+//		}
+//	}
+//
+//	l.Log(NoticeLevel, "maintenance window starts at 02:00")
+//
+// 内置等级的数值间隔为 10，value 必须位于 Discard 和 FatalLevel 之间、不与已有等级重复，等级的高低由数值决定，
+// 如 InfoLevel + 5 介于 Info 和 Warn 之间，SetLevel(NoticeLevel) 会过滤掉 Info 而保留 Warn。
+// label 不区分大小写地唯一、不含空格，短于 5 个字符时补齐到 5 个字符，与内置等级对齐。
+//
+// 等级表在输出日志时不加锁读取，因此 RegisterLevel 只能在包初始化（init 函数或包级变量的初始化）中调用，
+// 不能与任何 logger 输出日志并发执行。
+func RegisterLevel(value logLevel, label string, theme LevelStyle) error {
+	label = strings.TrimSpace(label)
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	switch {
+	case value <= Discard || value >= FatalLevel:
+		return errors.New("elog: custom level " + strconv.Itoa(int(value)) + " is not between Discard and FatalLevel")
+	case levelOf(value).levelLabel != "":
+		return errors.New("elog: level " + strconv.Itoa(int(value)) + " is already registered as " + value.String())
+	case label == "" || strings.ContainsAny(label, " \t\r\n"):
+		return errors.New("elog: invalid level label " + strconv.Quote(label))
+	}
+	if existing, err := ParseLevel(label); err == nil {
+		return errors.New("elog: level label " + strconv.Quote(label) + " is already used by level " + strconv.Itoa(int(existing)))
+	}
+	if theme.Label == "" {
+		theme.Label = "0;30;47"
+	}
+	if theme.Msg == "" {
+		theme.Msg = "1;37;40"
+	}
+	for len(label) < len(_InfoLabel) {
+		label += " "
+	}
+	levelMap[value] = levelInfo{
+		levelLabel:      label,
+		levelLabelColor: "\x1b[" + theme.Label + "m ",
+		levelColor:      "\x1b[" + theme.Msg + "m ",
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+//go:build !elog_nodebug
+
+package elog
+
+// debugCompiled 为 false 时 Debug 级别的调用在编译期被消除，见 level_debug_off.go
+const debugCompiled = true
+
+// Debug 以 DebugLevel 输出日志，参数的拼接方式见 OSprint
+func (l *Log) Debug(v ...any) {
+	if l.enabled(DebugLevel) {
+		l.Out(defaultCallDepth, DebugLevel, l.sprint(v))
+	}
+}
+
+func (l *Log) Debugf(format string, v ...any) {
+	if l.enabled(DebugLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, DebugLevel, msg, fs)
+	}
+}
+
+func Debug(v ...any) {
+	if l := Default(); l.enabled(DebugLevel) {
+		l.Out(defaultCallDepth, DebugLevel, l.sprint(v))
+	}
+}
+
+func Debugf(format string, v ...any) {
+	if l := Default(); l.enabled(DebugLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, DebugLevel, msg, fs)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build elog_nodebug
+
+package elog
+
+// 使用 elog_nodebug 构建标签编译时，Debug 和 Debugf 是空函数，编译器会将调用连同等级比较、
+// 参数装箱一起内联消除：
+//
+//	go build -tags elog_nodebug
+const debugCompiled = false
+
+func (l *Log) Debug(v ...any) {}
+
+func (l *Log) Debugf(format string, v ...any) {}
+
+func Debug(v ...any) {}
+
+func Debugf(format string, v ...any) {}
@@ -0,0 +1,78 @@
+package elog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// noticeLevel 是测试用的自定义等级，与应用一样在包初始化时注册
+var noticeLevel = func() logLevel {
+	const notice = InfoLevel + 5
+	if err := RegisterLevel(notice, "notice", LevelStyle{Label: "0;30;44", Msg: "1;34;40"}); err != nil {
+		panic(err)
+	}
+	return notice
+}()
+
+func TestCustomLevel(t *testing.T) {
+	if noticeLevel.String() != "notice" || InfoLevel.String() != "INFO" || logLevel(36).String() != "Level(36)" {
+		t.Errorf("String: %q %q %q", noticeLevel, InfoLevel, logLevel(36))
+	}
+	if level, err := ParseLevel("NOTICE"); err != nil || level != noticeLevel {
+		t.Errorf("ParseLevel: %v %v", level, err)
+	}
+
+	var text, js bytes.Buffer
+	l := New(noticeLevel, OOutput(&text), OFlag(Llevel)).AddOutput(&js, WithEncoder(JSONEncoder))
+	l.Info("filtered") // Info 低于 notice
+	l.Log(noticeLevel, "maintenance at", "02:00")
+	l.Logf(noticeLevel, "%d nodes", 3)
+	l.Warn("above")
+	if got, want := text.String(), "notice maintenance at 02:00\nnotice 3 nodes\nWARN above\n"; got != want {
+		t.Errorf("text: got %q, want %q", got, want)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(strings.SplitN(js.String(), "\n", 2)[0]), &entry); err != nil || entry["level"] != "notice" {
+		t.Errorf("json: %q %v", js.String(), err)
+	}
+	if n := l.Counts()[noticeLevel]; n != 2 {
+		t.Errorf("Counts: %d", n)
+	}
+
+	// 标签与内置等级对齐，带颜色时使用注册的配色，并且能被 ParseLine 解析回来
+	var colored bytes.Buffer
+	c := New(TraceLevel, OOutput(&colored), OFlag(Llevel|LlevelLabelColor|Lmsgcolor))
+	c.Log(noticeLevel, "hi")
+	if want := "\x1b[0;30;44m notice \x1b[0m \x1b[1;34;40m hi \x1b[0m\n"; colored.String() != want {
+		t.Errorf("colored: got %q, want %q", colored.String(), want)
+	}
+	if e, err := ParseLine(colored.Bytes(), Llevel|LlevelLabelColor|Lmsgcolor); err != nil || e.Level != noticeLevel || e.Msg != "hi" {
+		t.Errorf("ParseLine: %+v %v", e, err)
+	}
+}
+
+func TestRegisterLevelErrors(t *testing.T) {
+	for _, tc := range []struct {
+		value logLevel
+		label string
+	}{
+		{Discard, "NONE"},           // 不高于 Discard
+		{FatalLevel, "CRIT"},        // 不低于 Fatal
+		{AuditLevel + 5, "AFTER"},   // 超出等级表
+		{WarnLevel, "WARNING"},      // 内置等级的数值
+		{noticeLevel, "NOTICE2"},    // 已注册的数值
+		{InfoLevel + 6, "Notice"},   // 标签不区分大小写地重复
+		{InfoLevel + 6, "info"},     // 与内置等级的标签重复
+		{InfoLevel + 6, "TWO WORD"}, // 含有空格
+		{InfoLevel + 6, " "},
+	} {
+		if err := RegisterLevel(tc.value, tc.label, LevelStyle{}); err == nil || !strings.HasPrefix(err.Error(), "elog: ") {
+			t.Errorf("RegisterLevel(%d, %q): want error, got %v", tc.value, tc.label, err)
+		}
+	}
+	if levelOf(InfoLevel+6).levelLabel != "" {
+		t.Error("a failed registration should not change the level table")
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !elog_notrace
+
+package elog
+
+// traceCompiled 为 false 时 Trace 级别的调用在编译期被消除，见 level_trace_off.go
+const traceCompiled = true
+
+// Trace 以 TraceLevel 输出日志，参数的拼接方式见 OSprint
+func (l *Log) Trace(v ...any) {
+	if l.enabled(TraceLevel) {
+		l.Out(defaultCallDepth, TraceLevel, l.sprint(v))
+	}
+}
+
+func (l *Log) Tracef(format string, v ...any) {
+	if l.enabled(TraceLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, TraceLevel, msg, fs)
+	}
+}
+
+func Trace(v ...any) {
+	if l := Default(); l.enabled(TraceLevel) {
+		l.Out(defaultCallDepth, TraceLevel, l.sprint(v))
+	}
+}
+
+func Tracef(format string, v ...any) {
+	if l := Default(); l.enabled(TraceLevel) {
+		msg, fs := sprintf(format, v)
+		l.out(defaultCallDepth, TraceLevel, msg, fs)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build elog_notrace
+
+package elog
+
+// 使用 elog_notrace 构建标签编译时，Trace 和 Tracef 是空函数，编译器会将调用连同等级比较、
+// 参数装箱一起内联消除，适合对延迟极其敏感的程序：
+//
+//	go build -tags elog_notrace
+const traceCompiled = false
+
+func (l *Log) Trace(v ...any) {}
+
+func (l *Log) Tracef(format string, v ...any) {}
+
+func Trace(v ...any) {}
+
+func Tracef(format string, v ...any) {}
@@ -0,0 +1,85 @@
+package elog
+
+import "sync/atomic"
+
+type levelHook struct {
+	id uint64
+	fn func(old, new logLevel)
+}
+
+var levelHookID uint64
+
+// OnLevelChange 注册等级变化时的回调，返回的函数用于移除它。SetLevel、SetLevelRange 使最低等级发生变化后，
+// 在返回之前同步地按注册顺序调用 fn，适合根据等级缓存了 verbose 之类开关的组件及时更新：
+//
+//	remove := l.OnLevelChange(func(old, new logLevel) {
+//		verbose.Store(new <= elog.DebugLevel)
+//	})
+//	defer remove()
+//
+// 只修改最高等级、或者设置为相同的等级时不调用。回调在锁外调用，可以使用同一个 logger；
+// 回调中再次修改等级时不会嵌套调用回调，而是在这一轮回调结束后以新的等级再调用一轮，
+// 其它 goroutine 在回调期间修改等级时同样如此，因此每个回调看到的等级变化是连续的。
+// 回调 panic 时以 *CallbackPanicError 交给错误处理函数，不影响其余的回调。
+//
+// 回调只属于注册它的 logger。Extend 派生的子 logger 创建时复制父 logger 的等级，之后不随父 logger 变化，
+// 也不会继承这些回调；需要一起调整等级的子 logger 应各自注册。
+func (l *Log) OnLevelChange(fn func(old, new logLevel)) (remove func()) {
+	id := atomic.AddUint64(&levelHookID, 1)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// 总是分配新的切片，正在通知的调用方拿到的旧切片不会被修改
+	hooks := make([]levelHook, 0, len(l.levelHooks)+1)
+	hooks = append(hooks, l.levelHooks...)
+	l.levelHooks = append(hooks, levelHook{id: id, fn: fn})
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		hooks := make([]levelHook, 0, len(l.levelHooks))
+		for _, h := range l.levelHooks {
+			if h.id != id {
+				hooks = append(hooks, h)
+			}
+		}
+		l.levelHooks = hooks
+	}
+}
+
+// setMinLevel 把最低等级设置为 level，最高等级不变，返回值与 setLevelRange 相同。调用方需持有锁。
+func (l *Log) setMinLevel(level logLevel) bool {
+	_, max := l.levelRange()
+	return l.setLevelRange(level, max)
+}
+
+// setLevelRange 把日志等级范围设置为 min 到 max，最低等级变化且需要通知回调时返回 true，
+// 此时调用方应在释放锁之后调用 notifyLevel。调用方需持有锁。
+func (l *Log) setLevelRange(min, max logLevel) bool {
+	old, _ := l.levelRange()
+	l.storeLevelRange(min, max)
+	if old == min || len(l.levelHooks) == 0 || l.notifying {
+		return false // 正在通知时由进行中的 notifyLevel 发现这次变化
+	}
+	l.notifying, l.notified = true, old
+	return true
+}
+
+// notifyLevel 调用等级变化的回调，直到回调期间没有新的变化。不能持有锁调用。
+func (l *Log) notifyLevel() {
+	for {
+		l.mu.Lock()
+		cur, _ := l.levelRange()
+		old, hooks := l.notified, l.levelHooks
+		if old == cur {
+			l.notifying = false
+			l.mu.Unlock()
+			return
+		}
+		l.notified = cur
+		l.mu.Unlock()
+		for _, h := range hooks {
+			if err := callLevelHook(h.fn, old, cur); err != nil {
+				l.callbackFailed(err)
+			}
+		}
+	}
+}
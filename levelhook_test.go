@@ -0,0 +1,97 @@
+package elog
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOnLevelChange(t *testing.T) {
+	skipCompiledOut(t, DebugLevel)
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}))
+	var got []string
+	remove := l.OnLevelChange(func(old, new logLevel) {
+		got = append(got, fmt.Sprint(old, "->", new))
+		// 回调中可以使用同一个 logger
+		if !l.Enabled(new) {
+			t.Errorf("the new level should be in effect in the callback")
+		}
+	})
+	l.SetLevel(DebugLevel)
+	l.SetLevel(DebugLevel)                  // 没有变化
+	l.SetLevelRange(DebugLevel, ErrorLevel) // 只修改最高等级
+	l.SetLevelRange(WarnLevel, FatalLevel)
+	remove()
+	l.SetLevel(InfoLevel)
+	want := []string{fmt.Sprint(InfoLevel, "->", DebugLevel), fmt.Sprint(DebugLevel, "->", WarnLevel)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// Extend 派生的子 logger 不继承回调
+	calls := 0
+	l.OnLevelChange(func(old, new logLevel) { calls++ })
+	l.Extend().SetLevel(TraceLevel)
+	if calls != 0 {
+		t.Errorf("children should not inherit callbacks, got %d calls", calls)
+	}
+}
+
+func TestOnLevelChangeReentrant(t *testing.T) {
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}))
+	var got []string
+	l.OnLevelChange(func(old, new logLevel) {
+		got = append(got, fmt.Sprint(old, "->", new))
+		if new == TraceLevel {
+			l.SetLevel(DebugLevel) // 不嵌套调用，这一轮结束后再通知一轮
+			if len(got) != 1 {
+				t.Error("callbacks should not be called re-entrantly")
+			}
+		}
+	})
+	l.OnLevelChange(func(old, new logLevel) { panic("boom") })
+	b := captureInternal(t)
+	l.SetLevel(TraceLevel)
+	want := []string{fmt.Sprint(InfoLevel, "->", TraceLevel), fmt.Sprint(TraceLevel, "->", DebugLevel)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if l.Level() != DebugLevel {
+		t.Errorf("want DebugLevel, got %v", l.Level())
+	}
+	if n := strings.Count(b.String(), "level change callback panicked: boom"); n != 2 {
+		t.Errorf("want the panicking callback reported twice, got %d:\n%s", n, b.String())
+	}
+}
+
+// TestOnLevelChangeConcurrent 并发修改等级时每个回调看到的变化是连续的，最后一次通知的是最终的等级，需配合 -race 运行
+func TestOnLevelChangeConcurrent(t *testing.T) {
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}))
+	var mu sync.Mutex
+	last := InfoLevel
+	l.OnLevelChange(func(old, new logLevel) {
+		mu.Lock()
+		defer mu.Unlock()
+		if old != last {
+			t.Errorf("notifications should be contiguous: last %v, got %v->%v", last, old, new)
+		}
+		last = new
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.SetLevel(logLevel((i + j) % int(FatalLevel+1)))
+			}
+		}(i)
+	}
+	wg.Wait()
+	if last != l.Level() {
+		t.Errorf("the last notification should report the final level %v, got %v", l.Level(), last)
+	}
+}
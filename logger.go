@@ -1,12 +1,25 @@
 package elog
 
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
 const defaultCallDepth = 2
 
 type logLevel int
 
-// Fatal > Panic > Error > Warn > Info > Debug > Trace > Discard
+// levelStep 是相邻的内置等级之间的间隔，间隔中的值留给 RegisterLevel 注册的自定义等级
+const levelStep = 10
+
+// Audit > Fatal > Panic > Error > Warn > Info > Debug > Trace > Discard
+//
+// 不兼容的变更：为了给 RegisterLevel 留出位置，内置等级的数值从 0、1、2……8 改为 0、10、20……80（如 InfoLevel 从 3 变为 30）。
+// 通过常量和 ParseLevel 使用等级的代码不受影响；把等级按数值保存在配置文件或数据库中、比较 LevelNumber 输出的数值
+// 或者用 logLevel(3) 这样的数值构造等级的代码需要改为新的数值，或者改用等级名称。
 const (
-	Discard    logLevel = iota
+	Discard    logLevel = iota * levelStep
 	TraceLevel          // 在线调试: 默认情况下，既不打印到终端也不输出到文件。此时，对程序运行效率几乎不产生影响。常用语 for 循环中调试
 	DebugLevel          // 终端查看、在线调试: 默认情况下会打印到终端输出，但是不会归档到日志文件。因此，一般用于开发者在程序当前启动窗口上，查看日志流水信息。
 	InfoLevel           // 报告程序进度和状态信息: 一般这种信息都是一过性的，不会大量反复输出。例如：连接商用库成功后，可以打印一条连库成功的信息，便于跟踪程序进展信息。
@@ -14,6 +27,7 @@ const (
 	ErrorLevel          // 状态错误: 该错误发生后程序仍然可以运行，但是极有可能运行在某种非正常的状态下，导致无法完成全部既定的功能。
 	PanicLevel          // 致命的错误: 表明程序遇到了致命的错误，可以不马上终止运行，依赖 recover()。
 	FatalLevel          // 致命的错误: 表明程序遇到了致命的错误，必须马上终止运行。
+	AuditLevel          // 审计日志: 合规要求必须记录的事件，不受等级过滤及各种抑制功能影响，只能通过 Audit/Auditf 输出。
 )
 
 const (
@@ -24,6 +38,7 @@ const (
 	_InfoLabel  = "INFO "
 	_DebugLabel = "DEBUG"
 	_TraceLabel = "TRACE"
+	_AuditLabel = "AUDIT"
 )
 
 const (
@@ -42,15 +57,21 @@ const (
 	Info_  = "\x1b[0;30;46m "
 	Debug_ = "\x1b[0;37;44m "
 	Trace_ = "\x1b[0;30;42m "
+	Audit_ = "\x1b[0;30;47m "
 
 	color_ = " \x1b[0m "
+	_reset = "\x1b[0m"
 )
 
-var levelMap = map[logLevel]struct {
+// levelInfo 是等级表中的一项，标签补齐到 5 个字符，如 "WARN "
+type levelInfo struct {
 	levelLabel      string
 	levelLabelColor string
 	levelColor      string
-}{
+}
+
+// levelMap 是以等级的数值为下标的等级表，标签为空的项是未定义的等级，内置等级之间的项由 RegisterLevel 填充
+var levelMap = [AuditLevel + 1]levelInfo{
 	FatalLevel: {_FatalLabel, Fatal_, _magenta},
 	PanicLevel: {_PanicLabel, Panic_, _magenta},
 	ErrorLevel: {_ErrorLabel, Error_, _red},
@@ -58,6 +79,34 @@ var levelMap = map[logLevel]struct {
 	InfoLevel:  {_InfoLabel, Info_, _cyan},
 	DebugLevel: {_DebugLabel, Debug_, _blue},
 	TraceLevel: {_TraceLabel, Trace_, _green},
+	AuditLevel: {_AuditLabel, Audit_, _while},
+}
+
+// levelOf 返回 level 在等级表中的项，未定义或超出范围的等级返回零值
+func levelOf(level logLevel) levelInfo {
+	if level < 0 || level > AuditLevel {
+		return levelInfo{}
+	}
+	return levelMap[level]
+}
+
+// String 返回等级的名称，如 "WARN"，包括 RegisterLevel 注册的自定义等级；未定义的等级返回 "Level(35)" 的形式
+func (level logLevel) String() string {
+	if label := strings.TrimSpace(levelOf(level).levelLabel); label != "" {
+		return label
+	}
+	return "Level(" + strconv.Itoa(int(level)) + ")"
+}
+
+// ParseLevel 按名称返回等级，不区分大小写，如 "warn"、"ERROR"，包括 RegisterLevel 注册的自定义等级，未知的名称返回错误
+func ParseLevel(name string) (logLevel, error) {
+	name = strings.TrimSpace(name)
+	for level, m := range levelMap {
+		if m.levelLabel != "" && strings.EqualFold(strings.TrimSpace(m.levelLabel), name) {
+			return logLevel(level), nil
+		}
+	}
+	return Discard, errors.New("elog: unknown level " + strconv.Quote(name))
 }
 
 // Content Order (date、time、level、prefix、filepath、msg)
@@ -65,13 +114,136 @@ type logOrder string
 
 const (
 	OrderDate   logOrder = "Date"
-	OrderTime   logOrder = "Time"
+	OrderTime   logOrder = "Time" // 设置了 Lmicroseconds 时包含微秒部分，微秒总是紧跟在秒之后，不能单独排序
 	OrderLevel  logOrder = "Level"
 	OrderPrefix logOrder = "Prefix"
 	OrderPath   logOrder = "Path"
 	OrderMsg    logOrder = "Message"
+	// OrderDateTime 等同于相邻的 OrderDate、OrderTime，设置后 Order 返回的是展开后的两项
+	OrderDateTime logOrder = "DateTime"
+)
+
+var orderList = []logOrder{OrderDate, OrderTime, OrderLevel, OrderPrefix, OrderPath, OrderMsg}
+
+// orderStep 是编译后的输出项，数值与 orderList 中的下标相同。format 按它逐项输出，每条日志不再比较字符串
+type orderStep uint8
+
+const (
+	stepDate orderStep = iota
+	stepTime
+	stepLevel
+	stepPrefix
+	stepPath
+	stepMsg
 )
 
+// compileOrder 把 normalizeOrder 规范化后的输出顺序编译为 orderStep，order 为空时返回 nil
+func compileOrder(order []logOrder) []orderStep {
+	if len(order) == 0 {
+		return nil
+	}
+	steps := make([]orderStep, 0, len(order))
+	for _, o := range order {
+		for i, k := range orderList {
+			if o == k {
+				steps = append(steps, orderStep(i))
+			}
+		}
+	}
+	return steps
+}
+
+// normalizeOrder 返回去掉了重复项和未知项的 order 副本，重复的项只保留第一次出现的位置，OrderDateTime 会被展开
+func normalizeOrder(orders []logOrder) []logOrder {
+	if hasOrder(orders, OrderDateTime) {
+		expanded := make([]logOrder, 0, len(orders)+1)
+		for _, o := range orders {
+			if o == OrderDateTime {
+				expanded = append(expanded, OrderDate, OrderTime)
+			} else {
+				expanded = append(expanded, o)
+			}
+		}
+		orders = expanded
+	}
+	normalized := make([]logOrder, 0, len(orderList))
+	for _, o := range orders {
+		known, seen := false, false
+		for _, k := range orderList {
+			known = known || o == k
+		}
+		for _, n := range normalized {
+			seen = seen || o == n
+		}
+		if known && !seen {
+			normalized = append(normalized, o)
+		}
+	}
+	return normalized
+}
+
+// ParseOrder 按名称返回输出项，不区分大小写，如 "date"、"Message"、"DateTime"，未知的名称返回错误，
+// 用于从配置文件中读取输出顺序
+func ParseOrder(name string) (logOrder, error) {
+	name = strings.TrimSpace(name)
+	for _, o := range append(orderList[:len(orderList):len(orderList)], OrderDateTime) {
+		if strings.EqualFold(string(o), name) {
+			return o, nil
+		}
+	}
+	return "", errors.New("elog: unknown order " + strconv.Quote(name))
+}
+
+// validateOrder 返回 orders 中未知的项的说明，如拼写错误的 logOrder("Datee")
+func validateOrder(orders []logOrder) error {
+	var unknown []string
+	for _, o := range orders {
+		if o != OrderDateTime && !hasOrder(orderList, o) {
+			unknown = append(unknown, strconv.Quote(string(o)))
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	return errors.New("elog: unknown order " + strings.Join(unknown, ", "))
+}
+
+func hasOrder(orders []logOrder, o logOrder) bool {
+	for _, x := range orders {
+		if x == o {
+			return true
+		}
+	}
+	return false
+}
+
+// orderFlags 是各输出项生效所需的 flag，设置了其中任意一个即可
+var orderFlags = []struct {
+	order logOrder
+	flag  int
+	names string
+}{
+	{OrderDate, Ldate, "Ldate"},
+	{OrderTime, Ltime | Lmicroseconds, "Ltime or Lmicroseconds"},
+	{OrderLevel, Llevel, "Llevel"},
+	{OrderPrefix, Lmsgprefix, "Lmsgprefix"},
+	{OrderPath, Lshortfile | Llongfile, "Lshortfile or Llongfile"},
+}
+
+// checkOrder 返回 orders 中因为 flag 没有设置而不会输出任何内容的项的说明
+func checkOrder(orders []logOrder, flag int) error {
+	var missing []string
+	for _, of := range orderFlags {
+		if hasOrder(orders, of.order) && flag&of.flag == 0 {
+			missing = append(missing, string(of.order)+" requires "+of.names)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return errors.New("elog: order has no effect: " + strings.Join(missing, ", "))
+}
+
 // Flag set include setting of date, time, path, prefix, level, msg
 const (
 	Ldate = 1 << iota
@@ -84,9 +256,40 @@ const (
 	Lmsgcolor
 	Llevel
 	LlevelLabelColor
+	LnameColor // 按 logger 名称（没有名称时按前缀）从调色板中固定地选取一种颜色，用于消息前缀
+	Ldateiso   // 日期使用 ISO 8601 格式 2006-01-02，与 Ltime 同时设置时输出 2006-01-02T15:04:05+08:00
+	Lweekday   // 在日期之前输出星期的英文缩写，如 Wed，只在设置了 Ldate 时生效
+	// 时间总是占用相同的宽度，不同 flag 的 logger 的输出合并后仍然按列对齐：没有设置 Lmicroseconds 时在微秒的位置填充空格，
+	// OTimeLayout 的输出用空格填充到该格式的最大宽度
+	Lfixedtime
+	// 整行（头部和消息）使用等级的颜色，颜色在第一项之前开始、在行结束符之前结束。
+	// 与 Lmsgcolor、LlevelLabelColor、LnameColor 互斥，同时设置时以 Llinecolor 为准，这些 flag 不生效
+	Llinecolor
+	// 等级标签只输出一个大写字母，如 W、E，不补齐空格；自定义等级取标签的首字母。用于很窄的终端，见 OCompact
+	Lshortlevel
+	// 与标准库 log.LstdFlags（Ldate|Ltime）不同，还包含文件路径和等级标签。
+	// elog 的 flag 与标准库 log 的 flag 数值部分重合但含义不同，不能直接传入 log 包的常量，见 FromStdFlags
 	LstdFlags = Ldate | Ltime | Lshortfile | Llevel
+	// LstdlibFlags 与标准库的 log.LstdFlags 输出相同的日期和时间，从标准库迁移、下游按固定格式解析日志时使用
+	LstdlibFlags = Ldate | Ltime
+	// LstdFlagsProd 适合写入文件、交给日志采集系统：UTC 的微秒时间戳，不带颜色
+	LstdFlagsProd = Ldate | Ltime | Lmicroseconds | LUTC | Lshortfile | Llevel
+	// LstdFlagsDev 适合开发时在终端查看：只有时间，等级标签和消息带颜色
+	LstdFlagsDev = Ltime | Lshortfile | Llevel | LlevelLabelColor | Lmsgcolor
 )
 
+// NormalizeFlags 返回规范化后的 flag：Lmicroseconds 隐含 Ltime，同时设置 Lshortfile 和 Llongfile 时以 Lshortfile 为准。
+// OFlag、SetFlag、AddFlag、SubFlag 都会自动规范化，SubFlag 去掉 Ltime 时会一并去掉 Lmicroseconds。
+func NormalizeFlags(flag int) int {
+	if flag&Lmicroseconds != 0 {
+		flag |= Ltime
+	}
+	if flag&Lshortfile != 0 {
+		flag &^= Llongfile
+	}
+	return flag
+}
+
 type Logger interface {
 	Fatal(...any)
 	Panic(...any)
@@ -104,3 +307,30 @@ type Logger interface {
 	Debugf(string, ...any)
 	Tracef(string, ...any)
 }
+
+// LoggerE 在 Logger 的基础上增加了返回写入错误的方法
+type LoggerE interface {
+	Logger
+	LogE(logLevel, ...any) error
+	LogfE(logLevel, string, ...any) error
+}
+
+// LevelEnabler 由能够报告某个等级是否会被输出的 Logger 实现，*Log 以及 Ctx、BeginScope、Multi 返回的 Logger 都实现了它。
+// 接受 Logger 的库可以在构造代价高的参数之前先用 Enabled 检查，未实现 LevelEnabler 的 Logger 视为所有等级都会输出：
+//
+//	func (c *Client) dump(lg elog.Logger, resp *Response) {
+//		if elog.Enabled(lg, elog.DebugLevel) {
+//			lg.Debug(resp.Dump()) // 只在 Debug 开启时才构造
+//		}
+//	}
+type LevelEnabler interface {
+	Enabled(level logLevel) bool
+}
+
+// Enabled 报告 lg 是否会输出 level 等级的日志，lg 没有实现 LevelEnabler 时返回 true
+func Enabled(lg Logger, level logLevel) bool {
+	if e, ok := lg.(LevelEnabler); ok {
+		return e.Enabled(level)
+	}
+	return true
+}
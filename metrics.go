@@ -0,0 +1,79 @@
+package elog
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// metricLevels 是 MetricsHandler 输出的等级，没有写入过的等级也会以 0 输出，便于在 Prometheus 中计算比例
+var metricLevels = [...]logLevel{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, PanicLevel, FatalLevel, AuditLevel}
+
+// MetricsHandler 返回以 Prometheus 文本格式输出 logger 计数的 http.Handler，挂到自己的 mux 上即可被抓取：
+//
+//	http.Handle("/metrics/elog", l.MetricsHandler())
+//
+// 输出的指标如下，各等级的数值与 Counts、BytesWritten 一致：
+//
+//	elog_entries_total{level="error"} 42   已写入的日志条数
+//	elog_bytes_total{level="error"} 2048   已写入的字节数
+//	elog_write_errors_total 0              写入失败的日志条数，见 WriteErrors
+//	elog_suppressed_total 3                被过滤器和采样抑制的日志条数，见 Suppressed
+//	elog_dropped_total 0                   因 Reporter 或 AsyncWriter 的队列已满而被丢弃的日志条数
+//
+// 不依赖 Prometheus 的客户端库。计数通过原子操作读取，可以在输出日志的同时被并发抓取；
+// ResetCounts 会让计数归零，Prometheus 会把它当作计数器重置处理。
+func (l *Log) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(l.appendMetrics(nil))
+	})
+}
+
+func (l *Log) appendMetrics(buf []byte) []byte {
+	s := l.Stats()
+	dropped := s.ReportDropped
+	for _, a := range s.Async {
+		dropped += a.Dropped
+	}
+	buf = appendMetricHeader(buf, "elog_entries_total", "Number of log entries written.")
+	buf = appendLevelMetric(buf, "elog_entries_total", s.Counts)
+	buf = appendMetricHeader(buf, "elog_bytes_total", "Number of formatted bytes written.")
+	buf = appendLevelMetric(buf, "elog_bytes_total", s.Bytes)
+	buf = appendMetricHeader(buf, "elog_write_errors_total", "Number of log entries that failed to write.")
+	buf = appendMetric(buf, "elog_write_errors_total", "", s.WriteErrors)
+	buf = appendMetricHeader(buf, "elog_suppressed_total", "Number of log entries suppressed by filters and sampling.")
+	buf = appendMetric(buf, "elog_suppressed_total", "", s.Suppressed)
+	buf = appendMetricHeader(buf, "elog_dropped_total", "Number of log entries dropped because a queue was full.")
+	buf = appendMetric(buf, "elog_dropped_total", "", dropped)
+	return buf
+}
+
+func appendMetricHeader(buf []byte, name, help string) []byte {
+	buf = append(buf, "# HELP "...)
+	buf = append(buf, name...)
+	buf = append(buf, ' ')
+	buf = append(buf, help...)
+	buf = append(buf, "\n# TYPE "...)
+	buf = append(buf, name...)
+	return append(buf, " counter\n"...)
+}
+
+func appendLevelMetric(buf []byte, name string, counters map[logLevel]uint64) []byte {
+	for _, level := range metricLevels {
+		buf = appendMetric(buf, name, levelName(level), counters[level])
+	}
+	return buf
+}
+
+// appendMetric 追加一行指标，level 不为空时带上 level 标签
+func appendMetric(buf []byte, name, level string, v uint64) []byte {
+	buf = append(buf, name...)
+	if level != "" {
+		buf = append(buf, `{level="`...)
+		buf = append(buf, level...) // 等级名称只含小写字母，不需要转义
+		buf = append(buf, `"}`...)
+	}
+	buf = append(buf, ' ')
+	buf = strconv.AppendUint(buf, v, 10)
+	return append(buf, '\n')
+}
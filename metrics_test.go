@@ -0,0 +1,75 @@
+package elog
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(Llevel))
+	l.Info("a")
+	l.Info("b")
+	l.Error("c")
+	l.AddFilter(func(level logLevel, msg string) bool { return msg != "noise\n" })
+	l.Warn("noise")
+
+	rec := httptest.NewRecorder()
+	l.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE elog_entries_total counter\n",
+		`elog_entries_total{level="info"} 2` + "\n",
+		`elog_entries_total{level="error"} 1` + "\n",
+		`elog_entries_total{level="trace"} 0` + "\n",
+		`elog_bytes_total{level="error"} ` + strconv.Itoa(len("ERROR c\n")) + "\n",
+		"elog_write_errors_total 0\n",
+		"elog_suppressed_total 1\n",
+		"elog_dropped_total 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics should contain %q, got:\n%s", want, body)
+		}
+	}
+
+	l.SetOutput(failWriter{errors.New("disk full")})
+	l.Info("lost")
+	if l.WriteErrors() != 1 || !strings.Contains(string(l.appendMetrics(nil)), "elog_write_errors_total 1\n") {
+		t.Errorf("write errors should be counted, got %d", l.WriteErrors())
+	}
+}
+
+func TestMetricsHandlerConcurrent(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	h := l.MetricsHandler()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Info("x")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			}
+		}()
+	}
+	wg.Wait()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	want := `elog_entries_total{level="info"} ` + strconv.FormatUint(l.Counts()[InfoLevel], 10) + "\n"
+	if l.Counts()[InfoLevel] != 400 || !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("metrics should match Counts, want %q in:\n%s", want, rec.Body.String())
+	}
+}
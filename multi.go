@@ -0,0 +1,162 @@
+package elog
+
+// multiLogger 将每次调用分发给多个 *Log，每个 *Log 按自身的等级过滤
+type multiLogger []*Log
+
+var (
+	_ Logger       = multiLogger{}
+	_ LevelEnabler = multiLogger{}
+)
+
+// Multi 返回一个把每次调用分发给 loggers 中所有 logger 的 Logger，各 logger 保留自己的等级过滤，
+// 文件路径均指向调用处，参数按各 logger 自己的设置拼接。Fatal 会先写入所有 logger 再退出一次，退出码见 OExitCodes，
+// Panic 同理只 panic 一次；与 *Log 一样，所有 logger 的最低等级都高于 FatalLevel（PanicLevel）时不退出（不 panic）。
+func Multi(loggers ...*Log) Logger {
+	m := make(multiLogger, 0, len(loggers))
+	for _, l := range loggers {
+		if l != nil {
+			m = append(m, l)
+		}
+	}
+	return m
+}
+
+// enabled 报告是否有任意一个 logger 会输出 level 等级的日志，带格式的方法在格式化消息之前判断
+func (m multiLogger) enabled(level logLevel) bool {
+	for _, l := range m {
+		if l.enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// out 必须被 Logger 接口的方法直接调用，以保证文件路径指向调用处
+func (m multiLogger) out(level logLevel, msg string, extra ...Field) {
+	for _, l := range m {
+		if l.enabled(level) {
+			l.out(defaultCallDepth+1, level, msg, extra)
+		}
+	}
+}
+
+// print 与 out 相同，各 logger 按自己的设置（见 OSprint）拼接参数
+func (m multiLogger) print(level logLevel, v []any) {
+	for _, l := range m {
+		if l.enabled(level) {
+			l.out(defaultCallDepth+1, level, l.sprint(v), nil)
+		}
+	}
+}
+
+// Enabled 报告是否有任意一个 logger 会输出 level 等级的日志
+func (m multiLogger) Enabled(level logLevel) bool {
+	for _, l := range m {
+		if l.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// first 返回第一个最低等级不高于 level 的 logger，没有时返回 nil。
+// 与 *Log 的 Fatal、Panic 一样，只要有一个 logger 会退出进程或 panic，Multi 就退出进程或 panic
+func (m multiLogger) first(level logLevel) *Log {
+	for _, l := range m {
+		if l.atLeast(level) {
+			return l
+		}
+	}
+	return nil
+}
+
+// exit 在 Fatal 系列方法输出之后调用，以 first 返回的 logger 按参数决定的退出码（见 OExitCodes）退出进程
+func (m multiLogger) exit(v []any) {
+	f := m.first(FatalLevel)
+	if f == nil {
+		return
+	}
+	for _, l := range m {
+		l.beforeExit()
+	}
+	osExit(f.exitCode(v))
+}
+
+func (m multiLogger) beforePanic() {
+	for _, l := range m {
+		l.beforePanic()
+	}
+}
+
+// panicValue 只能被 Panic 和 Panicf 直接调用，任意一个 logger 开启了 OPanicError 时抛出 *PanicError
+func (m multiLogger) panicValue(msg string, v []any) any {
+	for _, l := range m {
+		if l.panicErr {
+			return l.panicValue(defaultCallDepth+1, msg, v)
+		}
+	}
+	return msg
+}
+
+func (m multiLogger) Fatal(v ...any) {
+	m.print(FatalLevel, v)
+	m.exit(v)
+}
+func (m multiLogger) Panic(v ...any) {
+	if p := m.first(PanicLevel); p != nil {
+		m.print(PanicLevel, v)
+		m.beforePanic()
+		panic(m.panicValue(p.sprint(v), v))
+	}
+}
+func (m multiLogger) Error(v ...any) { m.print(ErrorLevel, v) }
+func (m multiLogger) Warn(v ...any)  { m.print(WarnLevel, v) }
+func (m multiLogger) Info(v ...any)  { m.print(InfoLevel, v) }
+func (m multiLogger) Debug(v ...any) { m.print(DebugLevel, v) }
+func (m multiLogger) Trace(v ...any) { m.print(TraceLevel, v) }
+
+func (m multiLogger) Fatalf(format string, v ...any) {
+	if m.enabled(FatalLevel) {
+		msg, fs := sprintf(format, v)
+		m.out(FatalLevel, msg, fs...)
+	}
+	m.exit(v)
+}
+func (m multiLogger) Panicf(format string, v ...any) {
+	if m.first(PanicLevel) != nil {
+		s, fs := sprintf(format, v)
+		m.out(PanicLevel, s, fs...)
+		m.beforePanic()
+		panic(m.panicValue(s, v))
+	}
+}
+func (m multiLogger) Errorf(format string, v ...any) {
+	if m.enabled(ErrorLevel) {
+		msg, fs := sprintf(format, v)
+		m.out(ErrorLevel, msg, fs...)
+	}
+}
+func (m multiLogger) Warnf(format string, v ...any) {
+	if m.enabled(WarnLevel) {
+		msg, fs := sprintf(format, v)
+		m.out(WarnLevel, msg, fs...)
+	}
+}
+func (m multiLogger) Infof(format string, v ...any) {
+	if m.enabled(InfoLevel) {
+		msg, fs := sprintf(format, v)
+		m.out(InfoLevel, msg, fs...)
+	}
+}
+func (m multiLogger) Debugf(format string, v ...any) {
+	if m.enabled(DebugLevel) {
+		msg, fs := sprintf(format, v)
+		m.out(DebugLevel, msg, fs...)
+	}
+}
+func (m multiLogger) Tracef(format string, v ...any) {
+	if m.enabled(TraceLevel) {
+		msg, fs := sprintf(format, v)
+		m.out(TraceLevel, msg, fs...)
+	}
+}
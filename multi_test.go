@@ -0,0 +1,105 @@
+package elog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMulti(t *testing.T) {
+	var audit, ops bytes.Buffer
+	auditLog := New(TraceLevel, OOutput(&audit), OFlag(Lshortfile))
+	opsLog := New(WarnLevel, OOutput(&ops), OFlag(Lshortfile|Llevel))
+	m := Multi(auditLog, nil, opsLog)
+
+	m.Info("user", 42, "logged in")
+	m.Errorf("payment %d failed", 7)
+
+	if got, want := audit.String(), "multi_test.go:14 user 42 logged in\nmulti_test.go:15 payment 7 failed\n"; got != want {
+		t.Errorf("audit logger:\n got:  %q\n want: %q", got, want)
+	}
+	if got, want := ops.String(), "ERROR multi_test.go:15 payment 7 failed\n"; got != want {
+		t.Errorf("ops logger:\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestMultiFatalExitsOnce(t *testing.T) {
+	var b1, b2 bytes.Buffer
+	exits := 0
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	osExit = func(code int) { exits++ }
+
+	m := Multi(New(InfoLevel, OOutput(&b1)), New(InfoLevel, OOutput(&b2)))
+	m.Fatal("bye")
+	if exits != 1 {
+		t.Errorf("expected to exit once, exited %d times", exits)
+	}
+	if b1.String() != "bye\n" || b2.String() != "bye\n" {
+		t.Errorf("every logger should receive the entry before exiting, got %q and %q", b1.String(), b2.String())
+	}
+}
+
+func TestMultiPanicOnce(t *testing.T) {
+	var b1, b2 bytes.Buffer
+	m := Multi(New(InfoLevel, OOutput(&b1)), New(InfoLevel, OOutput(&b2)))
+	defer func() {
+		if v := recover(); v != "oops 1" {
+			t.Errorf("expected panic value %q, got %v", "oops 1", v)
+		}
+		if b1.String() != "oops 1\n" || b2.String() != "oops 1\n" {
+			t.Errorf("every logger should receive the entry before panicking, got %q and %q", b1.String(), b2.String())
+		}
+	}()
+	m.Panicf("oops %d", 1)
+}
+
+// 各 logger 按自己的 OSprint 拼接参数，退出码与 *Log 的 Fatal 一样由 OExitCodes 决定
+func TestMultiChildSettings(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	code := -1
+	osExit = func(c int) { code = c }
+
+	var b1, b2 bytes.Buffer
+	m := Multi(New(InfoLevel, OOutput(&b1), OSprint()), New(InfoLevel, OOutput(&b2), OExitCodes()))
+	m.Info("a", "b", 1)
+	m.Fatal("bye", codeError{4})
+	if got, want := b1.String(), "ab1\nbyeexit 4\n"; got != want {
+		t.Errorf("OSprint logger:\n got:  %q\n want: %q", got, want)
+	}
+	if got, want := b2.String(), "a b 1\nbye exit 4\n"; got != want {
+		t.Errorf("default logger:\n got:  %q\n want: %q", got, want)
+	}
+	if code != 1 {
+		t.Errorf("the first logger decides the exit code, got %d", code)
+	}
+	code = -1
+	Multi(New(InfoLevel, OOutput(&b2), OExitCodes())).Fatalf("bye: %w", codeError{4})
+	if code != 4 {
+		t.Errorf("expected exit code 4, got %d", code)
+	}
+
+	// 所有 logger 的最低等级都高于 FatalLevel、PanicLevel 时与 *Log 一样不退出、不 panic
+	code = -1
+	quiet := Multi(New(AuditLevel, OOutput(&b2)))
+	quiet.Fatal("bye")
+	if v := recovered(func() { quiet.Panic("boom") }); v != nil || code != -1 {
+		t.Errorf("should neither exit nor panic, got code %d and panic %v", code, v)
+	}
+}
+
+// 没有任何 logger 会输出时，带格式的方法不格式化参数
+func TestMultiSkipsFormatting(t *testing.T) {
+	var a, b bytes.Buffer
+	m := Multi(New(WarnLevel, OOutput(&a)), New(ErrorLevel, OOutput(&b)))
+	calls := 0
+	arg := countingStringer{&calls}
+	m.Infof("%v", arg)
+	m.Debugf("%v", arg)
+	m.Tracef("%v", arg)
+	if calls != 0 || a.Len() != 0 || b.Len() != 0 {
+		t.Errorf("disabled levels should not be formatted, %d calls, output %q %q", calls, a.String(), b.String())
+	}
+	m.Warnf("%v", arg)
+	if calls != 1 || a.String() != "expensive\n" || b.Len() != 0 {
+		t.Errorf("enabled levels should be formatted once, %d calls, output %q %q", calls, a.String(), b.String())
+	}
+}
@@ -0,0 +1,137 @@
+package elog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// maxErrorDepth 是展开嵌套的合并错误的最大层数，更深的合并错误以 "; " 连接为一行
+const maxErrorDepth = 4
+
+// multiError 由合并了多个错误的错误实现，如 errors.Join 的结果
+type multiError interface {
+	Unwrap() []error
+}
+
+// splitMulti 沿 errors.Unwrap 查找 err 的错误链中第一个合并错误，返回它之前各层添加的内容以及合并前的各个错误。
+// 外层的错误信息不以合并错误的信息结尾（自定义了 Error 方法）时无法区分各层添加的内容，ok 为 false。
+func splitMulti(err error) (prefix string, errs []error, ok bool) {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		m, is := e.(multiError)
+		if !is {
+			continue
+		}
+		msg, inner := err.Error(), e.Error()
+		if !strings.HasSuffix(msg, inner) {
+			return "", nil, false
+		}
+		for _, sub := range m.Unwrap() {
+			if sub != nil {
+				errs = append(errs, sub)
+			}
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(msg, inner), ": "), errs, len(errs) > 0
+	}
+	return "", nil, false
+}
+
+// appendError 追加 err 的错误信息。错误链中有合并错误时，合并前的每个错误单独一行，以 "- " 开头并按层级缩进 4 个空格，
+// 嵌套的合并错误递归展开，超过 maxErrorDepth 层的合并错误以 "; " 连接为一行：
+//
+//	load config: 2 errors:
+//	    - open a.yaml: permission denied
+//	    - parse b.yaml: 2 errors:
+//	        - line 3: unknown key "port"
+//	        - line 9: invalid duration "5"
+func appendError(buf []byte, err error, depth int) []byte {
+	prefix, errs, ok := splitMulti(err)
+	if !ok {
+		return append(buf, err.Error()...)
+	}
+	if depth >= maxErrorDepth {
+		return append(buf, flatError(err)...)
+	}
+	if prefix != "" {
+		buf = append(buf, prefix...)
+		buf = append(buf, ": "...)
+	}
+	buf = strconv.AppendInt(buf, int64(len(errs)), 10)
+	if len(errs) == 1 {
+		buf = append(buf, " error:"...)
+	} else {
+		buf = append(buf, " errors:"...)
+	}
+	for _, e := range errs {
+		buf = append(buf, '\n')
+		for i := 0; i <= depth; i++ {
+			buf = append(buf, "    "...)
+		}
+		buf = append(buf, "- "...)
+		buf = appendError(buf, e, depth+1)
+	}
+	return buf
+}
+
+// flatError 返回 err 的错误信息，合并错误的各个错误以 "; " 连接为一行
+func flatError(err error) string {
+	return strings.ReplaceAll(err.Error(), "\n", "; ")
+}
+
+// appendErrorsJSON 把合并前的各个错误编码为 JSON 数组，元素为各个错误的信息，
+// 嵌套的合并错误编码为 {"error": 错误信息, "errors": [...]}，超过 maxErrorDepth 层时只保留错误信息
+func appendErrorsJSON(buf []byte, errs []error, depth int) []byte {
+	buf = append(buf, '[')
+	for i, e := range errs {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		if _, sub, ok := splitMulti(e); ok && depth+1 < maxErrorDepth {
+			buf = append(buf, `{"error":`...)
+			buf = appendJSONString(buf, e.Error())
+			buf = append(buf, `,"errors":`...)
+			buf = appendErrorsJSON(buf, sub, depth+1)
+			buf = append(buf, '}')
+			continue
+		}
+		buf = appendJSONString(buf, e.Error())
+	}
+	return append(buf, ']')
+}
+
+// fieldErrors 返回错误字段中合并前的各个错误，f 不是错误字段或者错误链中没有合并错误时返回 nil
+func fieldErrors(f Field) []error {
+	err, _ := f.Value.(error)
+	if err == nil || f.Kind != KindError && f.Kind != KindAny {
+		return nil
+	}
+	_, errs, _ := splitMulti(err)
+	return errs
+}
+
+// renderedError 是按 appendError 展开后的错误，仍然作为 error 交给 fmt，使 fmt.Sprint 在参数之间添加空格的规则不变
+type renderedError struct{ s string }
+
+func (e renderedError) Error() string { return e.s }
+
+// renderErrors 把 v 中错误链含有合并错误的参数替换为展开后的错误，没有时直接返回 v
+func renderErrors(v []any) []any {
+	var out []any
+	for i, a := range v {
+		err, ok := a.(error)
+		if !ok || err == nil {
+			continue
+		}
+		if _, _, multi := splitMulti(err); !multi {
+			continue
+		}
+		if out == nil {
+			out = append([]any(nil), v...)
+		}
+		out[i] = renderedError{string(appendError(nil, err, 0))}
+	}
+	if out == nil {
+		return v
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// configErr 返回合并了一个普通错误和一个嵌套的合并错误、外层再包装一次的错误
+func configErr() error {
+	parse := fmt.Errorf("parse b.yaml: %w", joinErrors([]error{
+		errors.New("line 3: unknown key"),
+		errors.New("line 9: bad value"),
+	}))
+	return fmt.Errorf("load config: %w", joinErrors([]error{
+		fmt.Errorf("open a.yaml: %w", os.ErrPermission),
+		parse,
+	}))
+}
+
+const configErrText = "load config: 2 errors:\n" +
+	"    - open a.yaml: permission denied\n" +
+	"    - parse b.yaml: 2 errors:\n" +
+	"        - line 3: unknown key\n" +
+	"        - line 9: bad value"
+
+func TestMultiErrorText(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(InfoLevel, OOutput(&buf), OFlag(Llevel))
+	err := configErr()
+
+	l.Error(err)
+	if got, want := buf.String(), "ERROR "+configErrText+"\n"; got != want {
+		t.Errorf("Error:\n got:  %q\n want: %q", got, want)
+	}
+	buf.Reset()
+	_ = l.LogE(ErrorLevel, "reload failed:", err)
+	if got, want := buf.String(), "ERROR reload failed: "+configErrText+"\n"; got != want {
+		t.Errorf("LogE:\n got:  %q\n want: %q", got, want)
+	}
+	buf.Reset()
+	l.With().Error().Err(err).Msg("reload failed")
+	if got, want := buf.String(), "ERROR reload failed error="+configErrText+"\n"; got != want {
+		t.Errorf("Err field:\n got:  %q\n want: %q", got, want)
+	}
+
+	// 格式化方法与普通错误不受影响
+	buf.Reset()
+	l.Errorf("%v", joinErrors([]error{ioErr(1), ioErr(2)}))
+	l.Error(errors.New("plain"), ioErr(3))
+	if got, want := buf.String(), "ERROR io 1\nio 2\nERROR plain io 3\n"; got != want {
+		t.Errorf("unchanged:\n got:  %q\n want: %q", got, want)
+	}
+	if !errors.Is(err, os.ErrPermission) {
+		t.Error("rendering should not affect errors.Is")
+	}
+}
+
+func ioErr(n int) error { return fmt.Errorf("io %d", n) }
+
+// multi 与 errors.Join 的结果一样跳过 nil，用于构造只有一个错误的合并错误
+type multi []error
+
+func (m multi) Error() string {
+	var s []string
+	for _, err := range m {
+		if err != nil {
+			s = append(s, err.Error())
+		}
+	}
+	return strings.Join(s, "\n")
+}
+
+func (m multi) Unwrap() []error { return m }
+
+func TestMultiErrorDepth(t *testing.T) {
+	err := ioErr(0)
+	for i := 1; i <= maxErrorDepth+1; i++ {
+		err = joinErrors([]error{ioErr(i), err})
+	}
+	got := string(appendError(nil, err, 0))
+	lines := strings.Split(got, "\n")
+	if len(lines) != 2*maxErrorDepth+1 {
+		t.Fatalf("got %d lines:\n%s", len(lines), got)
+	}
+	// 最深一层以 "; " 连接为一行
+	last := lines[len(lines)-1]
+	if want := strings.Repeat("    ", maxErrorDepth) + "- io 1; io 0"; last != want {
+		t.Errorf("deepest line: got %q, want %q", last, want)
+	}
+	if single := string(appendError(nil, fmt.Errorf("ctx: %w", multi{ioErr(1), nil}), 0)); single != "ctx: 1 error:\n    - io 1" {
+		t.Errorf("single: got %q", single)
+	}
+}
+
+func TestMultiErrorJSON(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}))
+	l.AddOutput(&buf, WithEncoder(JSONEncoder))
+	l.With().Error().Err(configErr()).Msg("reload failed")
+	want := `"error":"load config: open a.yaml: permission denied\nparse b.yaml: line 3: unknown key\nline 9: bad value",` +
+		`"errors":["open a.yaml: permission denied",` +
+		`{"error":"parse b.yaml: line 3: unknown key\nline 9: bad value","errors":["line 3: unknown key","line 9: bad value"]}]}`
+	if got := buf.String(); !strings.HasSuffix(got, want+"\n") {
+		t.Errorf("got:  %s\nwant suffix: %s", got, want)
+	}
+}
+
+func TestMultiErrorStack(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(InfoLevel, OOutput(&buf), OFlag(Llevel))
+	l.ErrorStack(configErr(), "startup failed")
+	want := "ERROR startup failed: load config: open a.yaml: permission denied; parse b.yaml: line 3: unknown key; line 9: bad value\n" +
+		"    load config\n" +
+		"    - open a.yaml: permission denied\n" +
+		"    - parse b.yaml: 2 errors:\n" +
+		"        - line 3: unknown key\n" +
+		"        - line 9: bad value\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,187 @@
+package elog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDialTimeout   = 5 * time.Second
+	defaultRetryInterval = time.Second
+)
+
+// ErrNotConnected 在 NetWriter 与对端断开、且还没到下一次重连的时间时由 Write 返回，日志会被丢弃
+var ErrNotConnected = errors.New("elog: not connected")
+
+// Framing 决定 NetWriter 如何在流中划分每条日志
+type Framing int
+
+const (
+	FrameAuto         Framing = iota // 流式网络（tcp、unix）使用 FrameNewline，数据报网络（udp、unixgram）使用 FrameNone
+	FrameNewline                     // 每条日志以换行符结尾，没有时补上
+	FrameLengthPrefix                // 每条日志之前加上 4 字节大端序的长度，日志本身不含末尾的换行符
+	FrameNone                        // 原样写入
+)
+
+// NetWriter 把日志写到网络对端，如日志收集器，支持 tcp、udp 以及 unix、unixgram 域套接字。
+// 它实现了 io.WriteCloser，可以直接作为 logger 的输出。
+//
+// 写入是同步的：连接断开或写入失败时关闭连接并立即重连一次，重连失败则返回错误，之后的 RetryInterval 内
+// 直接返回 ErrNotConnected 而不再拨号，不会让每条日志都等待拨号超时；对端恢复（包括 unix 套接字文件被删除后重新创建）
+// 后自动恢复写入。流式连接在对端关闭后的第一次写入可能仍然成功，这条日志会丢失。
+// 不希望日志输出等待网络时可以用 NewAsyncWriter 包装。
+type NetWriter struct {
+	mu       sync.Mutex
+	network  string
+	addr     string
+	conn     net.Conn
+	frame    Framing
+	timeout  time.Duration
+	retry    time.Duration
+	nextDial time.Time
+	dialErr  error
+	buf      []byte
+	closed   bool
+}
+
+var _ io.WriteCloser = &NetWriter{}
+
+// NetOption 用于配置 NewNetWriter 创建的 NetWriter
+type NetOption func(w *NetWriter)
+
+// NetFraming 设置划分日志的方式，默认为 FrameAuto
+func NetFraming(f Framing) NetOption {
+	return func(w *NetWriter) {
+		w.frame = f
+	}
+}
+
+// NetDialTimeout 设置拨号的超时时间，默认为 5 秒
+func NetDialTimeout(d time.Duration) NetOption {
+	return func(w *NetWriter) {
+		w.timeout = d
+	}
+}
+
+// NetRetryInterval 设置重连失败后再次拨号前的最短间隔，默认为 1 秒
+func NetRetryInterval(d time.Duration) NetOption {
+	return func(w *NetWriter) {
+		w.retry = d
+	}
+}
+
+// NewNetWriter 返回一个写到 network 上 addr 的 NetWriter，network 为 tcp、tcp4、tcp6、udp、udp4、udp6、unix 或 unixgram。
+// 创建时立即拨号一次，失败时返回错误；对端暂时不可用也需要先创建时可以忽略这个错误，返回的 NetWriter 仍然可用，会在写入时重连。
+func NewNetWriter(network, addr string, options ...NetOption) (*NetWriter, error) {
+	w := &NetWriter{network: network, addr: addr, timeout: defaultDialTimeout, retry: defaultRetryInterval}
+	for _, opt := range options {
+		opt(w)
+	}
+	if w.frame == FrameAuto {
+		w.frame = FrameNewline
+		if isDatagram(network) {
+			w.frame = FrameNone
+		}
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("elog: unsupported network %q", network)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w, w.dial()
+}
+
+func isDatagram(network string) bool {
+	switch network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return true
+	}
+	return false
+}
+
+// dial 建立连接，失败时记录下一次可以拨号的时间。调用方需持有 w.mu。
+func (w *NetWriter) dial() error {
+	conn, err := net.DialTimeout(w.network, w.addr, w.timeout)
+	if err != nil {
+		w.dialErr = err
+		w.nextDial = time.Now().Add(w.retry)
+		return err
+	}
+	w.conn, w.dialErr = conn, nil
+	return nil
+}
+
+// Write 把 p 按划分方式写到对端，p 为一条完整的日志
+func (w *NetWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	w.buf = w.framed(w.buf[:0], p)
+	if w.conn == nil {
+		if time.Now().Before(w.nextDial) {
+			return 0, fmt.Errorf("%w: %v", ErrNotConnected, w.dialErr)
+		}
+		if err := w.dial(); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := w.conn.Write(w.buf); err == nil {
+		return len(p), nil
+	}
+	// 对端可能已经重启，关闭旧连接后立即重连一次
+	w.conn.Close()
+	w.conn = nil
+	if err := w.dial(); err != nil {
+		return 0, err
+	}
+	if _, err := w.conn.Write(w.buf); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *NetWriter) framed(buf, p []byte) []byte {
+	switch w.frame {
+	case FrameNewline:
+		buf = append(buf, p...)
+		if len(p) == 0 || p[len(p)-1] != '\n' {
+			buf = append(buf, '\n')
+		}
+	case FrameLengthPrefix:
+		if len(p) > 0 && p[len(p)-1] == '\n' {
+			p = p[:len(p)-1]
+		}
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(p)))
+		buf = append(buf, size[:]...)
+		buf = append(buf, p...)
+	default:
+		buf = append(buf, p...)
+	}
+	return buf
+}
+
+// Close 关闭连接，之后的写入返回 os.ErrClosed
+func (w *NetWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
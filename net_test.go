@@ -0,0 +1,190 @@
+package elog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lineServer 在 unix 套接字上接收按行划分的日志
+type lineServer struct {
+	ln    net.Listener
+	lines chan string
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func startLineServer(t *testing.T, path string) *lineServer {
+	t.Helper()
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets not supported: %v", err)
+	}
+	s := &lineServer{ln: ln, lines: make(chan string, 16)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go func() {
+				defer conn.Close()
+				sc := bufio.NewScanner(conn)
+				for sc.Scan() {
+					s.lines <- sc.Text()
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+// stop 模拟收集器退出：关闭所有连接并删除套接字文件
+func (s *lineServer) stop() {
+	s.ln.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+}
+
+func (s *lineServer) expect(t *testing.T, want string) {
+	t.Helper()
+	select {
+	case got := <-s.lines:
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for %q", want)
+	}
+}
+
+func TestNetWriterUnixReconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collector.sock")
+	srv := startLineServer(t, path)
+	w, err := NewNetWriter("unix", path, NetRetryInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(InfoLevel, OOutput(w))
+	defer l.Close()
+
+	l.Info("before restart")
+	srv.expect(t, "before restart")
+
+	srv.stop()
+	var failed error
+	l.SetErrorHandler(func(err error) { failed = err })
+	// 对端关闭后写入失败，重连也失败，之后的重试间隔内直接返回 ErrNotConnected
+	for i := 0; i < 3 && failed == nil; i++ {
+		l.Info("lost")
+	}
+	if failed == nil {
+		t.Fatal("writes should fail while the collector is down")
+	}
+	l.Info("lost")
+	if !errors.Is(failed, ErrNotConnected) {
+		t.Errorf("want ErrNotConnected within the retry interval, got %v", failed)
+	}
+
+	srv = startLineServer(t, path)
+	defer srv.stop()
+	time.Sleep(20 * time.Millisecond)
+	failed = nil
+	l.Info("after restart")
+	if failed != nil {
+		t.Fatalf("write should resume after the collector restarts, got %v", failed)
+	}
+	srv.expect(t, "after restart")
+}
+
+func TestNetWriterUnixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collector.sock")
+	ln, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram not supported: %v", err)
+	}
+	w, err := NewNetWriter("unixgram", path, NetRetryInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	read := func(ln *net.UnixConn) string {
+		buf := make([]byte, 1024)
+		ln.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := ln.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+	w.Write([]byte("one\n"))
+	if got := read(ln); got != "one\n" {
+		t.Errorf("datagrams are written as is, got %q", got)
+	}
+
+	// 重新创建套接字文件后数据报发往新的套接字
+	ln.Close()
+	os.Remove(path)
+	ln, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if _, err := w.Write([]byte("two\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(ln); got != "two\n" {
+		t.Errorf("want %q, got %q", "two\n", got)
+	}
+}
+
+func TestNetWriterLengthPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collector.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets not supported: %v", err)
+	}
+	defer ln.Close()
+	w, err := NewNetWriter("unix", path, NetFraming(FrameLengthPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	w.Write([]byte("multi\nline\n"))
+	w.Close()
+
+	var size uint32
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		t.Fatal(err)
+	}
+	msg, _ := io.ReadAll(conn)
+	if int(size) != len(msg) || string(msg) != "multi\nline" {
+		t.Errorf("unexpected frame %d %q", size, msg)
+	}
+	if _, err := w.Write([]byte("closed")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write after close should fail, got %v", err)
+	}
+}
+
+func TestNetWriterUnsupported(t *testing.T) {
+	if _, err := NewNetWriter("ip", "127.0.0.1"); err == nil {
+		t.Error("unsupported networks should be rejected")
+	}
+}
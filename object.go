@@ -0,0 +1,190 @@
+package elog
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// maxObjectDepth 是 ObjectFields 展开嵌套结构体的最大层数，更深的结构体作为一个字段整体输出
+const maxObjectDepth = 3
+
+// InfoObj 以 InfoLevel 输出消息 msg，并把 obj 的字段作为日志字段附加在后面，见 ObjectFields：
+//
+//	type Request struct {
+//		Method   string
+//		Path     string `elog:"path"`
+//		Password string `elog:"-"`
+//		Client   struct{ IP string }
+//	}
+//
+//	l.InfoObj("request", req) // INFO request Method=GET path=/login Client.IP=10.0.0.1
+func (l *Log) InfoObj(msg string, obj any) {
+	if l.enabled(InfoLevel) {
+		l.out(defaultCallDepth, InfoLevel, msg, ObjectFields(obj))
+	}
+}
+
+// ObjectFields 把结构体 obj（或指向结构体的指针）的导出字段转换为日志字段，可以与 EntryBuilder 一起使用：
+//
+//	l.With().Warn().Fields(elog.ObjectFields(resp)...).Msg("slow response")
+//
+// 规则与 encoding/json 相近：
+//
+//   - 字段的键为 elog 标签中的名称，没有标签时为字段名；标签为 "-" 的字段（如密码）不输出
+//   - 未导出的字段不输出，即使带有标签；未导出的嵌入结构体（不是指针）的导出字段仍然提升到外层
+//   - 嵌入的结构体的字段提升到外层，与外层的字段同级；嵌入字段带有标签名称时按普通的嵌套结构体处理
+//   - 嵌套的结构体展开为以 "." 连接的键，如 "Client.IP"，超过 3 层的结构体作为一个字段整体输出
+//   - 实现了 error、fmt.Stringer 或 encoding.TextMarshaler 的结构体（如 time.Time）不展开
+//   - 为 nil 的嵌套指针不输出它的字段
+//
+// 每个类型的反射结果只计算一次并缓存。obj 不是结构体时返回以 "value" 为键的一个字段，obj 为 nil 时返回 nil。
+func ObjectFields(obj any) []Field {
+	if obj == nil {
+		return nil
+	}
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || isLeafStruct(v.Type()) {
+		return []Field{Any("value", obj)}
+	}
+	plan := objectPlanOf(v.Type())
+	fields := make([]Field, 0, len(plan))
+	for i := range plan {
+		if f, ok := plan[i].field(v); ok {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// objectLeaf 是结构体中一个输出为字段的成员，path 是从外层结构体到它的字段下标，途中可能经过指针
+type objectLeaf struct {
+	key  string
+	path []int
+	conv func(key string, v reflect.Value) Field
+}
+
+// field 从结构体 v 中取出这个成员并转换为字段，途中经过为 nil 的指针时 ok 为 false
+func (o *objectLeaf) field(v reflect.Value) (f Field, ok bool) {
+	for _, i := range o.path {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return Field{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return o.conv(o.key, v), true
+}
+
+// objectPlans 缓存各个结构体类型的 []objectLeaf
+var objectPlans sync.Map
+
+func objectPlanOf(t reflect.Type) []objectLeaf {
+	if p, ok := objectPlans.Load(t); ok {
+		return p.([]objectLeaf)
+	}
+	p, _ := objectPlans.LoadOrStore(t, buildObjectPlan(t, "", nil, 0))
+	return p.([]objectLeaf)
+}
+
+// buildObjectPlan 列出结构体类型 t 中输出为字段的成员，prefix 为键的前缀，path 为 t 本身的字段下标，depth 为嵌套的层数
+func buildObjectPlan(t reflect.Type, prefix string, path []int, depth int) []objectLeaf {
+	var leaves []objectLeaf
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		// 与 encoding/json 一样，未导出的嵌入结构体（不是指针）的导出字段仍然提升到外层
+		if !sf.IsExported() && !(sf.Anonymous && sf.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		name, tagged := sf.Tag.Lookup("elog")
+		if name == "-" {
+			continue
+		}
+		if !tagged || name == "" {
+			name = sf.Name
+		}
+		fp := append(path[:len(path):len(path)], i)
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !isLeafStruct(ft) {
+			if sf.Anonymous && !tagged {
+				leaves = append(leaves, buildObjectPlan(ft, prefix, fp, depth)...)
+				continue
+			}
+			if depth+1 < maxObjectDepth {
+				leaves = append(leaves, buildObjectPlan(ft, prefix+name+".", fp, depth+1)...)
+				continue
+			}
+		}
+		leaves = append(leaves, objectLeaf{key: prefix + name, path: fp, conv: leafConverter(sf.Type)})
+	}
+	return leaves
+}
+
+var (
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	durationType      = reflect.TypeOf(time.Duration(0))
+	timeType          = reflect.TypeOf(time.Time{})
+)
+
+// isLeafStruct 报告结构体类型 t 是否有自己的文本形式，不需要展开
+func isLeafStruct(t reflect.Type) bool {
+	for _, it := range []reflect.Type{errorType, stringerType, textMarshalerType} {
+		if t.Implements(it) || reflect.PtrTo(t).Implements(it) {
+			return true
+		}
+	}
+	return false
+}
+
+// leafConverter 返回把 t 类型的值转换为字段的函数，常见的类型使用 String、Int64 等构造函数，避免 interface 装箱
+func leafConverter(t reflect.Type) func(key string, v reflect.Value) Field {
+	switch {
+	case t == durationType:
+		return func(key string, v reflect.Value) Field { return Dur(key, time.Duration(v.Int())) }
+	case t == timeType:
+		return func(key string, v reflect.Value) Field { return Time(key, v.Interface().(time.Time)) }
+	case t.Kind() == reflect.Ptr:
+		elem := leafConverter(t.Elem())
+		return func(key string, v reflect.Value) Field {
+			if v.IsNil() {
+				return Any(key, nil)
+			}
+			return elem(key, v.Elem())
+		}
+	case t.Implements(errorType) && t.Kind() == reflect.Interface:
+		return func(key string, v reflect.Value) Field {
+			if v.IsNil() {
+				return Field{Key: key, Kind: KindError}
+			}
+			return Field{Key: key, Kind: KindError, Value: v.Interface()}
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return func(key string, v reflect.Value) Field { return String(key, v.String()) }
+	case reflect.Bool:
+		return func(key string, v reflect.Value) Field { return Bool(key, v.Bool()) }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(key string, v reflect.Value) Field { return Int64(key, v.Int()) }
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return func(key string, v reflect.Value) Field { return Int64(key, int64(v.Uint())) }
+	case reflect.Float32, reflect.Float64:
+		return func(key string, v reflect.Value) Field { return Float64(key, v.Float()) }
+	}
+	return func(key string, v reflect.Value) Field { return Any(key, v.Interface()) }
+}
@@ -0,0 +1,138 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type objClient struct {
+	IP    string `elog:"ip"`
+	Agent string
+}
+
+type objAudit struct {
+	Trace string `elog:"trace_id"`
+}
+
+type objRequest struct {
+	objAudit        // 嵌入的字段提升到外层
+	Method   string `elog:"method"`
+	Password string `elog:"-"`
+	Token    string `elog:"token"` // 以下两个未导出的字段不输出
+	secret   string `elog:"secret"`
+	internal int
+	Client   objClient     `elog:"client"`
+	Proxy    *objClient    // 为 nil 时不输出
+	Meta     *objAudit     `elog:"meta"` // 带标签名称的嵌套指针
+	Elapsed  time.Duration `elog:"elapsed"`
+	At       time.Time     `elog:"at"`
+	Err      error         `elog:"err"`
+	Tags     []string      `elog:"tags"`
+	Count    *int          `elog:"count"`
+	Deep     objDeep       `elog:"deep"`
+}
+
+type objDeep struct {
+	L1 struct {
+		L2 struct {
+			L3 struct{ X int }
+		}
+	}
+}
+
+func newObjRequest() *objRequest {
+	r := &objRequest{
+		objAudit: objAudit{Trace: "t-1"},
+		Method:   "POST",
+		Password: "hunter2",
+		Token:    "abc",
+		secret:   "s",
+		internal: 1,
+		Client:   objClient{IP: "10.0.0.1", Agent: "curl"},
+		Meta:     &objAudit{Trace: "m-1"},
+		Elapsed:  1500 * time.Millisecond,
+		At:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Err:      errors.New("boom"),
+		Tags:     []string{"a", "b"},
+	}
+	r.Deep.L1.L2.L3.X = 7
+	return r
+}
+
+func objectKeys(fields []Field) string {
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.Key
+	}
+	return strings.Join(keys, " ")
+}
+
+func TestObjectFields(t *testing.T) {
+	fields := ObjectFields(newObjRequest())
+	want := "trace_id method token client.ip client.Agent meta.trace_id elapsed at err tags count deep.L1.L2"
+	if got := objectKeys(fields); got != want {
+		t.Errorf("keys:\n got:  %s\n want: %s", got, want)
+	}
+	var buf bytes.Buffer
+	l := New(InfoLevel, OOutput(&buf), OFlag(Llevel|Lshortfile))
+	l.InfoObj("request", newObjRequest())
+	got := buf.String()
+	wantLine := "INFO object_test.go:82 request trace_id=t-1 method=POST token=abc client.ip=10.0.0.1 client.Agent=curl meta.trace_id=m-1 " +
+		"elapsed=1.5s at=2024-01-02T03:04:05Z err=boom tags=[a b] count=<nil> deep.L1.L2={{7}}\n"
+	if got != wantLine {
+		t.Errorf("InfoObj:\n got:  %q\n want: %q", got, wantLine)
+	}
+	for _, secret := range []string{"hunter2", "secret", "internal"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("output contains %q", secret)
+		}
+	}
+
+	// 嵌套指针不为 nil 时展开；值为结构体或指针都使用同一份缓存的结果
+	r := *newObjRequest()
+	r.Proxy = &objClient{IP: "10.0.0.2"}
+	n := 3
+	r.Count = &n
+	if got := objectKeys(ObjectFields(r)); !strings.Contains(got, "Proxy.ip Proxy.Agent") {
+		t.Errorf("keys with proxy: %s", got)
+	}
+
+	// 不是结构体、有自己的文本形式的结构体以及 nil
+	if f := ObjectFields(42); len(f) != 1 || f[0].Key != "value" || f[0].Value != 42 {
+		t.Errorf("non-struct: %+v", f)
+	}
+	if f := ObjectFields(time.Time{}); len(f) != 1 || f[0].Key != "value" {
+		t.Errorf("time.Time: %+v", f)
+	}
+	if ObjectFields(nil) != nil || ObjectFields((*objRequest)(nil)) != nil {
+		t.Error("nil objects should have no fields")
+	}
+
+	buf.Reset()
+	l.SetLevel(WarnLevel).InfoObj("filtered", newObjRequest())
+	if buf.Len() != 0 {
+		t.Errorf("InfoObj below the level: %q", buf.String())
+	}
+}
+
+func BenchmarkObjectFields(b *testing.B) {
+	r := newObjRequest()
+	ObjectFields(r)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ObjectFields(r)
+	}
+}
+
+// BenchmarkObjectPlan 是没有缓存时每次都要计算的反射结果，与 BenchmarkObjectFields 对比可以看出缓存的收益
+func BenchmarkObjectPlan(b *testing.B) {
+	r := newObjRequest()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buildObjectPlan(reflect.TypeOf(*r), "", nil, 0)
+	}
+}
@@ -1,77 +1,176 @@
 package elog
 
 import (
+	"bytes"
+	"os"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
-func (l *Log) outputDate(flag *int, t time.Time) {
+func (l *Log) outputDate(flag *int, e *Entry) {
 	// 处理日期和时间
-	tmpFlag := *flag
+	tmpFlag, t := *flag, e.Time
+	if l.layout != "" {
+		l.outputLayout(flag, t)
+		return
+	}
 	if tmpFlag&Ldate != 0 {
+		if tmpFlag&Lweekday != 0 {
+			l.buf = append(l.buf, t.Weekday().String()[:3]...)
+			addSpace(&l.buf)
+		}
 		year, month, day := t.Date()
+		sep := byte('/')
+		if tmpFlag&Ldateiso != 0 {
+			sep = '-'
+		}
 		itoa(&l.buf, year, 4)
-		l.buf = append(l.buf, '/')
+		l.buf = append(l.buf, sep)
 		itoa(&l.buf, int(month), 2)
-		l.buf = append(l.buf, '/')
+		l.buf = append(l.buf, sep)
 		itoa(&l.buf, day, 2)
-		addSpace(&l.buf)
+		// ISO 8601 的日期和时间以 'T' 相连，时间之后紧跟时区偏移，作为一个整体输出
+		if tmpFlag&Ldateiso != 0 && tmpFlag&(Ltime|Lmicroseconds) != 0 {
+			l.buf = append(l.buf, 'T')
+			l.appendClock(t, tmpFlag&Lmicroseconds != 0)
+			l.appendOffset(t)
+			// 填充放在时区偏移之后，时间戳本身仍是合法的 RFC 3339
+			l.padTime(tmpFlag&(Lfixedtime|Lmicroseconds) == Lfixedtime, len(".000000"))
+			*flag = subFlag(*flag, Ltime|Lmicroseconds)
+		} else {
+			addSpace(&l.buf)
+		}
 		*flag = subFlag(*flag, Ldate)
 	}
 }
 
-func (l *Log) outputTime(flag *int, t time.Time) {
-	tmpFlag := *flag
+func (l *Log) outputTime(flag *int, e *Entry) {
+	tmpFlag, t := *flag, e.Time
+	if l.layout != "" {
+		l.outputLayout(flag, t)
+		return
+	}
+	if tmpFlag&(Ldate|Ldateiso) == Ldate|Ldateiso {
+		l.outputDate(flag, e) // ISO 8601 的日期和时间作为一个整体，在日期和时间中先出现的位置输出
+		return
+	}
 	if tmpFlag&(Ltime|Lmicroseconds) != 0 {
-		hour, min, sec := t.Clock()
-		itoa(&l.buf, hour, 2)
-		l.buf = append(l.buf, ':')
-		itoa(&l.buf, min, 2)
-		l.buf = append(l.buf, ':')
-		itoa(&l.buf, sec, 2)
-
-		if tmpFlag&Lmicroseconds != 0 {
-			l.buf = append(l.buf, '.')
-			itoa(&l.buf, t.Nanosecond()/1e3, 6)
+		l.appendClock(t, tmpFlag&Lmicroseconds != 0)
+		l.padTime(tmpFlag&(Lfixedtime|Lmicroseconds) == Lfixedtime, len(".000000"))
+		*flag = subFlag(*flag, Ltime|Lmicroseconds)
+	}
+}
 
+// outputLayout 按 OTimeLayout 设置的格式输出日期和时间，日期和时间只输出一次
+func (l *Log) outputLayout(flag *int, t time.Time) {
+	if *flag&(Ldate|Ltime|Lmicroseconds) != 0 {
+		start := len(l.buf)
+		l.buf = t.AppendFormat(l.buf, l.layout)
+		pad := 0
+		if *flag&Lfixedtime != 0 {
+			if l.layoutWidth == 0 {
+				l.layoutWidth = layoutWidth(l.layout)
+			}
+			if n := len(l.buf) - start; n > l.layoutWidth {
+				l.layoutWidth = n // 比预估的更宽（如更长的时区缩写）时，之后的日志按它对齐
+			} else {
+				pad = l.layoutWidth - n
+			}
 		}
+		l.padTime(pad > 0, pad)
+		*flag = subFlag(*flag, Ldate|Ltime|Lmicroseconds)
+	}
+}
+
+// padTime 在 pad 为 true 时追加 n 个空格代替时间中缺少的部分，之后追加间隔符号。
+// 时间以空格结尾时 addSpace 不会再添加间隔符号，因此这里直接追加。
+func (l *Log) padTime(pad bool, n int) {
+	if !pad {
 		addSpace(&l.buf)
-		*flag = subFlag(*flag, Ltime|Lmicroseconds)
+		return
+	}
+	for i := 0; i <= n; i++ {
+		l.buf = append(l.buf, ' ')
+	}
+}
+
+// layoutWidth 返回以 layout 格式化时间时的最大宽度，取月份名、星期名、各数字位都最长的时刻格式化的结果
+func layoutWidth(layout string) int {
+	return len(time.Date(2006, time.September, 27, 23, 59, 59, 999999999, time.UTC).Format(layout))
+}
+
+func (l *Log) appendClock(t time.Time, micro bool) {
+	hour, min, sec := t.Clock()
+	itoa(&l.buf, hour, 2)
+	l.buf = append(l.buf, ':')
+	itoa(&l.buf, min, 2)
+	l.buf = append(l.buf, ':')
+	itoa(&l.buf, sec, 2)
+	if micro {
+		l.buf = append(l.buf, '.')
+		itoa(&l.buf, t.Nanosecond()/1e3, 6)
 	}
 }
 
-func (l *Log) outputPath(flag *int, file string, line int) {
+// appendOffset 追加形如 +08:00 的时区偏移，UTC 为 +00:00
+func (l *Log) appendOffset(t time.Time) {
+	_, offset := t.Zone()
+	sign := byte('+')
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	offset /= 60
+	l.buf = append(l.buf, sign)
+	itoa(&l.buf, offset/60, 2)
+	l.buf = append(l.buf, ':')
+	itoa(&l.buf, offset%60, 2)
+}
+
+func (l *Log) outputPath(flag *int, e *Entry) {
 	// 处理文件路径
-	tmpFlag := *flag
+	tmpFlag, file := *flag, e.File
 	if tmpFlag&(Lshortfile|Llongfile) != 0 {
 		// 如果设置了简洁文件路径，则将文件路径从后往前遍历，找到第一个 '/'，然后取后面的部分
 		if tmpFlag&Lshortfile != 0 {
-			short := file
-			for i := len(file) - 1; i > 0; i-- {
-				if file[i] == '/' {
-					short = file[i+1:]
-					break
-				}
-			}
-			file = short
+			file = shortPath(file)
 		}
 		// 如果设置了全文件路径，则直接将填入 buffer
 		l.buf = append(l.buf, file...)
 		// 追加行号
 		l.buf = append(l.buf, ':')
-		itoa(&l.buf, line, -1)
+		itoa(&l.buf, e.Line, -1)
 		// 追加间隔符号，间隔符号后就是打印内容了
 		addSpace(&l.buf)
 		*flag = subFlag(*flag, Lshortfile|Llongfile)
 	}
 }
 
-func (l *Log) outputLevel(flag *int, level logLevel) {
+// shortPath 返回文件路径中最后一个 '/' 之后的部分
+func shortPath(file string) string {
+	for i := len(file) - 1; i > 0; i-- {
+		if file[i] == '/' {
+			return file[i+1:]
+		}
+	}
+	return file
+}
+
+func (l *Log) outputLevel(flag *int, e *Entry) {
 	// 处理等级前缀
-	tmpFlag := *flag
+	tmpFlag, level := *flag, e.Level
+	if tmpFlag&Llevel != 0 && level < l.labelFrom {
+		*flag = subFlag(*flag, Llevel|LlevelLabelColor)
+		return
+	}
 	if tmpFlag&Llevel != 0 {
-		label := levelMap[level].levelLabel
-		if tmpFlag&LlevelLabelColor != 0 {
-			label = levelMap[level].levelLabelColor + levelMap[level].levelLabel + color_
+		info := levelOf(level)
+		label := info.levelLabel
+		if tmpFlag&Lshortlevel != 0 {
+			label = shortLabel(label)
+		}
+		if tmpFlag&LlevelLabelColor != 0 && l.colorOn() {
+			label = info.levelLabelColor + label + color_
 			*flag = subFlag(*flag, LlevelLabelColor)
 		}
 		l.buf = append(l.buf, label...)
@@ -80,29 +179,117 @@ func (l *Log) outputLevel(flag *int, level logLevel) {
 	}
 }
 
-func (l *Log) outputPrefix(flag *int) {
+const upperLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// shortLabel 返回 Lshortlevel 使用的单字母标签：label 的首字母，小写字母转为大写
+func shortLabel(label string) string {
+	if label == "" {
+		return ""
+	}
+	if c := label[0]; 'a' <= c && c <= 'z' {
+		return upperLetters[c-'a' : c-'a'+1]
+	}
+	_, size := utf8.DecodeRuneInString(label)
+	return label[:size]
+}
+
+func (l *Log) outputPrefix(flag *int, e *Entry) {
 	// 处理消息前缀 msgPrefix
 	tmpFlag := *flag
 	if tmpFlag&Lmsgprefix != 0 {
-		l.buf = append(l.buf, l.prefix...)
+		if tmpFlag&LnameColor != 0 && e.Prefix != "" && l.colorOn() {
+			name := e.LoggerName
+			if name == "" {
+				name = e.Prefix
+			}
+			l.buf = append(l.buf, nameColor(name)...)
+			l.buf = append(l.buf, e.Prefix...)
+			l.buf = append(l.buf, _reset...)
+		} else {
+			l.buf = append(l.buf, e.Prefix...)
+		}
 		addSpace(&l.buf)
 		*flag = subFlag(*flag, Lmsgprefix)
 	}
 }
 
-func (l *Log) outputMsg(written *bool, level logLevel, msg string) {
+func (l *Log) outputMsg(written *bool, flag int, e *Entry) {
 	if *written {
 		return
 	}
-	if l.flag&Lmsgcolor != 0 {
+	l.alignMsg()
+	l.separateMsg()
+	level, fields := e.Level, e.Fields
+	msg := strings.TrimSuffix(e.Msg, "\n") // 换行符由 setNewLine 统一添加
+	// 空消息不输出颜色转义字符，避免在行内留下一段空的颜色块
+	colored := flag&Lmsgcolor != 0 && (msg != "" || len(fields) > 0) && l.colorOn()
+	if colored {
 		setColor(&l.buf, level)
-		defer unsetColor(&l.buf)
 	}
 	l.buf = append(l.buf, msg...) // 将打印内容填充到 buffer 中
-	addSpace(&l.buf)
+	// 字段以 key=value 的形式追加在打印内容之后
+	for _, f := range fields {
+		if f.Kind == KindError && f.num == inMsg {
+			continue // %w 捕获的错误已经在消息中，见 sprintf
+		}
+		addSpace(&l.buf)
+		l.buf = append(l.buf, f.Key...)
+		l.buf = append(l.buf, '=')
+		l.buf = appendFieldText(l.buf, f)
+	}
+	if colored {
+		unsetColor(&l.buf)
+	}
+	addSpace(&l.buf) // 间隔符号放在颜色块之外
 	*written = true
 }
 
+// separateMsg 把头部末尾的间隔符号替换为 OMsgSeparator 设置的分隔符，没有头部时不输出分隔符
+func (l *Log) separateMsg() {
+	if l.msgSep == "" || len(l.buf) == 0 || l.buf[len(l.buf)-1] != ' ' {
+		return
+	}
+	l.buf = append(l.buf[:len(l.buf)-1], l.msgSep...)
+}
+
+// alignMsg 在消息之前填充空格，使消息从 l.align 列开始
+func (l *Log) alignMsg() {
+	if l.align == 0 || len(l.buf) == 0 {
+		return
+	}
+	width := displayWidth(l.buf)
+	target := l.align
+	if target == AlignAuto {
+		if width > l.alignAt {
+			l.alignAt = width
+		}
+		target = l.alignAt
+	}
+	for ; width < target; width++ {
+		l.buf = append(l.buf, ' ')
+	}
+}
+
+// displayWidth 返回 b 的显示宽度，按 rune 计数并忽略 ANSI 转义序列（ESC [ ... 结束字节）
+func displayWidth(b []byte) int {
+	width := 0
+	for i := 0; i < len(b); {
+		if b[i] == '\x1b' && i+1 < len(b) && b[i+1] == '[' {
+			// 跳过参数和中间字节，直到结束字节 0x40-0x7E
+			i += 2
+			for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+				i++
+			}
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRune(b[i:])
+		i += size
+		width++
+	}
+	return width
+}
+
 func addSpace(buf *[]byte) {
 	b := *buf
 	if len(b) == 0 {
@@ -117,40 +304,108 @@ func addSpace(buf *[]byte) {
 	}
 }
 
+// endLine 以 OLineEnding 设置的行结束符结束 l.buf 中的一行
+func (l *Log) endLine() {
+	setNewLine(&l.buf)
+	if l.ending != "" {
+		l.buf = appendLineEnding(l.buf[:len(l.buf)-1], l.ending)
+	}
+}
+
+// appendLineEnding 在 buf 之后追加行结束符 ending，buf 末尾已有 ending 除 '\n' 之外的部分（如 "\r\n" 的 '\r'）时不重复添加
+func appendLineEnding(buf []byte, ending string) []byte {
+	if head := ending[:len(ending)-1]; head != "" && bytes.HasSuffix(buf, []byte(head)) {
+		buf = buf[:len(buf)-len(head)]
+	}
+	return append(buf, ending...)
+}
+
 func setNewLine(buf *[]byte) {
 	b := *buf
-	if len(b) == 0 || b[len(b)-1] != '\n' { // 如果打印内容为空或者内容末尾没有换行符，则追加换行符
-		if b[len(b)-1] == ' ' { // 末尾是空格的情况，替换成换行符
-			*buf = append(b[:len(b)-1], '\n')
-			return
-		}
-		*buf = append(*buf, '\n')
+	if len(b) == 0 { // 没有任何内容（空消息且没有头部）时只输出一个换行符
+		*buf = append(b, '\n')
+		return
+	}
+	switch b[len(b)-1] {
+	case '\n': // 内容末尾已经有换行符
+	case ' ': // 末尾是空格的情况，替换成换行符
+		*buf = append(b[:len(b)-1], '\n')
+	default:
+		*buf = append(b, '\n')
 	}
 }
 
-// 格式化数字，用于格式化日期和时间。当 num 小于 10 时添加一个 0 前缀，当 num 大于 10 时
-// 则逐一切取 num 的数值，追加到 buffer 中。wid 为负数时不填充 0 前缀。
+// 格式化数字，用于格式化日期、时间和行号等。逐一切取 num 的数值追加到 buffer 中，
+// wid 为数字部分的最小位数，不足时填充 0 前缀，wid <= 0 时不填充；负数会在 0 前缀之前输出 '-'，如 -05。
 func itoa(buf *[]byte, num int, wid int) {
-	var b [20]byte
+	var b [20]byte // uint64 最多 20 位
 	bIdx := len(b) - 1
-	for num >= 10 || wid > 1 {
+	u := uint(num)
+	if num < 0 {
+		u = -u // 对 math.MinInt 同样成立
+		*buf = append(*buf, '-')
+	}
+	for u >= 10 {
 		// 取模
-		q := num / 10
-		b[bIdx] = byte('0' + num - q*10)
+		q := u / 10
+		b[bIdx] = byte('0' + u - q*10)
 		// 更新
-		num = q
-		wid--
+		u = q
 		bIdx--
 	}
-	// num < 10
-	b[bIdx] = byte('0' + num)
+	// u < 10
+	b[bIdx] = byte('0' + u)
+	for digits := len(b) - bIdx; wid > digits; wid-- {
+		*buf = append(*buf, '0')
+	}
 	*buf = append(*buf, b[bIdx:]...)
 }
 
+// noColor 在环境变量 NO_COLOR 不为空时为 true，此时所有颜色相关的 flag 都不生效，见 https://no-color.org
+var noColor = os.Getenv("NO_COLOR") != ""
+
+func colorEnabled() bool {
+	return !noColor
+}
+
+// namePalette 是 LnameColor 使用的调色板，避开了与黑白背景对比度低的颜色
+var namePalette = [...]string{
+	"\x1b[31m", "\x1b[32m", "\x1b[33m", "\x1b[34m", "\x1b[35m", "\x1b[36m",
+	"\x1b[91m", "\x1b[92m", "\x1b[93m", "\x1b[94m", "\x1b[95m", "\x1b[96m",
+}
+
+// nameColor 按 name 的 FNV-1a 哈希从调色板中选取颜色，同一名称在任何进程中得到的颜色都相同
+func nameColor(name string) string {
+	h := uint32(2166136261)
+	for i := 0; i < len(name); i++ {
+		h ^= uint32(name[i])
+		h *= 16777619
+	}
+	return namePalette[h%uint32(len(namePalette))]
+}
+
 func setColor(buf *[]byte, level logLevel) {
-	*buf = append(*buf, levelMap[level].levelColor...)
+	*buf = append(*buf, levelOf(level).levelColor...)
 }
 
 func unsetColor(buf *[]byte) {
 	*buf = append(*buf, color_...)
 }
+
+// lineColor 返回 Llinecolor 使用的颜色，与 Lmsgcolor 相同但不带前导空格，不改变各项所在的列
+func lineColor(level logLevel) string {
+	return strings.TrimSuffix(levelOf(level).levelColor, " ")
+}
+
+// closeLineColor 结束 l.buf 中从 start 开始、长度为 n 的整行颜色：颜色之后没有内容时去掉颜色，
+// 否则把颜色的结束放在末尾的间隔符号之前，使行结束符位于颜色块之外
+func (l *Log) closeLineColor(start, n int) {
+	if l.buf[len(l.buf)-1] == ' ' {
+		l.buf = l.buf[:len(l.buf)-1]
+	}
+	if len(l.buf) == start+n {
+		l.buf = l.buf[:start]
+		return
+	}
+	l.buf = append(l.buf, _reset...)
+}
@@ -0,0 +1,177 @@
+package elog
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// itoaWant 用 strconv 计算 itoa 的期望结果
+func itoaWant(num, wid int) string {
+	s := strconv.Itoa(num)
+	sign := ""
+	if num < 0 {
+		sign, s = "-", s[1:]
+	}
+	if len(s) < wid {
+		s = strings.Repeat("0", wid-len(s)) + s
+	}
+	return sign + s
+}
+
+func TestItoa(t *testing.T) {
+	nums := []int{0, 1, 9, 10, 99, 100, 2022, 123456, -1, -9, -10, -2022, math.MaxInt, math.MinInt, math.MinInt + 1}
+	wids := []int{-5, -1, 0, 1, 2, 4, 6, 25}
+	for _, num := range nums {
+		for _, wid := range wids {
+			var buf []byte
+			itoa(&buf, num, wid)
+			if got, want := string(buf), itoaWant(num, wid); got != want {
+				t.Errorf("itoa(%d, %d): want %q, got %q", num, wid, want, got)
+			}
+		}
+	}
+}
+
+func TestItoaAppends(t *testing.T) {
+	buf := []byte("line:")
+	itoa(&buf, -7, 3)
+	if got, want := string(buf), "line:-007"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func FuzzItoa(f *testing.F) {
+	f.Add(0, 0)
+	f.Add(-1, 2)
+	f.Add(2022, 4)
+	f.Add(math.MinInt, 30)
+	f.Fuzz(func(t *testing.T, num, wid int) {
+		if wid > 1024 {
+			wid = 1024
+		}
+		var buf []byte
+		itoa(&buf, num, wid)
+		if got, want := string(buf), itoaWant(num, wid); got != want {
+			t.Errorf("itoa(%d, %d): want %q, got %q", num, wid, want, got)
+		}
+	})
+}
+
+func TestNameColor(t *testing.T) {
+	// 固定名称的颜色在不同版本、不同进程之间保持不变，不同名称尽量不同
+	if nameColor("api") != "\x1b[96m" || nameColor("db") != "\x1b[92m" {
+		t.Errorf("palette assignment changed: api %q, db %q", nameColor("api"), nameColor("db"))
+	}
+
+	var buf bytes.Buffer
+	api := New(InfoLevel, OOutput(&buf), OName("api"), OPrefix("[api]"), OFlag(Lmsgprefix|LnameColor|Llevel))
+	api.Info("hello")
+	want := "INFO \x1b[96m[api]\x1b[0m hello\n"
+	if buf.String() != want {
+		t.Errorf("want %q, got %q", want, buf.String())
+	}
+
+	// 没有名称时按前缀选取颜色
+	buf.Reset()
+	New(InfoLevel, OOutput(&buf), OPrefix("db"), OFlag(Lmsgprefix|LnameColor)).Info("query")
+	if want := nameColor("db") + "db\x1b[0m query\n"; buf.String() != want {
+		t.Errorf("want %q, got %q", want, buf.String())
+	}
+
+	// NO_COLOR 时所有颜色都不输出
+	noColor = true
+	defer func() { noColor = false }()
+	buf.Reset()
+	api.AddFlag(Lmsgcolor | LlevelLabelColor).Info("hello")
+	if want := "INFO [api] hello\n"; buf.String() != want {
+		t.Errorf("NO_COLOR: want %q, got %q", want, buf.String())
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	var text, js, csvOut bytes.Buffer
+	l := New(InfoLevel, OOutput(&text), OFlag(Llevel), OLineEnding("\r\n")).
+		AddOutput(&js, WithEncoder(JSONEncoder)).
+		AddOutput(&csvOut, WithEncoder(NewCSVEncoder(CSVConfig{Header: true, Columns: []CSVColumn{CSVLevel, CSVMsg}})))
+	l.Info("plain")
+	l.Warn("ends with lf\n")
+	l.Error("ends with crlf\r\n")
+	l.Raw("raw")
+	l.Extend().Info("child")
+
+	want := "INFO plain\r\nWARN ends with lf\r\nERROR ends with crlf\r\nraw\r\nINFO child\r\n"
+	if got := text.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+	if got := js.String(); strings.Count(got, "}\r\n") != 4 || strings.Count(got, "\n") != 4 {
+		t.Errorf("JSON lines should end with CRLF, got %q", got)
+	}
+	// 编码器输出的消息保留 Println 之外的换行符，CSV 中以引号包围
+	if got, want := csvOut.String(), "level,msg\r\nINFO,plain\r\nWARN,\"ends with lf\n\"\r\nERROR,\"ends with crlf\r\n\"\r\nINFO,child\r\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+
+	text.Reset()
+	l = New(InfoLevel, OOutput(&text), OLineEnding("\r"), OLineEnding("\n"))
+	l.Info("default\n")
+	if got := text.String(); got != "default\n" {
+		t.Errorf("invalid endings should be ignored, got %q", got)
+	}
+}
+
+func TestShortLevel(t *testing.T) {
+	skipCompiledOut(t, TraceLevel)
+	var buf bytes.Buffer
+	l := New(TraceLevel, OOutput(&buf), OFlag(Llevel|Lshortlevel))
+	l.Trace("t")
+	l.Info("i")
+	l.Log(noticeLevel, "n")
+	l.Audit("a")
+	if got, want := buf.String(), "T t\nI i\nN n\nA a\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := shortLabel("ü"); got != "ü" {
+		t.Errorf("shortLabel: got %q", got)
+	}
+}
+
+func TestMsgSeparator(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(InfoLevel, OOutput(&buf), OFlag(Llevel), OMsgSeparator(" | "))
+	l.Info("hello")
+	l.Info()
+	l.SetFlag(0)
+	l.Info("no header")
+	if got, want := buf.String(), "INFO | hello\nINFO |\nno header\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	l = New(InfoLevel, OOutput(&buf), OFlag(Llevel|Lshortlevel), OMsgSeparator("|"), OAlign(4))
+	l.Warn("aligned")
+	if got, want := buf.String(), "W  |aligned\n"; got != want {
+		t.Errorf("aligned: got %q, want %q", got, want)
+	}
+
+	var header []byte
+	l.FormatHeader(&header, time.Time{}, ErrorLevel, "", 0)
+	if got, want := string(header), "E  |"; got != want {
+		t.Errorf("FormatHeader: got %q, want %q", got, want)
+	}
+	if got := l.Extend().msgSep; got != "|" {
+		t.Errorf("Extend: msgSep %q", got)
+	}
+}
+
+func TestCompactOverride(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(InfoLevel, OOutput(&buf), OCompact(), OFlag(Llevel|Lshortlevel))
+	l.Error("boom")
+	if got, want := buf.String(), "E|boom\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package elog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PanicError 是开启 OPanicError 后 Panic 系列方法抛出的值，保留了原始参数及类型和抛出处的调用栈
+type PanicError struct {
+	Msg   string // 输出到日志中的消息，不含末尾的换行符
+	Value any    // 传给 Panic 的参数，只有一个参数时为该参数本身，否则为 []any；通过 EntryBuilder 抛出时为 nil
+	Stack string // 调用 Panic 处的调用栈
+}
+
+// Error 返回 Msg，与未开启 OPanicError 时抛出的字符串内容相同（不含末尾的换行符）
+func (e *PanicError) Error() string {
+	return e.Msg
+}
+
+// Unwrap 在 Value 是 error 时返回它，使 errors.Is、errors.As 可以匹配到原始错误
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// OPanicError 设置 Panic 系列方法抛出 *PanicError 而不是格式化后的字符串。
+// 默认行为与之前的版本保持一致，抛出 fmt.Sprintln 或 fmt.Sprintf 的结果。
+func OPanicError() LogOption {
+	return func(logger *Log) {
+		logger.panicErr = true
+	}
+}
+
+// panicValue 返回 Panic 系列方法应抛出的值，calldepth 的含义与 Out 相同。
+// panicErr 和 skip 只由 LogOption 在 New、Extend 中设置，之后不再修改，读取时不加锁，在 Writer 中调用 Panic 也不会死锁
+func (l *Log) panicValue(calldepth int, msg string, v []any) any {
+	structured, skip := l.panicErr, l.skip
+	if !structured {
+		return msg
+	}
+	var value any = v
+	switch len(v) {
+	case 0:
+		value = nil
+	case 1:
+		value = v[0]
+	}
+	return &PanicError{
+		Msg:   strings.TrimSuffix(msg, "\n"),
+		Value: value,
+		Stack: callerStack(calldepth + 1 + skip),
+	}
+}
+
+// panicDetail 返回 recover 得到的 panic 值在日志中的形式，字符串以外的值会带上类型，如 "EOF (*errors.errorString)"。
+// *PanicError 显示其消息及原始参数的类型。
+func panicDetail(v any) string {
+	msg := v
+	if pe, ok := v.(*PanicError); ok {
+		msg, v = pe.Msg, pe.Value
+	}
+	switch v.(type) {
+	case nil, string:
+		return fmt.Sprint(msg)
+	}
+	return fmt.Sprintf("%v (%T)", msg, v)
+}
@@ -0,0 +1,149 @@
+package elog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recovered 调用 f 并返回它抛出的值
+func recovered(f func()) (v any) {
+	defer func() { v = recover() }()
+	f()
+	return nil
+}
+
+func TestPanicValue(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	if v := recovered(func() { l.Panic("boom", 1) }); v != "boom 1\n" {
+		t.Errorf("default panic value should stay a string, got %#v", v)
+	}
+
+	var b bytes.Buffer
+	l = New(InfoLevel, OOutput(&b), OPanicError())
+	tests := []struct {
+		name  string
+		f     func()
+		msg   string
+		value any
+	}{
+		{"Panic", func() { l.Panic(io.EOF) }, "EOF", io.EOF},
+		{"Panic many", func() { l.Panic("a", 1) }, "a 1", []any{"a", 1}},
+		{"Panicf", func() { l.Panicf("read: %v", io.EOF) }, "read: EOF", io.EOF},
+		{"PanicIf", func() { l.PanicIf(true, "cond") }, "cond", "cond"},
+		{"child", func() { l.WithName("sub").Panic("child") }, "child", "child"},
+		{"builder", func() { l.With().Level(PanicLevel).Msg("built") }, "built", nil},
+		{"Multi", func() { Multi(New(InfoLevel, OOutput(io.Discard)), l).Panic("multi") }, "multi", "multi"},
+	}
+	for _, tt := range tests {
+		v := recovered(tt.f)
+		pe, ok := v.(*PanicError)
+		if !ok {
+			t.Errorf("%s: want *PanicError, got %#v", tt.name, v)
+			continue
+		}
+		if pe.Msg != tt.msg || pe.Error() != tt.msg {
+			t.Errorf("%s: want msg %q, got %q", tt.name, tt.msg, pe.Msg)
+		}
+		if s, ok := tt.value.([]any); ok {
+			if got, _ := pe.Value.([]any); len(got) != len(s) {
+				t.Errorf("%s: want value %v, got %#v", tt.name, s, pe.Value)
+			}
+		} else if pe.Value != tt.value {
+			t.Errorf("%s: want value %#v, got %#v", tt.name, tt.value, pe.Value)
+		}
+		if !strings.HasPrefix(pe.Stack, "github.com/TCP404/elog.TestPanicValue.func") {
+			t.Errorf("%s: stack should start at the caller, got %q", tt.name, pe.Stack)
+		}
+	}
+
+	v := recovered(func() { l.Panic(io.EOF) })
+	if err, _ := v.(error); !errors.Is(err, io.EOF) {
+		t.Errorf("PanicError should unwrap to the original error, got %#v", v)
+	}
+}
+
+func TestRecoverPanicError(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OPanicError())
+	func() {
+		defer RecoverAndLog(l)
+		l.Panic(io.EOF)
+	}()
+	logged := strings.SplitN(b.String(), "\n", 3)
+	if len(logged) != 3 || !strings.Contains(logged[1], "panic recovered: EOF (*errors.errorString) [goroutine ") {
+		t.Fatalf("recovered entry should show the original type, got %q", b.String())
+	}
+	if !strings.HasPrefix(logged[2], "github.com/TCP404/elog.TestRecoverPanicError.func") {
+		t.Errorf("recovered stack should start where Panic was called, got %q", logged[2])
+	}
+
+	b.Reset()
+	func() {
+		defer RecoverAndLog(l)
+		panic(errors.New("plain"))
+	}()
+	if !strings.Contains(b.String(), "panic recovered: plain (*errors.errorString) [goroutine ") {
+		t.Errorf("non-string values should include their type, got %q", b.String())
+	}
+}
+
+// TestPanicFlushes 检查 panic 向上传播、被 recover 时 panic 的日志已经写入文件，而不是还留在缓冲中
+func TestPanicFlushes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	bw := bufio.NewWriterSize(f, 4096)
+	async := NewAsyncWriter(&delayWriter{w: bw, delay: 10 * time.Millisecond}, 16)
+	defer async.Close()
+	l := New(InfoLevel, OOutput(async), OFlag(0))
+
+	onDisk := func(panicking func()) string {
+		var content []byte
+		func() {
+			defer func() {
+				recover()
+				content, _ = os.ReadFile(path)
+			}()
+			panicking()
+		}()
+		return string(content)
+	}
+	if got := onDisk(func() { l.Panic("first") }); got != "first\n" {
+		t.Errorf("Panic: the entry should be on disk before the panic propagates, got %q", got)
+	}
+	if got := onDisk(func() { l.Ctx(context.Background()).Panicf("second") }); got != "first\nsecond\n" {
+		t.Errorf("Ctx.Panicf: got %q", got)
+	}
+	if got := onDisk(func() { l.With().Level(PanicLevel).Msg("third") }); got != "first\nsecond\nthird\n" {
+		t.Errorf("EntryBuilder: got %q", got)
+	}
+}
+
+// delayWriter 在每次写入前等待 delay，模拟慢速的输出
+type delayWriter struct {
+	w     io.Writer
+	delay time.Duration
+}
+
+func (w *delayWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+	return w.w.Write(p)
+}
+
+func (w *delayWriter) Flush() error {
+	if f, ok := w.w.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package elog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseLine 把 elog 文本格式的一行日志解析回 Entry，flags 必须与写入时 logger 使用的 flag 相同，
+// 用于在测试中对捕获的日志断言，或者把旧的日志文件迁移到 JSON 等结构化的输出：
+//
+//	e, err := elog.ParseLine([]byte("2024/05/06 07:08:09 WARN main.go:12 disk almost full\n"), elog.LstdFlags)
+//
+// 按默认的输出顺序依次解析日期、时间、等级、文件路径、前缀和消息，颜色转义字符会被去掉，行结束符可以是 "\n" 或 "\r\n"。
+// 文本格式并不总是可逆的，以下信息无法还原：
+//
+//   - 没有设置 Ldateiso 和 Ltime 的组合时，时间按 time.Local（设置了 LUTC 时按 UTC）解析，只有时间时日期为公元 1 年 1 月 1 日
+//   - 没有设置 Llevel 时 Level 为 Discard，审计日志的 AUDIT 标签作为消息的一部分
+//   - Lshortfile 时 File 只有文件名，文件路径不能含有空格；Func、LoggerName 和 Stack 总是为空
+//   - Lmsgprefix 时前缀取第一个空格之前的部分，因此前缀不能为空或含有空格
+//   - 字段以 key=value 的形式保留在 Msg 中，Fields 总是为空
+//
+// 设置了 Lshortlevel 或者通过 OOrder、OTimeLayout、OAlign、OLevelLabelFrom、OMsgSeparator 等选项改变了格式的日志不能用 ParseLine 解析。
+func ParseLine(b []byte, flags int) (Entry, error) {
+	flags = NormalizeFlags(flags)
+	// 颜色只在输出时开启了颜色的行中出现，这些行的等级标签和消息两侧有颜色块带来的空格
+	colored := flags&Llinecolor == 0 && bytes.IndexByte(b, '\x1b') >= 0
+	line := string(stripColors(nil, b))
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	p := lineParser{s: line, loc: time.Local}
+	if flags&LUTC != 0 {
+		p.loc = time.UTC
+	}
+
+	var e Entry
+	if err := p.parseTime(flags, &e); err != nil {
+		return Entry{}, p.fail("time", line, err)
+	}
+	if flags&Llevel != 0 {
+		if err := p.parseLevel(colored && flags&LlevelLabelColor != 0, &e); err != nil {
+			return Entry{}, p.fail("level", line, err)
+		}
+	}
+	if flags&(Lshortfile|Llongfile) != 0 {
+		if err := p.parsePath(&e); err != nil {
+			return Entry{}, p.fail("file path", line, err)
+		}
+	}
+	if flags&Lmsgprefix != 0 {
+		e.Prefix = p.token()
+		if e.Prefix == "" {
+			return Entry{}, p.fail("prefix", line, errEmpty)
+		}
+		if err := p.sep(); err != nil {
+			return Entry{}, p.fail("prefix", line, err)
+		}
+	}
+	e.Msg = p.s
+	if colored && flags&Lmsgcolor != 0 && e.Msg != "" {
+		e.Msg = strings.TrimPrefix(e.Msg, " ")
+		e.Msg = strings.TrimSuffix(e.Msg, " ")
+	}
+	return e, nil
+}
+
+// ParseReader 逐行解析 r 中的日志，见 ParseLine。无法解析的行视为上一条日志的消息中换行之后的部分，
+// 因此多行的消息会被还原到同一个 Entry 中；第一行就无法解析时返回错误。
+func ParseReader(r io.Reader, flags int) ([]Entry, error) {
+	var entries []Entry
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		b, err := br.ReadBytes('\n')
+		if len(b) > 0 {
+			e, perr := ParseLine(b, flags)
+			switch {
+			case perr == nil:
+				entries = append(entries, e)
+			case len(entries) > 0:
+				last := &entries[len(entries)-1]
+				b = bytes.TrimSuffix(bytes.TrimSuffix(b, []byte("\n")), []byte("\r"))
+				last.Msg += "\n" + string(stripColors(nil, b))
+			default:
+				return nil, fmt.Errorf("elog: line %d: %w", n, perr)
+			}
+		}
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+	}
+}
+
+var errEmpty = errors.New("empty")
+
+// lineParser 从 s 的开头依次读取日志的各个部分，与 out.go 中输出各部分的函数一一对应
+type lineParser struct {
+	s   string
+	loc *time.Location
+}
+
+func (p *lineParser) fail(what, line string, err error) error {
+	return errors.New("elog: cannot parse " + what + " (" + err.Error() + ") in " + strconv.Quote(line))
+}
+
+// sep 读取各部分之间的一个间隔符号，行尾的间隔符号已被换行符替换，见 setNewLine
+func (p *lineParser) sep() error {
+	if p.s == "" {
+		return nil
+	}
+	if p.s[0] != ' ' {
+		return errors.New("missing separator before " + strconv.Quote(p.s))
+	}
+	p.s = p.s[1:]
+	return nil
+}
+
+// spaces 读取 n 个空格，行尾不足 n 个时读取剩下的全部
+func (p *lineParser) spaces(n int) error {
+	for ; n > 0 && p.s != ""; n-- {
+		if p.s[0] != ' ' {
+			return errors.New("missing padding before " + strconv.Quote(p.s))
+		}
+		p.s = p.s[1:]
+	}
+	return nil
+}
+
+// token 读取下一个空格之前的部分
+func (p *lineParser) token() string {
+	i := strings.IndexByte(p.s, ' ')
+	if i < 0 {
+		i = len(p.s)
+	}
+	tok := p.s[:i]
+	p.s = p.s[i:]
+	return tok
+}
+
+func (p *lineParser) expect(c byte) error {
+	if p.s == "" || p.s[0] != c {
+		return errors.New("want " + strconv.QuoteRune(rune(c)))
+	}
+	p.s = p.s[1:]
+	return nil
+}
+
+// number 读取 n 位数字，n <= 0 时读取任意多位
+func (p *lineParser) number(n int) (int, error) {
+	i := 0
+	for i < len(p.s) && (n <= 0 || i < n) && '0' <= p.s[i] && p.s[i] <= '9' {
+		i++
+	}
+	if i == 0 || (n > 0 && i < n) {
+		return 0, errors.New("want digits")
+	}
+	v, err := strconv.Atoi(p.s[:i])
+	p.s = p.s[i:]
+	return v, err
+}
+
+// parseTime 对应 outputDate 和 outputTime
+func (p *lineParser) parseTime(flags int, e *Entry) error {
+	if flags&(Ldate|Ltime|Lmicroseconds) == 0 {
+		return nil
+	}
+	year, month, day := 1, 1, 1
+	var hour, min, sec, nsec int
+	loc := p.loc
+	micro := flags&Lmicroseconds != 0
+	clock := func() (err error) {
+		if hour, err = p.number(2); err != nil {
+			return err
+		}
+		if err = p.expect(':'); err != nil {
+			return err
+		}
+		if min, err = p.number(2); err != nil {
+			return err
+		}
+		if err = p.expect(':'); err != nil {
+			return err
+		}
+		if sec, err = p.number(2); err != nil {
+			return err
+		}
+		if micro {
+			if err = p.expect('.'); err != nil {
+				return err
+			}
+			var us int
+			if us, err = p.number(6); err != nil {
+				return err
+			}
+			nsec = us * 1e3
+		}
+		return nil
+	}
+	// end 读取时间之后的间隔符号，Lfixedtime 时先读取代替微秒的空格，见 padTime
+	end := func() error {
+		if flags&Lfixedtime != 0 && !micro {
+			if err := p.spaces(len(".000000")); err != nil {
+				return err
+			}
+		}
+		return p.sep()
+	}
+
+	withTime := flags&(Ltime|Lmicroseconds) != 0
+	if flags&Ldate != 0 {
+		if flags&Lweekday != 0 {
+			wd := p.token()
+			if !isWeekday(wd) {
+				return errors.New("unknown weekday " + strconv.Quote(wd))
+			}
+			if err := p.sep(); err != nil {
+				return err
+			}
+		}
+		sep := byte('/')
+		if flags&Ldateiso != 0 {
+			sep = '-'
+		}
+		var err error
+		if year, err = p.number(0); err != nil {
+			return err
+		}
+		if err = p.expect(sep); err != nil {
+			return err
+		}
+		var m int
+		if m, err = p.number(2); err != nil {
+			return err
+		}
+		month = m
+		if err = p.expect(sep); err != nil {
+			return err
+		}
+		if day, err = p.number(2); err != nil {
+			return err
+		}
+		if flags&Ldateiso != 0 && withTime {
+			if err = p.expect('T'); err != nil {
+				return err
+			}
+			if err = clock(); err != nil {
+				return err
+			}
+			if loc, err = p.offset(); err != nil {
+				return err
+			}
+			withTime = false
+			if err = end(); err != nil {
+				return err
+			}
+		} else if err = p.sep(); err != nil {
+			return err
+		}
+	}
+	if withTime {
+		if err := clock(); err != nil {
+			return err
+		}
+		if err := end(); err != nil {
+			return err
+		}
+	}
+	e.Time = time.Date(year, time.Month(month), day, hour, min, sec, nsec, loc)
+	return nil
+}
+
+// offset 读取形如 +08:00 的时区偏移，对应 appendOffset
+func (p *lineParser) offset() (*time.Location, error) {
+	if p.s == "" || (p.s[0] != '+' && p.s[0] != '-') {
+		return nil, errors.New("want time zone offset")
+	}
+	sign := 1
+	if p.s[0] == '-' {
+		sign = -1
+	}
+	p.s = p.s[1:]
+	h, err := p.number(2)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(':'); err != nil {
+		return nil, err
+	}
+	m, err := p.number(2)
+	if err != nil {
+		return nil, err
+	}
+	offset := sign * (h*3600 + m*60)
+	if offset == 0 {
+		return time.UTC, nil
+	}
+	return time.FixedZone("", offset), nil
+}
+
+func isWeekday(s string) bool {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if d.String()[:3] == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLevel 对应 outputLevel：标签补齐到至少 5 个字符，之后是间隔符号；
+// 带颜色时颜色块的两侧各多出一个空格，见 LlevelLabelColor 使用的颜色常量
+func (p *lineParser) parseLevel(colored bool, e *Entry) error {
+	if colored {
+		if err := p.expect(' '); err != nil {
+			return err
+		}
+	}
+	label := p.token()
+	level, err := ParseLevel(label)
+	if err != nil || label == "" {
+		return errors.New("unknown level " + strconv.Quote(label))
+	}
+	e.Level = level
+	pad := len(_InfoLabel) - len(label) // 自定义等级的标签可能超过 5 个字符，此时没有补齐的空格
+	if pad < 0 {
+		pad = 0
+	}
+	if colored {
+		return p.spaces(pad + 2)
+	}
+	if pad == 0 {
+		return p.sep()
+	}
+	return p.spaces(pad)
+}
+
+// parsePath 对应 outputPath，文件路径与行号以第一个后面紧跟数字和间隔符号的 ':' 分隔
+func (p *lineParser) parsePath(e *Entry) error {
+	for i := 0; i < len(p.s); i++ {
+		if p.s[i] == ' ' {
+			break
+		}
+		if p.s[i] != ':' {
+			continue
+		}
+		j := i + 1
+		for j < len(p.s) && '0' <= p.s[j] && p.s[j] <= '9' {
+			j++
+		}
+		if j > i+1 && (j == len(p.s) || p.s[j] == ' ') {
+			line, err := strconv.Atoi(p.s[i+1 : j])
+			if err != nil {
+				return err
+			}
+			e.File, e.Line = p.s[:i], line
+			p.s = p.s[j:]
+			return p.sep()
+		}
+	}
+	return errors.New("want file:line")
+}
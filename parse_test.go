@@ -0,0 +1,153 @@
+package elog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	cst := time.FixedZone("", 8*3600)
+	for _, tc := range []struct {
+		line  string
+		flags int
+		want  Entry
+	}{
+		{"2024/05/06 07:08:09 WARN main.go:12 disk almost full\n", LstdFlags | LUTC,
+			Entry{Time: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC), Level: WarnLevel, File: "main.go", Line: 12, Msg: "disk almost full"}},
+		{"Mon 2024-05-06T07:08:09.000123+08:00 ERROR /src/app/main.go:7 [api] boom k=v\r\n", Ldate | Lmicroseconds | Ldateiso | Lweekday | Llevel | Llongfile | Lmsgprefix,
+			Entry{Time: time.Date(2024, 5, 6, 7, 8, 9, 123000, cst), Level: ErrorLevel, File: "/src/app/main.go", Line: 7, Prefix: "[api]", Msg: "boom k=v"}},
+		{"07:08:09        INFO  two spaces\n", Ltime | Lfixedtime | Llevel | LUTC,
+			Entry{Time: time.Date(1, 1, 1, 7, 8, 9, 0, time.UTC), Level: InfoLevel, Msg: " two spaces"}},
+		{"\x1b[0;30;43m WARN  \x1b[0m \x1b[1;33;40m  padded  \x1b[0m\n", Llevel | LlevelLabelColor | Lmsgcolor,
+			Entry{Level: WarnLevel, Msg: " padded "}},
+		{"\x1b[1;31;40mERROR x.go:1 line colored\x1b[0m\n", Llinecolor | Llevel | LlevelLabelColor | Lshortfile,
+			Entry{Level: ErrorLevel, File: "x.go", Line: 1, Msg: "line colored"}},
+		{"INFO\n", Llevel, Entry{Level: InfoLevel}},
+		{"\n", 0, Entry{}},
+	} {
+		got, err := ParseLine([]byte(tc.line), tc.flags)
+		if err != nil {
+			t.Errorf("%q: %v", tc.line, err)
+			continue
+		}
+		if !got.Time.Equal(tc.want.Time) || got.Time.Location().String() != tc.want.Time.Location().String() {
+			t.Errorf("%q: time %v, want %v", tc.line, got.Time, tc.want.Time)
+		}
+		got.Time, tc.want.Time = time.Time{}, time.Time{}
+		if got.Level != tc.want.Level || got.File != tc.want.File || got.Line != tc.want.Line || got.Prefix != tc.want.Prefix || got.Msg != tc.want.Msg {
+			t.Errorf("%q:\n got  %+v\n want %+v", tc.line, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		line  string
+		flags int
+	}{
+		{"2024-05-06 07:08:09 hello", LstdFlags},
+		{"2024/05/06 hello", Ldate | Ltime},
+		{"VERBOSE hello", Llevel},
+		{"main.go hello", Lshortfile},
+		{"Xyz 2024/05/06 hello", Ldate | Lweekday},
+	} {
+		if _, err := ParseLine([]byte(tc.line), tc.flags); err == nil || !strings.HasPrefix(err.Error(), "elog: cannot parse ") {
+			t.Errorf("%q: want parse error, got %v", tc.line, err)
+		}
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	skipCompiledOut(t, DebugLevel)
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b), OFlag(Ltime|Llevel|Lshortfile))
+	l.Info("first")
+	l.Warn("multi\nline\nmessage")
+	l.Debugf("%d", 3)
+
+	entries, err := ParseReader(&b, Ltime|Llevel|Lshortfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		level logLevel
+		line  int
+		msg   string
+	}{{InfoLevel, 64, "first"}, {WarnLevel, 65, "multi\nline\nmessage"}, {DebugLevel, 66, "3"}}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries: %+v", len(entries), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Level != w.level || e.File != "parse_test.go" || e.Line != w.line || e.Msg != w.msg || e.Time.IsZero() {
+			t.Errorf("entry %d: %+v", i, e)
+		}
+	}
+
+	if _, err := ParseReader(strings.NewReader("not a log line\n"), Llevel); err == nil || !strings.HasPrefix(err.Error(), "elog: line 1: ") {
+		t.Errorf("want error for the first line, got %v", err)
+	}
+}
+
+// FuzzParseLine 用各种 flag 组合输出任意的消息和前缀，ParseLine 解析的结果必须与写入的内容一致：
+//
+//	go test -fuzz FuzzParseLine -run xxx .
+func FuzzParseLine(f *testing.F) {
+	f.Add("hello", "[api]", uint16(LstdFlags), int64(1714950000123456789), uint8(3))
+	f.Add("  lead and trail  ", "p", uint16(Ldate|Lmicroseconds|Ldateiso|Lweekday|Llevel|Llongfile|Lmsgprefix), int64(0), uint8(4))
+	f.Add("", "x", uint16(Ltime|Lfixedtime|Llevel|LlevelLabelColor|Lmsgcolor), int64(-1e18), uint8(2))
+	f.Add("k=v", "-", uint16(Llinecolor|Llevel|Lmsgprefix|LnameColor|Lmsgcolor), int64(42), uint8(7))
+	f.Fuzz(func(t *testing.T, msg, prefix string, flags uint16, nsec int64, level uint8) {
+		if strings.ContainsAny(msg, "\r\n\x1b") || prefix == "" || strings.ContainsAny(prefix, " \r\n\x1b") {
+			t.Skip("multi-line messages and prefixes with spaces cannot be parsed from a single line")
+		}
+		lvl := logLevel(level%uint8(AuditLevel/levelStep)+1) * levelStep // Trace 到 Audit 之间的内置等级
+		flag := NormalizeFlags(int(flags)&(Llinecolor*2-1) | LUTC)
+		ts := time.Unix(0, nsec).UTC()
+
+		var b bytes.Buffer
+		l := New(TraceLevel, OOutput(&b), OFlag(flag), OPrefix(prefix), ONow(func() time.Time { return ts }))
+		if lvl == AuditLevel {
+			l.Audit(msg)
+		} else {
+			l.Out(1, lvl, msg) // Out 的调用处
+		}
+		e, err := ParseLine(b.Bytes(), flag|Llevel*boolInt(lvl == AuditLevel))
+		if err != nil {
+			t.Fatalf("%q: %v", b.String(), err)
+		}
+		// 写入时消息末尾的一个空格被换行符替换（带颜色的消息除外），见 setNewLine
+		want := msg
+		if flag&(Lmsgcolor|Llinecolor) != Lmsgcolor || msg == "" {
+			want = strings.TrimSuffix(msg, " ")
+		}
+		if e.Msg != want {
+			t.Errorf("%q: msg %q, want %q", b.String(), e.Msg, want)
+		}
+		if flag&Llevel != 0 && e.Level != lvl {
+			t.Errorf("%q: level %v, want %v", b.String(), e.Level, lvl)
+		}
+		if flag&Lmsgprefix != 0 && e.Prefix != prefix {
+			t.Errorf("%q: prefix %q, want %q", b.String(), e.Prefix, prefix)
+		}
+		if flag&(Lshortfile|Llongfile) != 0 && (!strings.HasSuffix(e.File, "parse_test.go") || e.Line == 0) {
+			t.Errorf("%q: caller %s:%d", b.String(), e.File, e.Line)
+		}
+		if flag&(Ldate|Ltime) == Ldate|Ltime {
+			want := ts.Truncate(time.Second)
+			if flag&Lmicroseconds != 0 {
+				want = ts.Truncate(time.Microsecond)
+			}
+			if !e.Time.Equal(want) {
+				t.Errorf("%q: time %v, want %v", b.String(), e.Time, want)
+			}
+		}
+	})
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package elog
+
+import "fmt"
+
+// LogPrinter 以固定的等级输出日志，实现了许多库定义的最小 logger 接口，见 Printer
+type LogPrinter struct {
+	l     *Log
+	level logLevel
+}
+
+// Printer 把 l 适配为许多库自己定义的最小 logger 接口，如 interface{ Printf(string, ...any) }、
+// interface{ Println(...any) } 以及同时带有 Print、Printf、Println 的接口，不必为每个库单独写一层包装：
+//
+//	client.Logger = elog.Printer(l, elog.DebugLevel)                                    // retryablehttp
+//	sarama.Logger = elog.Printer(l.Extend(elog.OPrefix("[sarama]")), elog.InfoLevel)
+//
+// 所有方法都以 level 等级输出，文件路径指向库中调用这些方法的位置。Print 与标准库的 log.Print 一样按 fmt.Sprint 拼接参数，
+// Println 按 fmt.Sprintln 拼接，Printf 与 Infof 等方法一样接受 %w。level 为 FatalLevel 或 PanicLevel 时只输出日志，
+// 不会退出进程或 panic；为 AuditLevel 时与 Audit 一样总是输出；被 elog_notrace、elog_nodebug 构建标签去掉的等级不输出。
+// 与 ReadOnly 一样，无法通过它修改 l 的配置。
+func Printer(l *Log, level logLevel) LogPrinter {
+	return LogPrinter{l: l, level: level}
+}
+
+// enabled 报告以 p.level 等级输出的日志是否会被输出，在格式化消息之前判断
+func (p LogPrinter) enabled() bool {
+	if p.level == AuditLevel {
+		return p.l != nil
+	}
+	return p.l.enabled(p.level)
+}
+
+func (p LogPrinter) Print(v ...any) {
+	if p.enabled() {
+		p.l.out(defaultCallDepth, p.level, fmt.Sprint(renderErrors(v)...), nil)
+	}
+}
+
+func (p LogPrinter) Printf(format string, v ...any) {
+	if p.enabled() {
+		msg, fs := sprintf(format, v)
+		p.l.out(defaultCallDepth, p.level, msg, fs)
+	}
+}
+
+func (p LogPrinter) Println(v ...any) {
+	if p.enabled() {
+		p.l.out(defaultCallDepth, p.level, fmt.Sprintln(renderErrors(v)...), nil)
+	}
+}
@@ -0,0 +1,93 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 各个库定义的最小 logger 接口
+type (
+	printfLogger  interface{ Printf(string, ...any) }
+	printlnLogger interface{ Println(...any) }
+	stdLikeLogger interface {
+		Print(...any)
+		Printf(string, ...any)
+		Println(...any)
+	}
+)
+
+var (
+	_ printfLogger  = LogPrinter{}
+	_ printlnLogger = LogPrinter{}
+	_ stdLikeLogger = LogPrinter{}
+)
+
+func TestPrinterCaller(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lshortfile|Llevel))
+	var p stdLikeLogger = Printer(l, WarnLevel)
+
+	next := func() int { _, _, line, _ := runtime.Caller(1); return line + 1 }
+	check := func(name string, want int) {
+		t.Helper()
+		if got, w := b.String(), "WARN printer_test.go:"+strconv.Itoa(want)+" "+name+"\n"; got != w {
+			t.Errorf("%s:\n got:  %q\n want: %q", name, got, w)
+		}
+		b.Reset()
+	}
+
+	line := next()
+	p.Print("print", 1)
+	check("print1", line)
+
+	line = next()
+	p.Printf("printf %d", 2)
+	check("printf 2", line)
+
+	line = next()
+	p.Println("println", 3)
+	check("println 3", line)
+}
+
+func TestPrinterLevel(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	exits := 0
+	osExit = func(int) { exits++ }
+
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Llevel))
+	Printer(l, DebugLevel).Printf("filtered")
+	Printer(l, InfoLevel).Printf("info")
+	Printer(l, ErrorLevel).Println("error")
+	Printer(l, FatalLevel).Print("fatal")
+	Printer(l, AuditLevel).Print("audit")
+	l.SetLevel(Discard + 1)
+	Printer(l, TraceLevel).Print("trace")
+	want := "INFO info\nERROR error\nFATAL fatal\nAUDIT audit\n"
+	if traceCompiled {
+		want += "TRACE trace\n"
+	}
+	if got := b.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if exits != 0 {
+		t.Errorf("FatalLevel printer exited %d times", exits)
+	}
+
+	var nilLog *Log
+	Printer(nilLog, AuditLevel).Print("nil logger")
+}
+
+func TestPrinterWrap(t *testing.T) {
+	var js bytes.Buffer
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}))
+	l.AddOutput(&js, WithEncoder(JSONEncoder))
+	Printer(l, ErrorLevel).Printf("retrying: %w", errors.New("timeout"))
+	if got := js.String(); !strings.Contains(got, `"msg":"retrying: timeout","error":"timeout"`) {
+		t.Errorf("got %s", got)
+	}
+}
@@ -0,0 +1,130 @@
+package elog
+
+// ReadOnly 返回 l 的只读视图，只能通过它输出日志，不能修改等级、flag、输出等配置，
+// 应用可以把它交给第三方库，而不必担心库调用 SetLevel、SetFlag 等方法改变应用的 logger：
+//
+//	client := thirdparty.New(thirdparty.WithLogger(l.ReadOnly()))
+//
+// 视图与 l 共用同一个实例，l 之后的配置修改对视图同样生效。视图包装而不是嵌入 l，
+// 无法通过类型断言取回 *Log。
+func (l *Log) ReadOnly() Logger {
+	return readOnly{l: l}
+}
+
+type readOnly struct {
+	l *Log
+}
+
+var (
+	_ Logger       = readOnly{}
+	_ LevelEnabler = readOnly{}
+)
+
+// Enabled 与所属 logger 的 Enabled 相同
+func (r readOnly) Enabled(level logLevel) bool { return r.l.Enabled(level) }
+
+// enabled 报告 level 等级的日志是否会被输出，各方法在格式化消息之前判断
+func (r readOnly) enabled(level logLevel) bool {
+	return r.l.enabled(level)
+}
+
+// out 必须被 Logger 接口的方法直接调用，以保证文件路径指向调用处。调用方需先通过 enabled 判断
+func (r readOnly) out(level logLevel, msg string, extra ...Field) {
+	r.l.out(defaultCallDepth+1, level, msg, extra)
+}
+
+func (r readOnly) Fatal(v ...any) {
+	if r.enabled(FatalLevel) {
+		r.out(FatalLevel, r.l.sprint(v))
+	}
+	if r.l.atLeast(FatalLevel) {
+		r.l.beforeExit()
+		osExit(r.l.exitCode(v))
+	}
+}
+func (r readOnly) Panic(v ...any) {
+	if r.l.atLeast(PanicLevel) {
+		s := r.l.sprint(v)
+		if r.enabled(PanicLevel) {
+			r.out(PanicLevel, s)
+		}
+		r.l.beforePanic()
+		panic(r.l.panicValue(defaultCallDepth, s, v))
+	}
+}
+func (r readOnly) Error(v ...any) {
+	if r.enabled(ErrorLevel) {
+		r.out(ErrorLevel, r.l.sprint(v))
+	}
+}
+func (r readOnly) Warn(v ...any) {
+	if r.enabled(WarnLevel) {
+		r.out(WarnLevel, r.l.sprint(v))
+	}
+}
+func (r readOnly) Info(v ...any) {
+	if r.enabled(InfoLevel) {
+		r.out(InfoLevel, r.l.sprint(v))
+	}
+}
+func (r readOnly) Debug(v ...any) {
+	if r.enabled(DebugLevel) {
+		r.out(DebugLevel, r.l.sprint(v))
+	}
+}
+func (r readOnly) Trace(v ...any) {
+	if r.enabled(TraceLevel) {
+		r.out(TraceLevel, r.l.sprint(v))
+	}
+}
+
+func (r readOnly) Fatalf(format string, v ...any) {
+	if r.enabled(FatalLevel) {
+		msg, fs := sprintf(format, v)
+		r.out(FatalLevel, msg, fs...)
+	}
+	if r.l.atLeast(FatalLevel) {
+		r.l.beforeExit()
+		osExit(r.l.exitCode(v))
+	}
+}
+func (r readOnly) Panicf(format string, v ...any) {
+	if r.l.atLeast(PanicLevel) {
+		s, fs := sprintf(format, v)
+		if r.enabled(PanicLevel) {
+			r.out(PanicLevel, s, fs...)
+		}
+		r.l.beforePanic()
+		panic(r.l.panicValue(defaultCallDepth, s, v))
+	}
+}
+func (r readOnly) Errorf(format string, v ...any) {
+	if r.enabled(ErrorLevel) {
+		msg, fs := sprintf(format, v)
+		r.out(ErrorLevel, msg, fs...)
+	}
+}
+func (r readOnly) Warnf(format string, v ...any) {
+	if r.enabled(WarnLevel) {
+		msg, fs := sprintf(format, v)
+		r.out(WarnLevel, msg, fs...)
+	}
+}
+func (r readOnly) Infof(format string, v ...any) {
+	if r.enabled(InfoLevel) {
+		msg, fs := sprintf(format, v)
+		r.out(InfoLevel, msg, fs...)
+	}
+}
+func (r readOnly) Debugf(format string, v ...any) {
+	if r.enabled(DebugLevel) {
+		msg, fs := sprintf(format, v)
+		r.out(DebugLevel, msg, fs...)
+	}
+}
+func (r readOnly) Tracef(format string, v ...any) {
+	if r.enabled(TraceLevel) {
+		msg, fs := sprintf(format, v)
+		r.out(TraceLevel, msg, fs...)
+	}
+}
@@ -0,0 +1,80 @@
+package elog
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	skipCompiledOut(t, DebugLevel)
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lshortfile))
+	ro := l.ReadOnly()
+	ro.Info("hello")
+	ro.Warnf("n=%d", 1)
+	ro.Debug("filtered")
+	l.SetLevel(DebugLevel) // 视图与 l 共用同一个实例
+	ro.Debug("debug")
+
+	want := "readonly_test.go:14 hello\n" +
+		"readonly_test.go:15 n=1\n" +
+		"readonly_test.go:18 debug\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+	if !Enabled(ro, DebugLevel) || Enabled(ro, TraceLevel) {
+		t.Error("Enabled should follow the underlying logger")
+	}
+
+	b.Reset()
+	if v := recovered(func() { ro.Panicf("boom %d", 2) }); v == nil {
+		t.Error("Panicf should panic")
+	}
+	if got, want := b.String(), "readonly_test.go:31 boom 2\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadOnlyNoMutation(t *testing.T) {
+	ro := New(InfoLevel).ReadOnly()
+	if _, ok := ro.(*Log); ok {
+		t.Fatal("view should not be a *Log")
+	}
+	if _, ok := ro.(interface{ SetLevel(logLevel) *Log }); ok {
+		t.Error("view should not expose SetLevel")
+	}
+	if _, ok := ro.(interface{ SetFlag(int) *Log }); ok {
+		t.Error("view should not expose SetFlag")
+	}
+	// 包装而不是嵌入：反射也拿不到导出的 *Log 字段
+	typ := reflect.TypeOf(ro)
+	for i := 0; i < typ.NumField(); i++ {
+		if f := typ.Field(i); f.Anonymous || f.IsExported() {
+			t.Errorf("view should not embed or export %s", f.Name)
+		}
+	}
+}
+
+// 被等级过滤的日志不格式化参数
+func TestReadOnlyLazy(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	osExit = func(int) {}
+
+	var calls int
+	arg := countingStringer{&calls}
+	var b bytes.Buffer
+	ro := New(WarnLevel, OOutput(&b), OLevelRange(WarnLevel, ErrorLevel)).ReadOnly()
+	ro.Info(arg)
+	ro.Infof("%s", arg)
+	ro.Debug(arg)
+	ro.Tracef("%s", arg)
+	ro.Fatal(arg) // 超出等级范围，只退出不输出
+	if calls != 0 || b.Len() != 0 {
+		t.Errorf("filtered entries formatted %d times, wrote %q", calls, b.String())
+	}
+	ro.Warn(arg)
+	if calls != 1 || b.String() != "expensive\n" {
+		t.Errorf("calls %d, got %q", calls, b.String())
+	}
+}
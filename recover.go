@@ -0,0 +1,146 @@
+package elog
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// RecoverOption 用于配置 RecoverAndLog 和 RecoverMiddleware 的行为
+type RecoverOption func(c *recoverConfig)
+
+type recoverConfig struct {
+	repanic bool // 记录日志后是否重新抛出 panic
+}
+
+// RRepanic 设置记录日志后重新抛出原 panic 值，默认吞掉 panic
+func RRepanic(repanic bool) RecoverOption {
+	return func(c *recoverConfig) {
+		c.repanic = repanic
+	}
+}
+
+// RecoverAndLog 必须直接以 defer 的方式调用：
+//
+//	defer elog.RecoverAndLog(l)
+//
+// 它会 recover 当前 goroutine 的 panic，并以 PanicLevel 记录 panic 值、goroutine id 以及
+// 去掉了 elog 自身帧的调用栈，随后根据 RRepanic 选项决定是重新抛出还是吞掉 panic。
+// l 为 nil 时使用默认 logger。
+func RecoverAndLog(l *Log, options ...RecoverOption) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	c := newRecoverConfig(options)
+	logPanic(l, v)
+	if c.repanic {
+		panic(v)
+	}
+}
+
+// RecoverMiddleware 返回一个 http.Handler，在 next 发生 panic 时记录日志。
+// 吞掉 panic 时向客户端返回 500；http.ErrAbortHandler 总是原样抛出，交给 net/http 处理。
+func RecoverMiddleware(l *Log, next http.Handler, options ...RecoverOption) http.Handler {
+	c := newRecoverConfig(options)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			logPanic(l, v)
+			if c.repanic {
+				panic(v)
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newRecoverConfig(options []RecoverOption) *recoverConfig {
+	c := new(recoverConfig)
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// logPanic 只能被 recover 所在的 defer 函数直接调用，否则计算出的调用深度会出错
+func logPanic(l *Log, v any) {
+	if l == nil {
+		l = Default()
+	}
+	if !l.enabled(PanicLevel) {
+		return // 不会输出时不必获取调用栈
+	}
+	stack, depth := panicStack()
+	if pe, ok := v.(*PanicError); ok && pe.Stack != "" {
+		stack = pe.Stack // 通过 Panic 系列方法抛出时，调用 Panic 处的调用栈更有用
+	}
+	msg := fmt.Sprintf("panic recovered: %s [goroutine %d]\n%s", panicDetail(v), goid(), stack)
+	// depth 是 panic 发生处相对于 logPanic 的帧数，Out 自身还要再加一层
+	l.Out(depth+1, PanicLevel, msg)
+}
+
+// panicStack 返回从 panic 发生处开始的调用栈，以及 panic 发生处相对于 panicStack 调用者的帧数。
+// recover 相关的帧、runtime.gopanic 及紧随其后的 runtime 帧（如 runtime.panicmem、runtime.sigpanic）都会被去掉。
+func panicStack() (string, int) {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs) // 跳过 runtime.Callers 和 panicStack 本身
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var (
+		sb       strings.Builder
+		depth    = -1
+		panicked bool
+		found    bool
+	)
+	for {
+		frame, more := frames.Next()
+		if !found {
+			depth++
+			if frame.Function == "runtime.gopanic" {
+				panicked = true
+			} else if panicked && !strings.HasPrefix(frame.Function, "runtime.") {
+				found = true
+			}
+		}
+		if found {
+			sb.WriteString(frame.Function)
+			sb.WriteString("()\n\t")
+			sb.WriteString(frame.File)
+			sb.WriteByte(':')
+			sb.WriteString(strconv.Itoa(frame.Line))
+			sb.WriteByte('\n')
+		}
+		if !more {
+			break
+		}
+	}
+	if !found {
+		return "", 0
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), depth
+}
+
+// goid 从 runtime.Stack 的首行 "goroutine 18 [running]:" 中解析出当前 goroutine 的 id
+func goid() int {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = b[len("goroutine "):]
+	id := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + int(c-'0')
+	}
+	return id
+}
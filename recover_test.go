@@ -0,0 +1,106 @@
+package elog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func panicky() {
+	panic("boom")
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lshortfile|Llevel))
+	func() {
+		defer RecoverAndLog(l)
+		panicky()
+	}()
+
+	got := b.String()
+	if !strings.HasPrefix(got, "PANIC recover_test.go:13 panic recovered: boom [goroutine ") {
+		t.Errorf("unexpected header, got %q", got)
+	}
+	if matched, _ := regexp.MatchString(`\[goroutine \d+\]\n`, got); !matched {
+		t.Errorf("goroutine id not found in %q", got)
+	}
+	if !strings.Contains(got, "elog.panicky()\n\t") {
+		t.Errorf("stack should start at the panicking function, got %q", got)
+	}
+	if strings.Contains(got, "elog.RecoverAndLog(") || strings.Contains(got, "runtime.gopanic") {
+		t.Errorf("stack should not contain recovery frames, got %q", got)
+	}
+}
+
+func TestRecoverAndLogRepanic(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("expected to re-panic with %q, got %v", "boom", v)
+		}
+		if !strings.Contains(b.String(), "panic recovered: boom") {
+			t.Errorf("panic should be logged before re-panicking, got %q", b.String())
+		}
+	}()
+	defer RecoverAndLog(l, RRepanic(true))
+	panicky()
+}
+
+func TestRecoverAndLogRuntimeError(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lshortfile))
+	func() {
+		defer RecoverAndLog(l)
+		var m map[string]int
+		m["a"] = 1
+	}()
+	got := b.String()
+	if !strings.HasPrefix(got, "recover_test.go:60 panic recovered: assignment to entry in nil map") {
+		t.Errorf("unexpected output %q", got)
+	}
+	if strings.Contains(got, "runtime.panic") || strings.Contains(got, "runtime.sigpanic") {
+		t.Errorf("stack should not contain runtime frames, got %q", got)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	h := RecoverMiddleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panicky()
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", rec.Code)
+	}
+	if !strings.Contains(b.String(), "panic recovered: boom") {
+		t.Errorf("panic not logged, got %q", b.String())
+	}
+}
+
+// 静默或等级范围不包含 PanicLevel 时不输出，也不必获取调用栈
+func TestRecoverAndLogDisabled(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OLevelRange(InfoLevel, ErrorLevel))
+	recovered := func() {
+		defer RecoverAndLog(l)
+		panicky()
+	}
+	recovered()
+	l.SetLevelRange(InfoLevel, FatalLevel).Mute()
+	recovered()
+	if b.Len() != 0 {
+		t.Errorf("got %q", b.String())
+	}
+	l.Unmute()
+	recovered()
+	if !strings.Contains(b.String(), "panic recovered: boom") {
+		t.Errorf("got %q", b.String())
+	}
+}
@@ -0,0 +1,85 @@
+package elog
+
+import (
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// reentryWait 是疑似重入时等待锁的最长时间。当前 goroutine 正在写入的可能是另一个 logger（如 A 的 Writer 通过 B 输出日志，
+// 而 B 恰好在别的 goroutine 中写入），这时锁会在 B 写完之后释放；超过这个时间仍然拿不到锁则视为重入
+const reentryWait = 100 * time.Millisecond
+
+// criticalFuncs 是持有 l.mu 写锁并调用 Writer、Encoder 等用户代码的函数的完整名称，见 inCritical。
+// 在 init 中计算，直接初始化会形成初始化循环
+var criticalFuncs [3]string
+
+func init() {
+	for i, fn := range [...]any{(*Log).write, (*Log).Raw, (*Log).plainOut} {
+		criticalFuncs[i] = runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	}
+}
+
+// rlock 获取 l.mu 的读锁。Writer、Encoder 或钩子在写入的临界区中又通过同一个 logger 输出日志时，当前 goroutine 已经持有写锁，
+// 直接加锁会永远阻塞；这时不加锁并返回 false，调用方应丢弃这条日志，见 reentered
+func (l *Log) rlock() bool {
+	return l.mu.TryRLock() || l.slowLock(l.mu.TryRLock, l.mu.RLock)
+}
+
+// lock 与 rlock 相同，获取的是写锁
+func (l *Log) lock() bool {
+	return l.mu.TryLock() || l.slowLock(l.mu.TryLock, l.mu.Lock)
+}
+
+// slowLock 在第一次尝试加锁失败后调用：不在写入的临界区中时以 block 阻塞地加锁，否则以 try 等待至多 reentryWait
+func (l *Log) slowLock(try func() bool, block func()) bool {
+	if atomic.LoadUint32(&l.writing) == 0 || !inCritical() {
+		block()
+		return true
+	}
+	for deadline := time.Now().Add(reentryWait); time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+		if try() {
+			return true
+		}
+	}
+	return false
+}
+
+// inWriter 报告当前 goroutine 是否正处于 l 的写入临界区中，这时 l.mu 由当前 goroutine 持有，
+// 在另一个 goroutine 中等待 l.mu 的操作（如 Panic 之前的 Flush）只能等到超时。l 为 nil 时返回 false
+func (l *Log) inWriter() bool {
+	return l != nil && atomic.LoadUint32(&l.writing) != 0 && inCritical()
+}
+
+// inCritical 报告当前 goroutine 的调用栈中是否有写入的临界区，只在拿不到锁时调用
+func inCritical() bool {
+	var pcs [64]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs[:])])
+	for {
+		f, more := frames.Next()
+		for _, name := range criticalFuncs {
+			if f.Function == name {
+				return true
+			}
+		}
+		if !more {
+			return false
+		}
+	}
+}
+
+// reentered 丢弃一条重入的日志并报告给诊断 logger。诊断 logger 自身重入时不再报告，避免无限递归
+func (l *Log) reentered(level logLevel, msg string) {
+	l.dropReentered(level.String()+" entry", msg)
+}
+
+// dropReentered 与 reentered 相同，what 描述被丢弃的内容，如 Plain 的输出
+func (l *Log) dropReentered(what, msg string) {
+	if l == Internal() {
+		return
+	}
+	internalf(WarnLevel, "elog: dropped %s logged from inside a writer of logger %q while it was writing: %q",
+		what, l.name, msg)
+}
@@ -0,0 +1,131 @@
+package elog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// loopWriter 在每次 Write 时通过 log 再输出一条日志，模拟把写入情况记录到同一个 logger 的 Writer
+type loopWriter struct {
+	buf bytes.Buffer
+	log func()
+}
+
+func (w *loopWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	w.log()
+	return len(p), nil
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// finish 在 timeout 内等待 f 返回，超时视为死锁
+func finish(t *testing.T, timeout time.Duration, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatal("deadlock: logging from inside a writer did not return")
+	}
+}
+
+func TestReentrantWriter(t *testing.T) {
+	diag := captureInternal(t)
+	w := new(loopWriter)
+	l := New(InfoLevel, OOutput(w), OName("app"))
+	w.log = func() { l.Info("from writer") }
+
+	finish(t, 5*time.Second, func() {
+		l.Info("outer")
+		l.Raw("raw")
+		l.Ctx(context.Background()).Info("ctx")
+	})
+	if got, want := w.buf.String(), "outer\nraw\nctx\n"; got != want {
+		t.Errorf("output: got %q, want %q", got, want)
+	}
+	if got := diag.String(); strings.Count(got, `dropped INFO entry logged from inside a writer of logger "app"`) != 3 ||
+		!strings.Contains(got, `"from writer\n"`) {
+		t.Errorf("diagnostics: %q", got)
+	}
+
+	// 临界区之外的日志照常输出
+	w.log = func() {}
+	l.Info("after")
+	if !strings.HasSuffix(w.buf.String(), "after\n") {
+		t.Errorf("after: got %q", w.buf.String())
+	}
+}
+
+// 一个 logger 的 Writer 通过另一个 logger 输出日志不是重入，即使那个 logger 正在别的 goroutine 中写入
+func TestReentrantOtherLogger(t *testing.T) {
+	captureInternal(t)
+	var inner syncBuffer
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var once sync.Once
+	b := New(InfoLevel, OOutput(writerFunc(func(p []byte) (int, error) {
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+		return inner.Write(p)
+	})))
+	w := new(loopWriter)
+	a := New(InfoLevel, OOutput(w))
+	w.log = func() { b.Info("from a") }
+
+	go b.Info("slow")
+	<-started
+	time.AfterFunc(10*time.Millisecond, func() { close(release) })
+	finish(t, 5*time.Second, func() { a.Info("outer") })
+	if got := string(inner.Bytes()); !strings.Contains(got, "slow\n") || !strings.Contains(got, "from a\n") {
+		t.Errorf("inner: got %q", got)
+	}
+}
+
+// 在 Writer 中通过 Info 以外的入口重入同一个 logger 同样不会死锁，见 rlock
+func TestReentrantEntryPoints(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	osExit = func(int) {}
+	info := func(l *Log) { l.Info("outer") }
+	for _, tt := range []struct {
+		name         string
+		outer, inner func(l *Log)
+		want         string
+	}{
+		{"T", info, func(l *Log) { l.T("event", 1) }, "outer\n"},
+		{"Panic", info, func(l *Log) { recovered(func() { l.Panic("p") }) }, "outer\n"},
+		{"Panicf", info, func(l *Log) { recovered(func() { l.Panicf("p %d", 1) }) }, "outer\n"},
+		{"Fatal", info, func(l *Log) { l.Fatal("f") }, "outer\n"},
+		{"Plain", info, func(l *Log) { l.Plain("p") }, "outer\n"},
+		{"Plainf", info, func(l *Log) { l.Plainf("p %d", 1) }, "outer\n"},
+		{"PlainWriter", func(l *Log) { l.Plain("outer") }, info, "outer\n"},
+		{"Banner", info, func(l *Log) { l.Banner() }, "outer\n"},
+		{"Throttle", info, func(l *Log) { l.Throttle(time.Second).Info("t") }, "outer\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			diag := captureInternal(t)
+			w := new(loopWriter)
+			l := New(InfoLevel, OOutput(w), OStdout(w), OFlag(0), OPanicError())
+			w.log = func() { tt.inner(l) }
+			finish(t, 2*time.Second, func() { tt.outer(l) })
+			if got := w.buf.String(); got != tt.want {
+				t.Errorf("output: got %q, want %q", got, tt.want)
+			}
+			if !strings.Contains(diag.String(), "logged from inside a writer") {
+				t.Errorf("diagnostics: %q", diag.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package elog
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// reportQueueSize 是 Reporter 异步队列的容量，队列满时新的日志条目会被丢弃
+const reportQueueSize = 256
+
+// Reporter 用于把高等级的日志转发给外部的错误追踪系统（如 Sentry），elog 不依赖任何具体的 SDK。
+// Report 在单独的 goroutine 中被依次调用，缓慢的 Reporter 不会阻塞日志输出。
+type Reporter interface {
+	Report(e Entry)
+}
+
+type reporter struct {
+	dropped uint64 // 需要 64 位对齐，必须放在第一个字段
+
+	l       *Log
+	r       Reporter
+	min     logLevel
+	queue   chan reportItem
+	mu      sync.RWMutex // 保护 stopped，避免向已关闭的 queue 发送
+	stopped bool
+}
+
+type reportItem struct {
+	entry Entry
+	flush chan struct{} // 不为空时表示一次 flush 请求，处理到这里时关闭它
+}
+
+// SetReporter 设置 Reporter，等级不低于 min 的日志（审计日志除外）会连同调用栈异步地交给 r。
+// 替换或移除（r 为 nil）旧的 Reporter 前会先等待其队列中的日志处理完毕。
+// Fatal 系列方法在退出前会同步地等待队列处理完毕。
+func (l *Log) SetReporter(r Reporter, min logLevel) *Log {
+	var rep *reporter
+	if r != nil {
+		rep = &reporter{l: l, r: r, min: min, queue: make(chan reportItem, reportQueueSize)}
+		go rep.run()
+	}
+	l.mu.Lock()
+	old := l.reporter
+	l.reporter = rep
+	l.syncCloser()
+	l.mu.Unlock()
+	if old != nil {
+		old.stop()
+	}
+	return l
+}
+
+// ReportDropped 返回因 Reporter 队列已满而被丢弃的日志条数
+func (l *Log) ReportDropped() uint64 {
+	l.mu.RLock()
+	rep := l.reporter
+	l.mu.RUnlock()
+	if rep == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&rep.dropped)
+}
+
+func (rep *reporter) run() {
+	for item := range rep.queue {
+		if item.flush != nil {
+			close(item.flush)
+			continue
+		}
+		if err := callReporter(rep.r, item.entry); err != nil {
+			rep.l.callbackFailed(err)
+		}
+	}
+}
+
+// report 将 e 放入队列，队列已满时丢弃
+func (rep *reporter) report(e Entry) {
+	rep.mu.RLock()
+	defer rep.mu.RUnlock()
+	if rep.stopped {
+		return
+	}
+	select {
+	case rep.queue <- reportItem{entry: e}:
+	default:
+		atomic.AddUint64(&rep.dropped, 1)
+	}
+}
+
+// flush 阻塞直到之前入队的日志都已交给 Reporter
+func (rep *reporter) flush() {
+	rep.mu.RLock()
+	if rep.stopped {
+		rep.mu.RUnlock()
+		return
+	}
+	done := make(chan struct{})
+	rep.queue <- reportItem{flush: done}
+	rep.mu.RUnlock()
+	<-done
+}
+
+// stop 处理完队列中的日志后停止后台 goroutine，之后的日志不再交给 Reporter
+func (rep *reporter) stop() {
+	rep.flush()
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	if !rep.stopped {
+		rep.stopped = true
+		close(rep.queue)
+	}
+}
+
+// callerStack 返回从 skip 开始的调用栈，skip 的含义与 runtime.Caller 相同
+func callerStack(skip int) string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var sb strings.Builder
+	for {
+		frame, more := frames.Next()
+		sb.WriteString(frame.Function)
+		sb.WriteString("()\n\t")
+		sb.WriteString(frame.File)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,122 @@
+package elog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// httpReporter 是一个把日志条目以 JSON 发送给错误追踪服务的 Reporter 示例
+type httpReporter struct {
+	url string
+}
+
+func (r httpReporter) Report(e Entry) {
+	body, _ := json.Marshal(map[string]any{
+		"level":   strings.TrimSpace(levelMap[e.Level].levelLabel),
+		"message": e.Msg,
+		"file":    e.File,
+		"line":    e.Line,
+		"stack":   e.Stack,
+	})
+	resp, err := http.Post(r.url, "application/json", bytes.NewReader(body))
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+func TestReporter(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]any
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &m)
+		mu.Lock()
+		received = append(received, m)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lshortfile)).SetReporter(httpReporter{url: srv.URL}, ErrorLevel)
+	l.Warn("not reported")
+	l.Error("database is down")
+	l.SetReporter(nil, ErrorLevel) // 移除前会等待队列处理完毕
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("expected 1 report, got %d: %v", len(received), received)
+	}
+	got := received[0]
+	if got["message"] != "database is down" || got["level"] != "ERROR" || got["file"] == "" || got["line"] != float64(52) {
+		t.Errorf("unexpected report %v", got)
+	}
+	if stack, _ := got["stack"].(string); !strings.HasPrefix(stack, "github.com/TCP404/elog.TestReporter()\n\t") {
+		t.Errorf("stack should start at the caller, got %q", stack)
+	}
+}
+
+type slowReporter struct {
+	mu      sync.Mutex
+	delay   time.Duration
+	entries []string
+}
+
+func (r *slowReporter) Report(e Entry) {
+	r.mu.Lock()
+	delay := r.delay
+	r.mu.Unlock()
+	time.Sleep(delay)
+	r.mu.Lock()
+	r.entries = append(r.entries, e.Msg)
+	r.mu.Unlock()
+}
+
+func TestReporterDoesNotBlock(t *testing.T) {
+	var b bytes.Buffer
+	r := &slowReporter{delay: 100 * time.Millisecond}
+	l := New(InfoLevel, OOutput(&b)).SetReporter(r, ErrorLevel)
+	defer func() {
+		// 加快处理剩余的队列，避免之后的测试中 Fatal 退出前 Flush 所有 logger 时等待它
+		r.mu.Lock()
+		r.delay = 0
+		r.mu.Unlock()
+		l.Close()
+	}()
+	start := time.Now()
+	for i := 0; i < reportQueueSize+10; i++ {
+		l.Error("burst")
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("a slow reporter should not stall logging, took %v", d)
+	}
+	if l.ReportDropped() == 0 {
+		t.Error("entries beyond the queue capacity should be dropped")
+	}
+}
+
+func TestReporterFlushedBeforeFatalExit(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	var b bytes.Buffer
+	r := &slowReporter{delay: 10 * time.Millisecond}
+	l := New(InfoLevel, OOutput(&b)).SetReporter(r, ErrorLevel)
+	osExit = func(int) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if len(r.entries) != 2 || r.entries[1] != "fatal" {
+			t.Errorf("reporter queue should be flushed before exit, got %q", r.entries)
+		}
+	}
+	l.Error("error")
+	l.Fatal("fatal")
+}
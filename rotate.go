@@ -0,0 +1,290 @@
+package elog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	defaultFileMode = 0644
+	defaultDirMode  = 0755
+)
+
+// ErrFileClosed 在 RotatingFile 关闭后由 Write 返回
+var ErrFileClosed = errors.New("elog: rotating file is closed")
+
+// RotatingFile 是按大小轮转的日志文件，当前文件写满 MaxSize 后依次重命名为 path.1、path.2……，
+// 超过 MaxBackups 的旧文件会被删除。它实现了 io.WriteCloser 和 Flusher，可以直接作为 logger 的输出。
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	file       *os.File
+	size       int64
+	maxSize    int64
+	maxBackups int
+	truncate   bool
+	mode       os.FileMode
+	dirMode    os.FileMode
+	createDirs bool
+	manual     bool // 是否由 GzipWriter 在两条日志之间轮转，此时 Write 不再自行轮转
+	closed     bool // 是否已经 Close。file 为 nil 而 closed 为 false 表示上一次轮转失败
+}
+
+// osRename 用于轮转时重命名文件，测试中替换为会失败的实现
+var osRename = os.Rename
+
+var (
+	_ io.WriteCloser = &RotatingFile{}
+	_ Flusher        = &RotatingFile{}
+)
+
+// FileOption 用于配置 NewRotatingFile 创建的 RotatingFile
+type FileOption func(f *RotatingFile)
+
+// FileMaxSize 设置单个文件的大小上限，单位为字节，n <= 0 时不轮转
+func FileMaxSize(n int64) FileOption {
+	return func(f *RotatingFile) {
+		f.maxSize = n
+	}
+}
+
+// FileMaxBackups 设置保留的旧文件个数，n <= 0 时保留全部
+func FileMaxBackups(n int) FileOption {
+	return func(f *RotatingFile) {
+		f.maxBackups = n
+	}
+}
+
+// FileTruncate 设置启动时是否清空已存在的文件，默认追加到文件末尾
+func FileTruncate(truncate bool) FileOption {
+	return func(f *RotatingFile) {
+		f.truncate = truncate
+	}
+}
+
+// FileMode 设置日志文件的权限，默认为 0644，记录敏感信息时可以使用 0600。
+// 权限不受 umask 影响，打开已存在的文件时也会被修改为该权限。
+func FileMode(mode os.FileMode) FileOption {
+	return func(f *RotatingFile) {
+		f.mode = mode.Perm()
+	}
+}
+
+// FileDirMode 设置自动创建的父目录的权限，默认为 0755，已存在的目录不会被修改
+func FileDirMode(mode os.FileMode) FileOption {
+	return func(f *RotatingFile) {
+		f.dirMode = mode.Perm()
+	}
+}
+
+// FileCreateDirs 设置是否自动创建不存在的父目录，默认创建
+func FileCreateDirs(create bool) FileOption {
+	return func(f *RotatingFile) {
+		f.createDirs = create
+	}
+}
+
+// NewRotatingFile 打开 path 并返回按大小轮转的 RotatingFile，无法创建目录或打开文件时返回错误：
+//
+//	f, err := elog.NewRotatingFile("logs/app.log", elog.FileMaxSize(100<<20), elog.FileMode(0600))
+//	if err != nil {
+//		return err
+//	}
+//	l := elog.New(elog.InfoLevel, elog.OOutput(f))
+func NewRotatingFile(path string, options ...FileOption) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		mode:       defaultFileMode,
+		dirMode:    defaultDirMode,
+		createDirs: true,
+	}
+	for _, opt := range options {
+		opt(f)
+	}
+	if f.createDirs {
+		if err := f.mkdir(); err != nil {
+			return nil, err
+		}
+	}
+	flag := os.O_APPEND
+	if f.truncate {
+		flag = os.O_TRUNC
+	}
+	if err := f.open(flag); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// mkdir 逐级创建不存在的父目录，并保证新建目录的权限不受 umask 影响
+func (f *RotatingFile) mkdir() error {
+	dir := filepath.Dir(f.path)
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("elog: stat log directory: %w", err)
+		}
+		missing = append(missing, d)
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+	if err := os.MkdirAll(dir, f.dirMode); err != nil {
+		return fmt.Errorf("elog: create log directory: %w", err)
+	}
+	for _, d := range missing {
+		if err := os.Chmod(d, f.dirMode); err != nil {
+			return fmt.Errorf("elog: create log directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// open 以 flag 指定的方式（O_APPEND 或 O_TRUNC）打开 f.path
+func (f *RotatingFile) open(flag int) error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|flag, f.mode)
+	if err != nil {
+		return fmt.Errorf("elog: open log file: %w", err)
+	}
+	if err := file.Chmod(f.mode); err != nil {
+		file.Close()
+		return fmt.Errorf("elog: open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("elog: open log file: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write 把 p 写入当前文件，写满时先轮转。轮转失败时返回错误，并在释放锁后报告给诊断 logger（见 Internal），
+// 诊断 logger 写到同一个文件也不会死锁。下一次 Write 重新打开 path 并重试轮转，暂时的失败（如磁盘已满、
+// 文件被其它进程占用）消除后会恢复写入。
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return 0, ErrFileClosed
+	}
+	if err := f.reopen(); err != nil {
+		f.mu.Unlock()
+		internalf(ErrorLevel, "%v", err)
+		return 0, err
+	}
+	if !f.manual && f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			f.mu.Unlock()
+			internalf(ErrorLevel, "%v", err)
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	f.mu.Unlock()
+	return n, err
+}
+
+// reopen 在上一次轮转失败、没有打开的文件时以追加方式重新打开 f.path，调用方需持有锁
+func (f *RotatingFile) reopen() error {
+	if f.file != nil {
+		return nil
+	}
+	return f.open(os.O_APPEND)
+}
+
+// setManualRotation 使 Write 不再自行轮转，由调用方通过 rotateDue 判断后调用 Rotate，见 GzipWriter
+func (f *RotatingFile) setManualRotation() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.manual = true
+}
+
+// rotateDue 报告当前文件是否已经写满，需要轮转
+func (f *RotatingFile) rotateDue() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file != nil && f.maxSize > 0 && f.size >= f.maxSize
+}
+
+// Rotate 立即轮转当前文件
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrFileClosed
+	}
+	if err := f.reopen(); err != nil {
+		return err
+	}
+	return f.rotate()
+}
+
+// rotate 关闭当前文件，把 path.i 重命名为 path.i+1，再打开新的空文件。
+// 失败时 f.file 为 nil，下一次 Write 或 Rotate 重新打开 path 后重试。
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return fmt.Errorf("elog: rotate log file: %w", err)
+	}
+	n := 1
+	for ; f.maxBackups <= 0 || n < f.maxBackups; n++ {
+		if _, err := os.Stat(f.backup(n)); err != nil {
+			break
+		}
+	}
+	if f.maxBackups > 0 && n >= f.maxBackups {
+		n = f.maxBackups
+		if err := os.Remove(f.backup(n)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("elog: rotate log file: %w", err)
+		}
+	}
+	for ; n > 0; n-- {
+		if err := osRename(f.backup(n-1), f.backup(n)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("elog: rotate log file: %w", err)
+		}
+	}
+	return f.open(os.O_TRUNC)
+}
+
+// backup 返回第 n 个旧文件的路径，n 为 0 时即当前文件
+func (f *RotatingFile) backup(n int) string {
+	if n == 0 {
+		return f.path
+	}
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
+
+// Flush 将已写入的内容同步到磁盘
+func (f *RotatingFile) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close 关闭当前文件，之后的 Write 返回 ErrFileClosed
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
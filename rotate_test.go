@@ -0,0 +1,161 @@
+package elog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on windows")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a", "b", "app.log")
+	f, err := NewRotatingFile(path, FileMode(0600), FileDirMode(0700))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+
+	for _, c := range []struct {
+		path string
+		mode os.FileMode
+	}{
+		{path, 0600},
+		{filepath.Dir(path), 0700 | os.ModeDir},
+		{filepath.Join(dir, "a"), 0700 | os.ModeDir},
+	} {
+		info, err := os.Stat(c.path)
+		if err != nil {
+			t.Fatalf("stat %s: %v", c.path, err)
+		}
+		if info.Mode() != c.mode {
+			t.Errorf("%s: want mode %v, got %v", c.path, c.mode, info.Mode())
+		}
+	}
+
+	// 默认权限为 0644
+	g, err := NewRotatingFile(filepath.Join(dir, "default.log"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer g.Close()
+	if info, _ := os.Stat(filepath.Join(dir, "default.log")); info.Mode() != 0644 {
+		t.Errorf("want default mode 0644, got %v", info.Mode())
+	}
+}
+
+func TestRotatingFileAppendTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewRotatingFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	New(InfoLevel, OOutput(f)).Info("new")
+	f.Close()
+	if b, _ := os.ReadFile(path); string(b) != "old\nnew\n" {
+		t.Errorf("should append by default, got %q", b)
+	}
+
+	f, err = NewRotatingFile(path, FileTruncate(true))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	New(InfoLevel, OOutput(f)).Info("fresh")
+	f.Close()
+	if b, _ := os.ReadFile(path); string(b) != "fresh\n" {
+		t.Errorf("should truncate, got %q", b)
+	}
+	if _, err := f.Write([]byte("x")); !errors.Is(err, ErrFileClosed) {
+		t.Errorf("write after Close should fail with ErrFileClosed, got %v", err)
+	}
+}
+
+func TestRotatingFileErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "app.log")
+	if f, err := NewRotatingFile(path, FileCreateDirs(false)); err == nil || f != nil {
+		t.Errorf("missing directory should fail, got %v, %v", f, err)
+	}
+
+	// 父目录位置已经是普通文件
+	parent := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(parent, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := NewRotatingFile(filepath.Join(parent, "app.log")); err == nil || f != nil {
+		t.Errorf("invalid directory should fail, got %v, %v", f, err)
+	}
+}
+
+func TestRotatingFileRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(path, FileMaxSize(8), FileMaxBackups(2))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+	l := New(InfoLevel, OOutput(f))
+	for _, msg := range []string{"line1", "line2", "line3", "line4"} {
+		l.Info(msg)
+	}
+
+	for name, want := range map[string]string{
+		path:        "line4\n",
+		path + ".1": "line3\n",
+		path + ".2": "line2\n",
+	} {
+		if b, err := os.ReadFile(name); err != nil || string(b) != want {
+			t.Errorf("%s: want %q, got %q (%v)", filepath.Base(name), want, b, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("backups beyond the limit should be removed, got %v", err)
+	}
+}
+
+// 重命名失败一次后，下一次写入重新打开 path 并重试轮转
+func TestRotatingFileRenameFailure(t *testing.T) {
+	defer func(rename func(string, string) error) { osRename = rename }(osRename)
+	diag := captureInternal(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(path, FileMaxSize(8))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+	failed := false
+	osRename = func(from, to string) error {
+		if !failed {
+			failed = true
+			return &os.LinkError{Op: "rename", Old: from, New: to, Err: errors.New("injected")}
+		}
+		return os.Rename(from, to)
+	}
+
+	f.Write([]byte("line1\n"))
+	if _, err := f.Write([]byte("lost\n")); err == nil {
+		t.Fatal("write should fail when rotation fails")
+	}
+	if !strings.Contains(diag.String(), "rotate log file: rename") {
+		t.Errorf("the failure should be reported, got %q", diag.String())
+	}
+	if n, err := f.Write([]byte("line2\n")); err != nil || n != 6 {
+		t.Fatalf("write after a failed rotation should recover, got %d, %v", n, err)
+	}
+	for name, want := range map[string]string{
+		path:        "line2\n",
+		path + ".1": "line1\n",
+	} {
+		if b, err := os.ReadFile(name); err != nil || string(b) != want {
+			t.Errorf("%s: want %q, got %q (%v)", filepath.Base(name), want, b, err)
+		}
+	}
+}
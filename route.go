@@ -0,0 +1,90 @@
+package elog
+
+import "io"
+
+// EntryMeta 是 FilterWriter 的断言可以看到的日志信息
+type EntryMeta struct {
+	Level  logLevel
+	Name   string
+	Prefix string
+}
+
+// filterWriter 只在 pred 返回 true 时才把日志写入 w
+type filterWriter struct {
+	w    io.Writer
+	pred func(e EntryMeta) bool
+}
+
+// FilterWriter 返回一个按 pred 决定是否写入的输出，可以用于 OOutput、SetOutput、AddOutput（包括使用 Encoder 的输出），
+// 例如只把名称为 audit 的 logger 的日志写到某个文件：
+//
+//	l.AddOutput(elog.FilterWriter(f, func(e elog.EntryMeta) bool { return e.Name == "audit" }))
+//
+// pred 在持有 logger 锁的情况下对每条日志、每个输出调用一次，必须足够快，且不能再使用同一个 logger 输出日志。
+// 不经过 logger 直接调用返回值的 Write 时不做过滤。Close 时会 Flush/Close 被包装的 w。
+func FilterWriter(w io.Writer, pred func(e EntryMeta) bool) io.Writer {
+	return &filterWriter{w: w, pred: pred}
+}
+
+// LevelWriter 返回只写入等级不低于 min 的日志的输出，例如控制台输出全部日志、文件只记录错误：
+//
+//	l := elog.New(elog.DebugLevel, elog.OOutput(os.Stderr, elog.LevelWriter(f, elog.ErrorLevel)))
+func LevelWriter(w io.Writer, min logLevel) io.Writer {
+	return FilterWriter(w, func(e EntryMeta) bool { return e.Level >= min })
+}
+
+func (f *filterWriter) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+// unwrapFilter 返回 w 包装的 Writer 以及它是否应写入 meta 描述的日志，断言 panic 时视为写入。调用方需持有锁。
+func (l *Log) unwrapFilter(w io.Writer, meta *EntryMeta) (io.Writer, bool) {
+	if f, ok := w.(*filterWriter); ok {
+		ok, err := callPredicate(f.pred, *meta)
+		if err != nil {
+			l.notePanic(err)
+			return f.w, true
+		}
+		return f.w, ok
+	}
+	return w, true
+}
+
+// writeText 把 l.buf 写入所有文本输出。没有 FilterWriter 且不需要区分颜色时整体写入 l.output，
+// 否则逐个输出判断后分别写入。两种方式都会写入每一个输出，一个输出失败不影响其他输出，
+// 返回合并后的各个输出的错误，见 joinErrors。调用方需持有锁。
+func (l *Log) writeText(meta *EntryMeta) error {
+	routed := l.colorForced // 只有 ColorAlways 的输出带颜色，其余输出需要分别写入
+	for _, w := range l.writers {
+		if _, ok := w.(*filterWriter); ok {
+			routed = true
+			break
+		}
+	}
+	if !routed {
+		return l.writeTo(l.output, l.buf)
+	}
+	f, _ := l.output.(*fanout)
+	var errs []error
+	for i, w := range l.writers {
+		w, ok := l.unwrapFilter(w, meta)
+		if !ok {
+			continue
+		}
+		buf := l.buf
+		if c, ok := w.(*colorWriter); l.colorForced && !(ok && c.mode == ColorAlways) {
+			buf = l.plainText()
+		}
+		err := l.writeTo(w, buf)
+		if f != nil {
+			err = f.record(i, err)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return joinErrors(errs)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package elog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFilterWriter(t *testing.T) {
+	skipCompiledOut(t, DebugLevel)
+	var all, errs, audit, auditJSON closeRecorder
+	isAudit := func(e EntryMeta) bool { return e.Name == "audit" }
+	l := New(DebugLevel, OOutput(&all, LevelWriter(&errs, ErrorLevel), FilterWriter(&audit, isAudit)), OFlag(Llevel)).
+		AddOutput(FilterWriter(&auditJSON, isAudit), WithEncoder(JSONEncoder))
+	sec := l.WithName("audit")
+
+	l.Debug("debug")
+	l.Error("error")
+	sec.Info("login")
+	sec.Error("denied")
+
+	if want := "DEBUG debug\nERROR error\nINFO login\nERROR denied\n"; all.String() != want {
+		t.Errorf("all: want %q, got %q", want, all.String())
+	}
+	if want := "ERROR error\nERROR denied\n"; errs.String() != want {
+		t.Errorf("LevelWriter: want %q, got %q", want, errs.String())
+	}
+	if want := "INFO login\nERROR denied\n"; audit.String() != want {
+		t.Errorf("FilterWriter: want %q, got %q", want, audit.String())
+	}
+	lines := strings.Split(strings.TrimSuffix(auditJSON.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("encoded FilterWriter should get 2 lines, got %q", auditJSON.String())
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil || got["logger"] != "audit" || got["msg"] != "login" {
+		t.Errorf("unexpected JSON %q (%v)", lines[0], err)
+	}
+
+	// Close 穿过 FilterWriter 关闭被包装的 Writer，标准错误不会被关闭
+	New(InfoLevel, OOutput(FilterWriter(os.Stderr, isAudit))).Close()
+	if err := l.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for name, w := range map[string]*closeRecorder{"errs": &errs, "audit": &audit, "auditJSON": &auditJSON} {
+		if w.flushed != 1 || w.closed != 1 {
+			t.Errorf("%s: wrapped writer should be flushed and closed once, got %+v", name, w)
+		}
+	}
+}
+
+func TestFilterWriterDirect(t *testing.T) {
+	var buf bytes.Buffer
+	w := FilterWriter(&buf, func(EntryMeta) bool { return false })
+	w.Write([]byte("raw\n"))
+	if buf.String() != "raw\n" {
+		t.Errorf("direct writes should not be filtered, got %q", buf.String())
+	}
+}
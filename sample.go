@@ -0,0 +1,76 @@
+package elog
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sampleFirstLimit 是每分钟内记录的不同消息数的上限，超过后新的消息不再享有“每分钟首次必定输出”的待遇
+const sampleFirstLimit = 1024
+
+// sampler 按等级对日志采样，每个等级一个计数器，第 n 条日志（从 0 开始）在 n*rate 跨过整数时输出，
+// 例如 rate 为 0.1 时输出第 0、10、20……条，结果与并发调度无关，便于测试。
+type sampler struct {
+	mu     sync.Mutex
+	rates  map[logLevel]float64
+	counts map[logLevel]uint64
+	now    func() time.Time
+	minute int64              // seen 对应的分钟
+	seen   map[sampleKey]bool // 本分钟内出现过的消息
+}
+
+type sampleKey struct {
+	level logLevel
+	msg   string
+}
+
+// OSamplePerLevel 按等级设置采样率，rate 为 0.1 表示每 10 条输出 1 条，rate >= 1 或 rates 中没有的等级全部输出，
+// rate <= 0 时只输出下面提到的首次出现的消息。
+//
+// 同一等级、同一消息在每分钟内第一次出现时总是会被输出，不受采样率影响，因此低频的重要消息不会被采样掉；
+// 通过 WithKey 指定了去重键的日志按键而不是消息判断是否首次出现。
+// 被采样掉的日志计入 Suppressed 和 SuppressedByLevel。采样以过滤器的形式实现，审计日志不受影响，
+// 通过 Extend 派生的子 logger 与父 logger 共享采样计数。
+func OSamplePerLevel(rates map[logLevel]float64) LogOption {
+	return func(logger *Log) {
+		s := &sampler{
+			rates:  make(map[logLevel]float64, len(rates)),
+			counts: make(map[logLevel]uint64, len(rates)),
+			seen:   make(map[sampleKey]bool),
+			now:    logger.clock,
+		}
+		for level, rate := range rates {
+			s.rates[level] = rate
+		}
+		logger.filters = append(logger.filters, filter{id: nextFilterID(), fn: s.allow, byKey: true})
+	}
+}
+
+func (s *sampler) allow(level logLevel, msg string) bool {
+	rate, ok := s.rates[level]
+	if !ok || rate >= 1 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.counts[level]
+	s.counts[level] = n + 1
+	sampled := rate > 0 && (n == 0 || math.Floor(float64(n)*rate) != math.Floor(float64(n-1)*rate))
+	first := s.first(level, msg)
+	return sampled || first
+}
+
+// first 报告 msg 是否是本分钟内第一次出现并记录它，调用方需持有 s.mu
+func (s *sampler) first(level logLevel, msg string) bool {
+	if minute := s.now().Unix() / 60; minute != s.minute {
+		s.minute = minute
+		s.seen = make(map[sampleKey]bool)
+	}
+	k := sampleKey{level, msg}
+	if s.seen[k] || len(s.seen) >= sampleFirstLimit {
+		return false
+	}
+	s.seen[k] = true
+	return true
+}
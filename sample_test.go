@@ -0,0 +1,69 @@
+package elog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplePerLevel(t *testing.T) {
+	skipCompiledOut(t, TraceLevel)
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b), OFlag(Llevel), OSamplePerLevel(map[logLevel]float64{
+		InfoLevel:  0.1,
+		TraceLevel: 0.01,
+		ErrorLevel: 1,
+	}))
+	for i := 0; i < 100; i++ {
+		l.Info("tick")
+	}
+	for i := 0; i < 200; i++ {
+		l.Trace("loop")
+	}
+	for i := 0; i < 5; i++ {
+		l.Error("boom")
+		l.Warn("not sampled")
+	}
+
+	counts := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		counts[line]++
+	}
+	want := map[string]int{"INFO tick": 10, "TRACE loop": 2, "ERROR boom": 5, "WARN not sampled": 5}
+	for line, n := range want {
+		if counts[line] != n {
+			t.Errorf("%q: want %d, got %d", line, n, counts[line])
+		}
+	}
+	if got := l.SuppressedByLevel(); !equalCounters(got, map[logLevel]uint64{InfoLevel: 90, TraceLevel: 198}) {
+		t.Errorf("unexpected suppressed counters %v", got)
+	}
+	if l.Suppressed() != 288 {
+		t.Errorf("want 288 suppressed in total, got %d", l.Suppressed())
+	}
+	if l.ResetCounts(); len(l.SuppressedByLevel()) != 0 {
+		t.Errorf("ResetCounts should clear per-level suppressed counters, got %v", l.SuppressedByLevel())
+	}
+}
+
+func TestSampleFirstPerMinute(t *testing.T) {
+	skipCompiledOut(t, DebugLevel)
+	var b bytes.Buffer
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(DebugLevel, OOutput(&b), ONow(func() time.Time { return now }),
+		OSamplePerLevel(map[logLevel]float64{DebugLevel: 0}))
+
+	l.Debug("a")
+	l.Debug("a")
+	l.Debug("b")
+	now = now.Add(30 * time.Second)
+	l.Debug("a")
+	now = now.Add(30 * time.Second) // 进入下一分钟
+	l.Debug("a")
+	l.Debug("a")
+
+	if want := "a\nb\na\n"; b.String() != want {
+		t.Errorf("want %q, got %q", want, b.String())
+	}
+}
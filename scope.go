@@ -0,0 +1,131 @@
+package elog
+
+import (
+	"sync"
+)
+
+// Scope 是 logger 之上附带了一组字段的轻量视图，由 BeginScope 创建，通过它输出的日志都会带上这些字段：
+//
+//	scope := l.BeginScope(elog.String("request_id", id))
+//	defer scope.End()
+//	scope.Info("handling request")
+//
+// 与 Extend 不同，Scope 不复制 logger 的配置，只保存 logger 和字段，创建的代价很低，适合每个请求创建一个；
+// logger 的等级、输出等配置的修改会立即反映到 Scope 上。Scope 可以被多个 goroutine 并发使用。
+type Scope struct {
+	l      *Log
+	fields []Field
+}
+
+var (
+	_ Logger       = &Scope{}
+	_ LevelEnabler = &Scope{}
+)
+
+var scopePool = sync.Pool{
+	New: func() any {
+		return &Scope{fields: make([]Field, 0, 4)}
+	},
+}
+
+// BeginScope 返回一个附带 fields 的 Scope，fields 会被复制。Scope 从对象池中获取，
+// 不再使用时可以调用 End 放回对象池，之后不能再使用它；不调用 End 也不会有问题，只是不能复用。
+func (l *Log) BeginScope(fields ...Field) *Scope {
+	s := scopePool.Get().(*Scope)
+	s.l = l
+	s.fields = append(s.fields[:0], fields...)
+	return s
+}
+
+// End 清空 Scope 并放回对象池，字段切片的容量超过 maxPooledFields 时不放回
+func (s *Scope) End() {
+	if cap(s.fields) > maxPooledFields {
+		s.fields, s.l = nil, nil
+		return
+	}
+	for i := range s.fields {
+		s.fields[i] = Field{} // 不再持有字段值的引用
+	}
+	s.fields = s.fields[:0]
+	s.l = nil
+	scopePool.Put(s)
+}
+
+// Enabled 与所属 logger 的 Enabled 相同
+func (s *Scope) Enabled(level logLevel) bool { return s.l.Enabled(level) }
+
+// Log 返回 Scope 所属的 logger
+func (s *Scope) Log() *Log {
+	return s.l
+}
+
+// out 必须被 Logger 接口的方法直接调用，以保证文件路径指向调用处
+func (s *Scope) out(level logLevel, msg string, extra ...Field) {
+	if !s.l.enabled(level) {
+		return
+	}
+	// 限制容量，中间件追加字段时不会写入 Scope 共享的底层数组
+	fields := s.fields[:len(s.fields):len(s.fields)]
+	if len(extra) > 0 {
+		fields = append(fields, extra...)
+	}
+	s.l.out(defaultCallDepth+1, level, msg, fields)
+}
+
+func (s *Scope) Fatal(v ...any) {
+	s.out(FatalLevel, s.l.sprint(v))
+	if s.l.atLeast(FatalLevel) {
+		s.l.beforeExit()
+		osExit(s.l.exitCode(v))
+	}
+}
+func (s *Scope) Panic(v ...any) {
+	if s.l.atLeast(PanicLevel) {
+		msg := s.l.sprint(v)
+		s.out(PanicLevel, msg)
+		s.l.beforePanic()
+		panic(s.l.panicValue(defaultCallDepth, msg, v))
+	}
+}
+func (s *Scope) Error(v ...any) { s.out(ErrorLevel, s.l.sprint(v)) }
+func (s *Scope) Warn(v ...any)  { s.out(WarnLevel, s.l.sprint(v)) }
+func (s *Scope) Info(v ...any)  { s.out(InfoLevel, s.l.sprint(v)) }
+func (s *Scope) Debug(v ...any) { s.out(DebugLevel, s.l.sprint(v)) }
+func (s *Scope) Trace(v ...any) { s.out(TraceLevel, s.l.sprint(v)) }
+
+func (s *Scope) Fatalf(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	s.out(FatalLevel, msg, fs...)
+	if s.l.atLeast(FatalLevel) {
+		s.l.beforeExit()
+		osExit(s.l.exitCode(v))
+	}
+}
+func (s *Scope) Panicf(format string, v ...any) {
+	if s.l.atLeast(PanicLevel) {
+		msg, fs := sprintf(format, v)
+		s.out(PanicLevel, msg, fs...)
+		s.l.beforePanic()
+		panic(s.l.panicValue(defaultCallDepth, msg, v))
+	}
+}
+func (s *Scope) Errorf(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	s.out(ErrorLevel, msg, fs...)
+}
+func (s *Scope) Warnf(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	s.out(WarnLevel, msg, fs...)
+}
+func (s *Scope) Infof(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	s.out(InfoLevel, msg, fs...)
+}
+func (s *Scope) Debugf(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	s.out(DebugLevel, msg, fs...)
+}
+func (s *Scope) Tracef(format string, v ...any) {
+	msg, fs := sprintf(format, v)
+	s.out(TraceLevel, msg, fs...)
+}
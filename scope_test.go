@@ -0,0 +1,76 @@
+package elog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lshortfile))
+	l.Use(func(e *Entry) bool {
+		e.Fields = append(e.Fields, String("mw", "1"))
+		return true
+	})
+	scope := l.BeginScope(String("request_id", "r-42"), Int("attempt", 2))
+	scope.Info("handling request")
+	scope.Warnf("slow %s", "db")
+	scope.Debug("filtered")
+	l.Info("outside")
+	scope.End()
+
+	want := "scope_test.go:18 handling request request_id=r-42 attempt=2 mw=1\n" +
+		"scope_test.go:19 slow db request_id=r-42 attempt=2 mw=1\n" +
+		"scope_test.go:21 outside mw=1\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestScopeConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&lockedWriter{mu: &mu, w: &b}))
+	l.Use(func(e *Entry) bool {
+		e.Fields = append(e.Fields, String("mw", "1"))
+		return true
+	})
+	scope := l.BeginScope(String("id", "a"))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				scope.Info("x")
+			}
+		}()
+	}
+	wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if n := bytes.Count(b.Bytes(), []byte("x id=a mw=1\n")); n != 400 {
+		t.Errorf("want 400 identical lines, got %d", n)
+	}
+}
+
+func BenchmarkScope(b *testing.B) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := l.BeginScope(String("request_id", "r-42"))
+		s.Info("handling request")
+		s.End()
+	}
+}
+
+func BenchmarkScopeExtend(b *testing.B) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		child := l.Extend(OPrefix("r-42"))
+		child.Info("handling request")
+	}
+}
@@ -0,0 +1,145 @@
+package elog
+
+import (
+	"expvar"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// count 记录一条 level 等级、格式化后长度为 n 字节的日志。
+// 无论有几个 Writer，每条日志只按文本格式的长度（包含头部和换行符）计算一次。
+// SetLevelRange 允许 AuditLevel 以上或负数的等级，这些等级的日志照常输出，但不计数。
+func (l *Log) count(level logLevel, n int) {
+	if level < 0 || level >= logLevel(len(l.counts)) {
+		return
+	}
+	atomic.AddUint64(&l.counts[level], 1)
+	atomic.AddUint64(&l.bytes[level], uint64(n))
+}
+
+// Counts 返回各等级已写入的日志条数，没有写入过的等级不会出现在结果中
+func (l *Log) Counts() map[logLevel]uint64 {
+	return loadCounters(&l.counts)
+}
+
+// BytesWritten 返回各等级已写入的字节数，没有写入过的等级不会出现在结果中。
+// 字节数为格式化后的文本长度，包含头部和换行符；日志同时写入多个 Writer 时只计算一次。
+func (l *Log) BytesWritten() map[logLevel]uint64 {
+	return loadCounters(&l.bytes)
+}
+
+// WriteErrors 返回写入失败的日志条数，一条日志写入多个输出时无论几个失败都只计一次
+func (l *Log) WriteErrors() uint64 {
+	return atomic.LoadUint64(&l.writeErrors)
+}
+
+// ResetCounts 将日志条数、字节数、写入失败和被抑制的条数（包括各等级的）清零
+func (l *Log) ResetCounts() *Log {
+	for i := range l.counts {
+		atomic.StoreUint64(&l.counts[i], 0)
+		atomic.StoreUint64(&l.bytes[i], 0)
+		atomic.StoreUint64(&l.suppressedBy[i], 0)
+	}
+	atomic.StoreUint64(&l.suppressed, 0)
+	atomic.StoreUint64(&l.writeErrors, 0)
+	return l
+}
+
+func loadCounters(c *[AuditLevel + 1]uint64) map[logLevel]uint64 {
+	m := make(map[logLevel]uint64)
+	for i := range c {
+		if n := atomic.LoadUint64(&c[i]); n > 0 {
+			m[logLevel(i)] = n
+		}
+	}
+	return m
+}
+
+// PublishExpvar 以 name 为名通过 expvar 发布 logger 的计数，形如：
+//
+//	{"counts": {"info": 10, "error": 1}, "bytes": {"info": 420, "error": 57}, "bytes_total": 477, "suppressed": 3}
+//
+// 与 expvar.Publish 相同，name 在进程内重复（包括 go test -count=2 时再次发布同一个名字）时会 panic，
+// 每个 logger 应使用不同的名字。
+func (l *Log) PublishExpvar(name string) *Log {
+	expvar.Publish(name, expvar.Func(l.expvarStats))
+	return l
+}
+
+func (l *Log) expvarStats() any {
+	counts := make(map[string]uint64)
+	for level, n := range l.Counts() {
+		counts[levelName(level)] = n
+	}
+	bytes := make(map[string]uint64)
+	var total uint64
+	for level, n := range l.BytesWritten() {
+		bytes[levelName(level)] = n
+		total += n
+	}
+	return map[string]any{
+		"counts":      counts,
+		"bytes":       bytes,
+		"bytes_total": total,
+		"suppressed":  l.Suppressed(),
+	}
+}
+
+// levelName 返回小写的等级名称，如 "info"
+func levelName(level logLevel) string {
+	return strings.ToLower(strings.TrimSpace(levelOf(level).levelLabel))
+}
+
+// Stats 是 logger 计数在某一时刻的快照
+type Stats struct {
+	Counts        map[logLevel]uint64 // 同 Counts
+	Bytes         map[logLevel]uint64 // 同 BytesWritten
+	Suppressed    uint64              // 同 Suppressed
+	WriteErrors   uint64              // 同 WriteErrors
+	ReportDropped uint64              // 同 ReportDropped
+	// 挂在 logger 上的 AsyncWriter（包括通过 FilterWriter 包装的）的队列状态，按输出添加的顺序排列
+	Async []AsyncStats
+}
+
+// Stats 返回 logger 各项计数的快照，l 为 nil 时返回零值
+func (l *Log) Stats() Stats {
+	if l == nil {
+		return Stats{}
+	}
+	s := Stats{
+		Counts:        l.Counts(),
+		Bytes:         l.BytesWritten(),
+		Suppressed:    l.Suppressed(),
+		WriteErrors:   l.WriteErrors(),
+		ReportDropped: l.ReportDropped(),
+	}
+	for _, a := range l.asyncWriters() {
+		s.Async = append(s.Async, a.Stats())
+	}
+	return s
+}
+
+// asyncWriters 返回 logger 所有输出中的 AsyncWriter，同一个 AsyncWriter 只出现一次
+func (l *Log) asyncWriters() []*AsyncWriter {
+	l.mu.RLock()
+	writers := append([]io.Writer(nil), l.writers...)
+	for _, o := range l.encoded {
+		writers = append(writers, o.w)
+	}
+	if l.audit != nil {
+		writers = append(writers, l.audit)
+	}
+	l.mu.RUnlock()
+
+	var found []*AsyncWriter
+	seen := make(map[*AsyncWriter]bool)
+	for _, w := range writers {
+		w = unwrapWriter(w)
+		if a, ok := w.(*AsyncWriter); ok && !seen[a] {
+			seen[a] = true
+			found = append(found, a)
+		}
+	}
+	return found
+}
@@ -0,0 +1,93 @@
+package elog
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCountsAndBytes(t *testing.T) {
+	var a, b, js bytes.Buffer
+	l := New(InfoLevel, OOutput(&a, &b), OFlag(Llevel)).AddOutput(&js, WithEncoder(JSONEncoder))
+	l.Info("hello")
+	l.Info("world")
+	l.Error("boom")
+	l.Debug("dropped")
+	l.SuppressPattern(regexp.MustCompile("noise"))
+	l.Warn("noise")
+	l.Audit("login")
+
+	wantCounts := map[logLevel]uint64{InfoLevel: 2, ErrorLevel: 1, AuditLevel: 1}
+	if got := l.Counts(); !equalCounters(got, wantCounts) {
+		t.Errorf("counts: want %v, got %v", wantCounts, got)
+	}
+	// 多个 Writer 和编码输出只按文本格式计算一次
+	wantBytes := map[logLevel]uint64{
+		InfoLevel:  uint64(len("INFO hello\n") + len("INFO world\n")),
+		ErrorLevel: uint64(len("ERROR boom\n")),
+		AuditLevel: uint64(len("AUDIT login\n")),
+	}
+	if got := l.BytesWritten(); !equalCounters(got, wantBytes) {
+		t.Errorf("bytes: want %v, got %v (output %q)", wantBytes, got, a.String())
+	}
+	var total uint64
+	for _, n := range wantBytes {
+		total += n
+	}
+	if total != uint64(a.Len()) || a.Len() != b.Len() {
+		t.Errorf("bytes should match one writer's output, want %d, got %d and %d", total, a.Len(), b.Len())
+	}
+
+	name := fmt.Sprintf("elog_test_stats_%p", l)
+	l.PublishExpvar(name)
+	var stats struct {
+		Counts     map[string]uint64 `json:"counts"`
+		Bytes      map[string]uint64 `json:"bytes"`
+		BytesTotal uint64            `json:"bytes_total"`
+		Suppressed uint64            `json:"suppressed"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &stats); err != nil {
+		t.Fatalf("invalid expvar JSON: %v", err)
+	}
+	if stats.Counts["info"] != 2 || stats.Bytes["error"] != wantBytes[ErrorLevel] || stats.BytesTotal != total || stats.Suppressed != 1 {
+		t.Errorf("unexpected expvar stats %+v", stats)
+	}
+
+	l.ResetCounts()
+	if len(l.Counts()) != 0 || len(l.BytesWritten()) != 0 || l.Suppressed() != 0 {
+		t.Errorf("ResetCounts should clear all counters, got %v %v %d", l.Counts(), l.BytesWritten(), l.Suppressed())
+	}
+}
+
+func equalCounters(a, b map[logLevel]uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// 超出计数数组的等级照常输出但不计数，不会越界 panic
+func TestCountsOutOfRangeLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(0)).SetLevelRange(-10, 100)
+	l.AddFilter(func(level logLevel, msg string) bool { return !strings.HasPrefix(msg, "drop") })
+	l.Log(100, "high")
+	l.Log(-5, "low")
+	l.Log(100, "drop")
+	l.Log(-5, "drop")
+	if got, want := b.String(), "high\nlow\n"; got != want {
+		t.Errorf("output: got %q, want %q", got, want)
+	}
+	if len(l.Counts()) != 0 || len(l.BytesWritten()) != 0 || l.Suppressed() != 2 {
+		t.Errorf("unexpected counters %v %v %d", l.Counts(), l.BytesWritten(), l.Suppressed())
+	}
+}
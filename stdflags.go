@@ -0,0 +1,50 @@
+package elog
+
+import "log"
+
+// FromStdFlags 把标准库 log 的 flag 转换为输出相同内容的 elog flag。两者的数值部分重合但含义不同：
+//
+//	flag           log   elog
+//	Ldate          1     1
+//	Ltime          2     2
+//	Lmicroseconds  4     4
+//	Llongfile      8     16
+//	Lshortfile     16    32
+//	LUTC           32    8
+//	Lmsgprefix     64    64
+//	LstdFlags      3     Ldate|Ltime|Lshortfile|Llevel
+//
+// 直接把 log.Lshortfile 传给 OFlag 得到的是 Llongfile，log.LUTC 则是 Lshortfile。
+// log 总是输出前缀，不设置 Lmsgprefix 时前缀在行首；elog 只在设置了 Lmsgprefix 时输出前缀，
+// 因此结果总是带有 Lmsgprefix，前缀的位置由 NewFromStdFlags 通过输出顺序还原。
+func FromStdFlags(stdFlags int) int {
+	flag := Lmsgprefix
+	for _, f := range []struct{ std, elog int }{
+		{log.Ldate, Ldate},
+		{log.Ltime, Ltime},
+		{log.Lmicroseconds, Lmicroseconds},
+		{log.Llongfile, Llongfile},
+		{log.Lshortfile, Lshortfile},
+		{log.LUTC, LUTC},
+	} {
+		if stdFlags&f.std != 0 {
+			flag |= f.elog
+		}
+	}
+	return NormalizeFlags(flag)
+}
+
+// NewFromStdFlags 返回与 log.New(os.Stderr, prefix, stdFlags) 格式相同的 logger，等级为 InfoLevel，
+// 前缀通过 OPrefix 设置，便于从标准库 log 迁移：
+//
+//	l := elog.NewFromStdFlags(log.LstdFlags|log.Lshortfile, elog.OPrefix("app: "))
+//	l.Info("started") // app: 2009/11/10 23:00:00 main.go:12 started
+//
+// 唯一的区别是文件路径的行号之后没有 log 输出的冒号。options 在转换得到的设置之后应用，可以覆盖它们。
+func NewFromStdFlags(stdFlags int, options ...LogOption) *Log {
+	defaults := []LogOption{OFlag(FromStdFlags(stdFlags))}
+	if stdFlags&log.Lmsgprefix == 0 {
+		defaults = append(defaults, OOrder(OrderPrefix))
+	}
+	return New(InfoLevel, append(defaults, options...)...)
+}
@@ -0,0 +1,59 @@
+package elog
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+)
+
+func TestStdFlagValues(t *testing.T) {
+	// 数值相同的 flag 含义不同，不能直接传入 log 包的常量
+	if log.Llongfile == Llongfile || log.Lshortfile == Lshortfile || log.LUTC == LUTC {
+		t.Error("file and UTC flags are expected to differ from the standard library")
+	}
+	if log.Lshortfile != Llongfile || log.LUTC != Lshortfile {
+		t.Error("the overlap documented in FromStdFlags has changed")
+	}
+	if log.LstdFlags != LstdlibFlags || LstdFlags == LstdlibFlags {
+		t.Error("LstdlibFlags should equal log.LstdFlags, LstdFlags should not")
+	}
+	for std, want := range map[int]int{
+		0:                                0,
+		log.LstdFlags:                    Ldate | Ltime,
+		log.Lshortfile | log.Llongfile:   Lshortfile,
+		log.Lmicroseconds | log.LUTC:     Ltime | Lmicroseconds | LUTC,
+		log.Llongfile | log.Lmsgprefix:   Llongfile,
+		log.Ldate | log.Ltime | log.LUTC: Ldate | Ltime | LUTC,
+	} {
+		if got := FromStdFlags(std); got != want|Lmsgprefix {
+			t.Errorf("FromStdFlags(%d) = %s, want %s", std, flagNames(got), flagNames(want|Lmsgprefix))
+		}
+	}
+}
+
+func TestNewFromStdFlags(t *testing.T) {
+	digits := regexp.MustCompile(`[0-9]`)
+	for _, std := range []int{
+		0,
+		log.LstdFlags,
+		log.LstdFlags | log.Lmicroseconds | log.LUTC,
+		log.Ldate | log.Lshortfile,
+		log.Ltime | log.Lmsgprefix,
+		log.LstdFlags | log.Lshortfile | log.Lmsgprefix,
+	} {
+		for _, prefix := range []string{"", "app: "} {
+			var want, got bytes.Buffer
+			log.New(&want, prefix, std).Println("hello")
+			NewFromStdFlags(std, OOutput(&got), OPrefix(prefix)).Info("hello")
+			// 时间可能跨秒，只比较格式；log 在行号之后多输出一个冒号
+			w := digits.ReplaceAllString(want.String(), "0")
+			w = regexp.MustCompile(`\.go:0+:`).ReplaceAllString(w, ".go:0")
+			g := digits.ReplaceAllString(got.String(), "0")
+			g = regexp.MustCompile(`\.go:0+`).ReplaceAllString(g, ".go:0")
+			if g != w {
+				t.Errorf("flags %d prefix %q:\n got  %q\n want %q", std, prefix, got.String(), want.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package elog
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// StreamHandler 返回以 Server-Sent Events 推送实时日志的 http.Handler，每条日志是一个事件，内容为文本格式的一行，
+// 消息跨多行时每行各有一个 data: 字段。浏览器中可以直接使用 EventSource 接收：
+//
+//	http.Handle("/logs/live", l.StreamHandler())
+//	// new EventSource("/logs/live?min=warn")
+//
+// 查询参数 min 设置最低等级（如 warn、error），名称无效时返回 400。每个客户端通过 Follow 同样的机制订阅，
+// 日志输出不会因为客户端而阻塞；客户端跟不上时缓存已满，连接会被服务端结束，客户端断开或 logger Close 时同样结束。
+// 处理器不做鉴权，挂载时应放在需要的中间件之后。
+func (l *Log) StreamHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		min := Discard
+		if s := r.URL.Query().Get("min"); s != "" {
+			level, err := ParseLevel(s)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			min = level
+		}
+		f := newFollower(nil, min)
+		if !l.follow(f) {
+			http.Error(w, "logger closed", http.StatusServiceUnavailable)
+			return
+		}
+		defer l.unfollow(f)
+
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no") // 避免 nginx 缓冲
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		var buf []byte
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case p, ok := <-f.ch:
+				if !ok {
+					return
+				}
+				buf = appendEvent(buf[:0], p)
+				if _, err := w.Write(buf); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// appendEvent 把一条日志编码为一个 SSE 事件
+func appendEvent(buf, p []byte) []byte {
+	p = bytes.TrimSuffix(p, []byte{'\n'})
+	for {
+		line := p
+		i := bytes.IndexByte(p, '\n')
+		if i >= 0 {
+			line = p[:i]
+		}
+		buf = append(buf, "data: "...)
+		buf = append(buf, bytes.TrimSuffix(line, []byte{'\r'})...)
+		buf = append(buf, '\n')
+		if i < 0 {
+			break
+		}
+		p = p[i+1:]
+	}
+	return append(buf, '\n')
+}
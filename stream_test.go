@@ -0,0 +1,70 @@
+package elog
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStreamHandler(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard), OFlag(Llevel))
+	srv := httptest.NewServer(l.StreamHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?min=warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	followers := func() int {
+		l.mu.RLock()
+		defer l.mu.RUnlock()
+		return len(l.followers)
+	}
+	waitFor(t, func() bool { return followers() == 1 })
+
+	l.Info("filtered out")
+	l.Warn("disk almost full")
+	l.Error("line one\nline two")
+	r := bufio.NewReader(resp.Body)
+	want := []string{
+		"data: WARN disk almost full\n", "\n",
+		"data: ERROR line one\n", "data: line two\n", "\n",
+	}
+	for _, w := range want {
+		got, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != w {
+			t.Errorf("want %q, got %q", w, got)
+		}
+	}
+
+	resp.Body.Close() // 客户端断开后订阅被移除
+	waitFor(t, func() bool { return followers() == 0 })
+}
+
+func TestStreamHandlerBadLevel(t *testing.T) {
+	l := New(InfoLevel, OOutput(io.Discard))
+	rec := httptest.NewRecorder()
+	l.StreamHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/?min=loud", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("want 400, got %d", rec.Code)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]logLevel{"warn": WarnLevel, "ERROR": ErrorLevel, " Info ": InfoLevel, "audit": AuditLevel} {
+		if got, err := ParseLevel(name); err != nil || got != want {
+			t.Errorf("ParseLevel(%q): want %d, got %d, %v", name, want, got, err)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("unknown names should be rejected")
+	}
+}
@@ -0,0 +1,109 @@
+package elog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TemplateKey 是 EntryBuilder.T 输出模板键时使用的字段名
+const TemplateKey = "template"
+
+var (
+	templatesMu sync.RWMutex
+	templates   map[string]string // 通过 RegisterTemplates 注册的全局模板，写时复制
+)
+
+// RegisterTemplates 注册全局的消息模板，键为事件名，值为 fmt 格式的模板，同名的模板会被覆盖：
+//
+//	elog.RegisterTemplates(map[string]string{
+//		"user.login.failed": "login failed for %s after %d attempts",
+//	})
+//	l.T("user.login.failed", user, attempts)
+//
+// 模板可以使用 %[2]d 这样的显式参数下标，翻译后的模板可以调整参数的顺序或重复使用同一个参数，
+// 调用处不需要修改。单个 logger 可以通过 OTemplates 或 Log.RegisterTemplates 覆盖全局模板。
+func RegisterTemplates(m map[string]string) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+	templates = mergeTemplates(templates, m)
+}
+
+// OTemplates 为 logger 注册消息模板，优先于全局模板，见 RegisterTemplates。Extend 派生的子 logger 继承这些模板。
+func OTemplates(m map[string]string) LogOption {
+	return func(l *Log) {
+		l.templates = mergeTemplates(l.templates, m)
+	}
+}
+
+// RegisterTemplates 为 l 注册消息模板，优先于全局模板，已经派生的子 logger 不受影响
+func (l *Log) RegisterTemplates(m map[string]string) *Log {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.templates = mergeTemplates(l.templates, m)
+	return l
+}
+
+// mergeTemplates 返回合并了 m 的 dst 的副本，Extend 派生的子 logger 和正在读取的调用方拿到的旧 map 不会被修改
+func mergeTemplates(dst, m map[string]string) map[string]string {
+	merged := make(map[string]string, len(dst)+len(m))
+	for k, v := range dst {
+		merged[k] = v
+	}
+	for k, v := range m {
+		merged[k] = v
+	}
+	return merged
+}
+
+// template 查找 key 对应的模板，先查找 l 自身的模板，再查找全局模板。
+// 在 l 的 Writer 中重入时只查找全局模板，这条日志随后会在输出时被丢弃，见 rlock
+func (l *Log) template(key string) (string, bool) {
+	if l.rlock() {
+		tmpl, ok := l.templates[key]
+		l.mu.RUnlock()
+		if ok {
+			return tmpl, true
+		}
+	}
+	templatesMu.RLock()
+	tmpl, ok := templates[key]
+	templatesMu.RUnlock()
+	return tmpl, ok
+}
+
+// render 用 key 对应的模板格式化 v；没有注册模板时输出键和以空格分隔的参数，如 "user.login.failed alice 3"，
+// 与 Info 一样不会丢失信息
+func (l *Log) render(key string, v []any) string {
+	if tmpl, ok := l.template(key); ok {
+		return fmt.Sprintf(tmpl, v...)
+	}
+	var sb strings.Builder
+	sb.WriteString(key)
+	for _, a := range v {
+		sb.WriteByte(' ')
+		fmt.Fprint(&sb, a)
+	}
+	return sb.String()
+}
+
+// T 以 InfoLevel 输出 key 对应模板格式化后的消息，见 RegisterTemplates。
+// 其它等级以及需要同时输出模板键字段时使用 EntryBuilder.T：
+//
+//	l.With().Warn().Str("ip", ip).T("user.login.failed", user, attempts)
+func (l *Log) T(key string, v ...any) {
+	if l.enabled(InfoLevel) {
+		l.Out(defaultCallDepth, InfoLevel, l.render(key, v))
+	}
+}
+
+// T 与 Msgf 一样输出日志，消息由 key 对应的模板格式化，并追加字段 template=key。
+// 无论模板被翻译成哪种语言，看板都可以按这个字段对事件分组。
+func (b *EntryBuilder) T(key string, v ...any) {
+	if b == nil {
+		return
+	}
+	b.check()
+	b.fields = append(b.fields, String(TemplateKey, key))
+	b.emit(b.l.render(key, v))
+}
@@ -0,0 +1,52 @@
+package elog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	defer func(m map[string]string) { templates = m }(templates)
+	RegisterTemplates(map[string]string{
+		"user.login.failed": "login failed for %s after %d attempts",
+		"user.greet":        "hello %s",
+	})
+
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(0))
+	l.T("user.login.failed", "alice", 3)
+	l.T("cache.miss", "users", 42) // 未注册的键
+	l.With().Warn().Str("ip", "10.0.0.1").T("user.login.failed", "bob", 5)
+	l.With().Debug().T("user.login.failed", "hidden", 1)
+	want := "login failed for alice after 3 attempts\n" +
+		"cache.miss users 42\n" +
+		"login failed for bob after 5 attempts ip=10.0.0.1 template=user.login.failed\n"
+	if b.String() != want {
+		t.Errorf("\n got:  %q\n want: %q", b.String(), want)
+	}
+
+	// logger 的模板优先于全局模板，可以调整参数顺序；子 logger 继承模板，之后注册的不影响已经派生的子 logger
+	b.Reset()
+	zh := l.Extend(OTemplates(map[string]string{"user.login.failed": "%[2]d 次尝试后 %[1]s 登录失败"}))
+	child := zh.Extend()
+	zh.RegisterTemplates(map[string]string{"user.greet": "你好 %s"})
+	zh.T("user.login.failed", "alice", 3)
+	zh.T("user.greet", "alice")
+	child.T("user.greet", "alice")
+	l.T("user.login.failed", "alice", 3)
+	want = "3 次尝试后 alice 登录失败\n你好 alice\nhello alice\nlogin failed for alice after 3 attempts\n"
+	if b.String() != want {
+		t.Errorf("\n got:  %q\n want: %q", b.String(), want)
+	}
+}
+
+func TestTemplateJSON(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}), OTemplates(map[string]string{"job.done": "job %s done"}))
+	l.AddOutput(&b, WithEncoder(JSONEncoder))
+	l.With().T("job.done", "backup")
+	if got, want := b.String(), `"msg":"job backup done","template":"job.done"}`+"\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("want suffix %q, got %q", want, got)
+	}
+}
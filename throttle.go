@@ -0,0 +1,93 @@
+package elog
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Throttle 是限制输出频率的 logger 句柄，由 Log.Throttle 创建，每个间隔内最多输出一条日志：
+//
+//	t := l.Throttle(time.Second)
+//	for _, item := range items {
+//		t.Tracef("processing %v", item)
+//	}
+//
+// 间隔内的其余日志被丢弃并计数，下一条被输出的日志末尾会追加 "(suppressed N similar in last 1s)"。
+// 被丢弃的路径只有读取时钟、一次原子读取和一次原子加法，不上锁也不格式化消息，适合放在热循环中；
+// Throttle 可以被多个 goroutine 并发使用，也可以保存下来反复使用，不需要每次循环重新创建。
+// 等级被过滤掉的日志不计入丢弃的条数。
+type Throttle struct {
+	next       int64  // 下一次允许输出的时刻，相对于 start 的纳秒数，原子读写，需要 64 位对齐
+	suppressed uint64 // 上一次输出之后丢弃的条数，原子读写
+
+	l        *Log
+	interval time.Duration
+	now      func() time.Time
+	start    time.Time
+}
+
+// Throttle 返回每 interval 最多输出一条日志的 Throttle，interval <= 0 时不限制。
+// 时间取自 logger 的时钟（见 ONow），在创建时确定，之后对 ONow 的修改不影响已经创建的 Throttle。
+// l 为 nil 时返回的 Throttle 不输出任何日志。
+func (l *Log) Throttle(interval time.Duration) *Throttle {
+	var now func() time.Time
+	if l != nil && l.rlock() {
+		now = l.now
+		l.mu.RUnlock()
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &Throttle{l: l, interval: interval, now: now, start: now()}
+}
+
+// allow 报告现在是否可以输出，可以时一并返回上一次输出之后丢弃的条数
+func (t *Throttle) allow() (suppressed uint64, ok bool) {
+	if t.interval <= 0 {
+		return 0, true
+	}
+	now := int64(t.now().Sub(t.start)) // time.Now 带单调读数，不受系统时钟调整的影响
+	next := atomic.LoadInt64(&t.next)
+	if now < next || !atomic.CompareAndSwapInt64(&t.next, next, now+int64(t.interval)) {
+		atomic.AddUint64(&t.suppressed, 1)
+		return 0, false
+	}
+	return atomic.SwapUint64(&t.suppressed, 0), true
+}
+
+// out 必须被 Throttle 的方法直接调用，以保证文件路径指向调用处。消息只在允许输出时才格式化。
+func (t *Throttle) out(level logLevel, format string, v []any, ln bool) {
+	if !t.l.enabled(level) {
+		return
+	}
+	suppressed, ok := t.allow()
+	if !ok {
+		return
+	}
+	var msg string
+	var fields []Field
+	if ln {
+		msg = t.l.sprint(v)
+	} else {
+		msg, fields = sprintf(format, v)
+	}
+	if suppressed > 0 {
+		msg = strings.TrimSuffix(msg, "\n") + " (suppressed " + strconv.FormatUint(suppressed, 10) +
+			" similar in last " + t.interval.String() + ")"
+	}
+	t.l.out(defaultCallDepth+1, level, msg, fields)
+}
+
+func (t *Throttle) Error(v ...any) { t.out(ErrorLevel, "", v, true) }
+func (t *Throttle) Warn(v ...any)  { t.out(WarnLevel, "", v, true) }
+func (t *Throttle) Info(v ...any)  { t.out(InfoLevel, "", v, true) }
+func (t *Throttle) Debug(v ...any) { t.out(DebugLevel, "", v, true) }
+func (t *Throttle) Trace(v ...any) { t.out(TraceLevel, "", v, true) }
+
+func (t *Throttle) Errorf(format string, v ...any) { t.out(ErrorLevel, format, v, false) }
+func (t *Throttle) Warnf(format string, v ...any)  { t.out(WarnLevel, format, v, false) }
+func (t *Throttle) Infof(format string, v ...any)  { t.out(InfoLevel, format, v, false) }
+func (t *Throttle) Debugf(format string, v ...any) { t.out(DebugLevel, format, v, false) }
+func (t *Throttle) Tracef(format string, v ...any) { t.out(TraceLevel, format, v, false) }
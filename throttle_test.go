@@ -0,0 +1,81 @@
+package elog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	skipCompiledOut(t, TraceLevel)
+	var b bytes.Buffer
+	now := time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+	l := New(TraceLevel, OOutput(&b), OFlag(Lshortfile), ONow(func() time.Time { return now }))
+	th := l.Throttle(time.Second)
+
+	for i := 0; i < 5; i++ {
+		th.Tracef("item %d", i)
+	}
+	now = now.Add(500 * time.Millisecond)
+	th.Trace("item", 5)
+	now = now.Add(500 * time.Millisecond)
+	th.Tracef("item %d", 6)
+	now = now.Add(time.Second)
+	th.Info("item", 7)
+
+	want := "throttle_test.go:19 item 0\n" +
+		"throttle_test.go:24 item 6 (suppressed 5 similar in last 1s)\n" +
+		"throttle_test.go:26 item 7\n"
+	if got := b.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestThrottleLevel(t *testing.T) {
+	var b bytes.Buffer
+	now := time.Now()
+	l := New(InfoLevel, OOutput(&b), OFlag(0), ONow(func() time.Time { return now }))
+	th := l.Throttle(time.Minute)
+	th.Debug("filtered by level")
+	th.Info("first")
+	th.Debug("filtered by level")
+	th.Info("second")
+	now = now.Add(time.Minute)
+	th.Warn("third")
+	if got, want := b.String(), "first\nthird (suppressed 1 similar in last 1m0s)\n"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestThrottleConcurrent(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(0))
+	th := l.Throttle(time.Hour)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				th.Info("hot")
+			}
+		}()
+	}
+	wg.Wait()
+	if got := b.String(); got != "hot\n" || th.suppressed != 799 {
+		t.Errorf("want exactly one entry and 799 suppressed, got %q and %d", got, th.suppressed)
+	}
+}
+
+func BenchmarkThrottleSuppressed(b *testing.B) {
+	l := New(TraceLevel, OOutput(io.Discard))
+	th := l.Throttle(time.Hour)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			th.Tracef("processing %s", "item")
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package elog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// ErrWriteTimeout 表示写入没有在 OWriteTimeout 设置的时间内完成
+var ErrWriteTimeout = errors.New("elog: write timed out")
+
+// WriteTimeoutError 是写入超时时交给错误处理函数的错误，Data 为没有写成功的日志内容，
+// 可以在错误处理函数中把它写到备用的输出。errors.Is(err, ErrWriteTimeout) 为 true。
+type WriteTimeoutError struct {
+	Data []byte
+}
+
+func (e *WriteTimeoutError) Error() string { return ErrWriteTimeout.Error() }
+func (e *WriteTimeoutError) Unwrap() error { return ErrWriteTimeout }
+
+// OWriteTimeout 为每次写入设置超时，超时后 logger 不再等待，把日志交给错误处理函数（见 OErrorHandler）后继续运行，
+// 避免一个卡住的 Writer（如失去响应的 NFS 挂载）让所有输出日志的 goroutine 都阻塞在锁上。
+//
+// 只有一个输出且它实现了 SetWriteDeadline（如 net.Conn）时直接设置写入期限；否则在辅助 goroutine 中写入。
+// 超时的写入仍在进行时，之后的日志直接以超时失败而不再启动新的 goroutine，写入最终完成后 goroutine 随之退出，不会泄漏。
+// d <= 0 时不设置超时。
+func OWriteTimeout(d time.Duration) LogOption {
+	return func(logger *Log) {
+		logger.guard = nil
+		if d > 0 {
+			logger.guard = &writeGuard{timeout: d}
+		}
+	}
+}
+
+type writeGuard struct {
+	timeout time.Duration
+	pending int32 // 非 0 表示有一次超时的写入还没有完成，原子读写
+}
+
+type deadlineWriter interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// writeTo 把 p 写入 w，设置了 OWriteTimeout 时带超时。调用方需持有锁。
+func (l *Log) writeTo(w io.Writer, p []byte) error {
+	w = realStdout(w)
+	g := l.guard
+	if g == nil {
+		_, err := w.Write(p)
+		return err
+	}
+	target := w
+	if w == l.output && len(l.writers) == 1 {
+		target = realStdout(l.writers[0]) // output 总是 fanout，只有一个输出时直接使用它
+	}
+	if dw, ok := target.(deadlineWriter); ok {
+		return g.writeDeadline(dw, target, p)
+	}
+	return g.write(w, p)
+}
+
+func (g *writeGuard) writeDeadline(dw deadlineWriter, w io.Writer, p []byte) error {
+	if err := dw.SetWriteDeadline(time.Now().Add(g.timeout)); err != nil {
+		return g.write(w, p)
+	}
+	_, err := w.Write(p)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return &WriteTimeoutError{Data: append([]byte(nil), p...)}
+	}
+	return err
+}
+
+func (g *writeGuard) write(w io.Writer, p []byte) error {
+	if atomic.LoadInt32(&g.pending) != 0 {
+		return &WriteTimeoutError{Data: append([]byte(nil), p...)}
+	}
+	data := append([]byte(nil), p...) // 写入在另一个 goroutine 中进行，不能继续使用 l.buf
+	done := make(chan error, 1)
+	atomic.StoreInt32(&g.pending, 1)
+	go func() {
+		_, err := w.Write(data)
+		atomic.StoreInt32(&g.pending, 0)
+		done <- err
+	}()
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return &WriteTimeoutError{Data: data}
+	}
+}
@@ -0,0 +1,81 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingWriter 在 release 关闭之前阻塞所有写入
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestWriteTimeout(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	var mu sync.Mutex
+	var failed []string
+	before := runtime.NumGoroutine()
+	l := New(InfoLevel, OOutput(w), OWriteTimeout(20*time.Millisecond), OErrorHandler(func(err error) {
+		var te *WriteTimeoutError
+		if !errors.Is(err, ErrWriteTimeout) || !errors.As(err, &te) {
+			t.Errorf("unexpected error %v", err)
+			return
+		}
+		mu.Lock()
+		failed = append(failed, string(te.Data))
+		mu.Unlock()
+	}))
+
+	l.Info("first")
+	start := time.Now()
+	l.Info("second") // 第一次写入仍卡住，直接失败而不再等待
+	if d := time.Since(start); d >= 20*time.Millisecond {
+		t.Errorf("second write should fail fast, took %v", d)
+	}
+	if len(failed) != 2 || failed[0] != "first\n" || failed[1] != "second\n" {
+		t.Errorf("unexpected failed entries %q", failed)
+	}
+
+	close(w.release)
+	deadline := time.Now().Add(time.Second)
+	for (atomic.LoadInt32(&l.guard.pending) != 0 || runtime.NumGoroutine() > before) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("goroutine leaked: %d before, %d after", before, n)
+	}
+	l.Info("third")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if got, want := w.buf.String(), "first\nthird\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestWriteTimeoutDeadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	var got error
+	l := New(InfoLevel, OOutput(client), OWriteTimeout(10*time.Millisecond), OErrorHandler(func(err error) { got = err }))
+	l.Info("nobody reads")
+	var te *WriteTimeoutError
+	if !errors.As(got, &te) || string(te.Data) != "nobody reads\n" {
+		t.Errorf("expected a WriteTimeoutError, got %v", got)
+	}
+}
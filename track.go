@@ -0,0 +1,97 @@
+package elog
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// TimeTrack 返回一个函数，调用它时以 level 等级记录自 TimeTrack 调用以来经过的时间，常与 defer 搭配使用：
+//
+//	defer l.TimeTrack(DebugLevel, "load config")()
+//
+// 输出形如 "load config took 12.3ms"，文件路径指向调用返回函数的用户函数。
+func (l *Log) TimeTrack(level logLevel, name string) func() {
+	start := time.Now()
+	return func() {
+		if l.enabled(level) {
+			l.Out(defaultCallDepth, level, name+" took "+humanDuration(time.Since(start)))
+		}
+	}
+}
+
+// Timed 以 level 等级记录 fn 的开始、结束和耗时，fn 返回错误时以同一等级记录错误，并原样返回该错误。
+func (l *Log) Timed(level logLevel, name string, fn func() error) error {
+	if !l.enabled(level) {
+		return fn()
+	}
+	l.Out(defaultCallDepth, level, name+" started")
+	start := time.Now()
+	err := fn()
+	d := humanDuration(time.Since(start))
+	if err != nil {
+		l.Out(defaultCallDepth, level, fmt.Sprintf("%s failed after %s: %v", name, d, err))
+		return err
+	}
+	l.Out(defaultCallDepth, level, name+" finished in "+d)
+	return nil
+}
+
+// noop 是等级未开启时 TraceFn 返回的空函数
+func noop() {}
+
+// TraceFn 以 TraceLevel 记录当前函数的进入和退出，函数名通过 runtime 自动获取：
+//
+//	defer l.TraceFn(id, name)()
+//
+// 进入时输出 "→ pkg.Func(id, name)"，退出时输出 "← pkg.Func (took 1.2ms)"。
+// TraceLevel 未开启（或以 elog_notrace 标签编译）时直接返回空函数，不会调用 runtime.Caller。
+func (l *Log) TraceFn(args ...any) func() {
+	if !traceCompiled || !l.enabled(TraceLevel) {
+		return noop
+	}
+	fn := "???"
+	if pc, _, _, ok := runtime.Caller(1); ok {
+		fn = shortFuncName(runtime.FuncForPC(pc).Name())
+	}
+
+	var sb strings.Builder
+	sb.WriteString("→ ")
+	sb.WriteString(fn)
+	sb.WriteByte('(')
+	for i, arg := range args {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprint(&sb, arg)
+	}
+	sb.WriteByte(')')
+	l.Out(defaultCallDepth, TraceLevel, sb.String())
+
+	start := time.Now()
+	return func() {
+		l.Out(defaultCallDepth, TraceLevel, "← "+fn+" (took "+humanDuration(time.Since(start))+")")
+	}
+}
+
+// shortFuncName 去掉函数全名中的包路径，只保留最后一级包名，如 github.com/TCP404/elog.New -> elog.New
+func shortFuncName(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// humanDuration 将时长保留 3 位有效数字，如 12.3ms、1.25s、430µs
+func humanDuration(d time.Duration) string {
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+	unit := time.Duration(1)
+	for limit := time.Duration(1000); abs >= limit && unit < time.Second; limit *= 10 {
+		unit *= 10
+	}
+	return d.Round(unit).String()
+}
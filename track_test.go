@@ -0,0 +1,107 @@
+package elog
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{999, "999ns"},
+		{430 * time.Microsecond, "430µs"},
+		{12345678, "12.3ms"},
+		{1234567890, "1.23s"},
+		{12345678900, "12.3s"},
+		{123456789000, "2m3s"},
+		{-12345678, "-12.3ms"},
+	}
+	for _, tc := range tests {
+		if got := humanDuration(tc.in); got != tc.want {
+			t.Errorf("humanDuration(%d): want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestTimeTrack(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lshortfile))
+	func() {
+		defer l.TimeTrack(InfoLevel, "load config")()
+	}()
+	pattern := `^track_test.go:37 load config took [0-9.]+[nµm]?s\n$`
+	if matched, _ := regexp.MatchString(pattern, b.String()); !matched {
+		t.Errorf("output %q does not match %q", b.String(), pattern)
+	}
+
+	b.Reset()
+	l.TimeTrack(TraceLevel, "ignored")()
+	if b.Len() != 0 {
+		t.Errorf("level below the logger level should not be logged, got %q", b.String())
+	}
+}
+
+func TestTimed(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Lshortfile))
+
+	if err := l.Timed(InfoLevel, "job", func() error { return nil }); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	pattern := `^track_test.go:54 job started\ntrack_test.go:54 job finished in [0-9.]+[nµm]?s\n$`
+	if matched, _ := regexp.MatchString(pattern, b.String()); !matched {
+		t.Errorf("output %q does not match %q", b.String(), pattern)
+	}
+
+	b.Reset()
+	errJob := errors.New("disk full")
+	if err := l.Timed(InfoLevel, "job", func() error { return errJob }); err != errJob {
+		t.Fatalf("Timed should return the error of fn, got %v", err)
+	}
+	pattern = `^track_test.go:64 job started\ntrack_test.go:64 job failed after [0-9.]+[nµm]?s: disk full\n$`
+	if matched, _ := regexp.MatchString(pattern, b.String()); !matched {
+		t.Errorf("output %q does not match %q", b.String(), pattern)
+	}
+
+	b.Reset()
+	called := false
+	l.Timed(DebugLevel, "job", func() error { called = true; return nil })
+	if !called || b.Len() != 0 {
+		t.Errorf("fn should run silently when the level is disabled, called: %v, got %q", called, b.String())
+	}
+}
+
+func tracedFunc(l *Log, id int, name string) {
+	defer l.TraceFn(id, name)()
+}
+
+func TestTraceFn(t *testing.T) {
+	skipCompiledOut(t, TraceLevel)
+	var b bytes.Buffer
+	l := New(TraceLevel, OOutput(&b), OFlag(Lshortfile))
+	tracedFunc(l, 7, "gopher")
+	pattern := `^track_test.go:81 → elog.tracedFunc\(7, gopher\)\ntrack_test.go:82 ← elog.tracedFunc \(took [0-9.]+[nµm]?s\)\n$`
+	if matched, _ := regexp.MatchString(pattern, b.String()); !matched {
+		t.Errorf("output %q does not match %q", b.String(), pattern)
+	}
+
+	b.Reset()
+	l.SetLevel(DebugLevel)
+	tracedFunc(l, 7, "gopher")
+	if b.Len() != 0 {
+		t.Errorf("TraceFn should be a no-op when TraceLevel is disabled, got %q", b.String())
+	}
+}
+
+func BenchmarkTraceFnDisabled(b *testing.B) {
+	l := New(InfoLevel)
+	for i := 0; i < b.N; i++ {
+		l.TraceFn()()
+	}
+}
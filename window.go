@@ -0,0 +1,65 @@
+package elog
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// windowLevel 是 SetWindowLevel 设置的时间窗口，from 和 to 为一天中的第几分钟，创建后不再修改
+type windowLevel struct {
+	from, to int
+	level    logLevel
+}
+
+// SetWindowLevel 在每天 from 到 to 的时间窗口内把最低等级提高到 level，形如 "22:00"、"06:00"，
+// 窗口包含 from 不包含 to，from 晚于 to 时窗口跨越午夜，两者相同时为全天。例如夜间批处理期间只保留 Warn 及以上的日志：
+//
+//	err := l.SetWindowLevel("22:00", "06:00", elog.WarnLevel)
+//
+// 窗口内实际的最低等级取 level 与 SetLevel 设置的等级中较高的一个，审计日志不受影响。
+// 是否在窗口内按日志的时间戳（见 ONow）判断，设置了 LUTC 时按 UTC，否则按时间戳自身的时区，通常为 time.Local。
+// IsEnabled 等不带时间戳的判断不考虑时间窗口。level 不高于 Discard 时移除时间窗口；from 或 to 的格式无效时不做修改，返回错误。
+func (l *Log) SetWindowLevel(from, to string, level logLevel) error {
+	var w *windowLevel
+	if level > Discard {
+		start, err := parseClock(from)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return err
+		}
+		w = &windowLevel{from: start, to: end, level: level}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.window = w
+	return nil
+}
+
+// parseClock 把 "HH:MM" 解析为一天中的第几分钟
+func parseClock(s string) (int, error) {
+	if len(s) == 5 && s[2] == ':' {
+		h, herr := strconv.Atoi(s[:2])
+		m, merr := strconv.Atoi(s[3:])
+		if herr == nil && merr == nil && 0 <= h && h < 24 && 0 <= m && m < 60 {
+			return h*60 + m, nil
+		}
+	}
+	return 0, errors.New("elog: invalid time of day " + strconv.Quote(s) + ", want HH:MM")
+}
+
+// suppresses 报告时间戳为 t 的 level 日志是否因为落在窗口内而被过滤
+func (w *windowLevel) suppresses(level logLevel, t time.Time) bool {
+	if w == nil || level >= w.level || level == AuditLevel {
+		return false
+	}
+	hour, min, _ := t.Clock()
+	m := hour*60 + min
+	if w.from <= w.to {
+		return w.from == w.to || (w.from <= m && m < w.to)
+	}
+	return m >= w.from || m < w.to
+}
@@ -0,0 +1,96 @@
+package elog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWindowLevel(t *testing.T) {
+	skipCompiledOut(t, DebugLevel)
+	var now time.Time
+	at := func(h, m, s int) { now = time.Date(2024, 3, 4, h, m, s, 0, time.UTC) }
+	var buf bytes.Buffer
+	l := New(DebugLevel, OOutput(&buf), OFlag(Ltime|Llevel), ONow(func() time.Time { return now }))
+	if err := l.SetWindowLevel("22:00", "06:00", WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		h, m, s int
+		quiet   bool
+	}{
+		{12, 0, 0, false},
+		{21, 59, 59, false}, // 窗口之前
+		{22, 0, 0, true},    // 包含 from
+		{23, 59, 59, true},
+		{0, 0, 0, true}, // 跨越午夜
+		{5, 59, 59, true},
+		{6, 0, 0, false}, // 不包含 to
+	} {
+		at(tc.h, tc.m, tc.s)
+		buf.Reset()
+		l.Debug("debug")
+		l.Info("info")
+		l.Warn("warn")
+		l.Audit("audit")
+		got := buf.String()
+		want := []string{"DEBUG debug", "INFO info", "WARN warn", "AUDIT audit"}
+		if tc.quiet {
+			want = want[2:]
+		}
+		if lines := strings.Count(got, "\n"); lines != len(want) {
+			t.Errorf("%02d:%02d:%02d: got %q, want %q", tc.h, tc.m, tc.s, got, want)
+			continue
+		}
+		for _, w := range want {
+			if !strings.Contains(got, w) {
+				t.Errorf("%02d:%02d:%02d: got %q, want %q", tc.h, tc.m, tc.s, got, w)
+			}
+		}
+	}
+
+	// 与 SetLevel 取较高的一个：最低等级已经是 Error 时窗口内外都不输出 Warn
+	at(23, 0, 0)
+	buf.Reset()
+	l.SetLevel(ErrorLevel)
+	l.Warn("warn")
+	l.Error("error")
+	if got := buf.String(); got != "23:00:00 ERROR error\n" {
+		t.Errorf("max of levels: got %q", got)
+	}
+
+	// LUTC 按 UTC 判断，否则按时间戳自身的时区
+	l.SetLevel(DebugLevel)
+	now = time.Date(2024, 3, 4, 12, 0, 0, 0, time.FixedZone("", -11*3600)) // UTC 23:00
+	buf.Reset()
+	l.Info("local noon")
+	l.AddFlag(LUTC).Info("utc night")
+	if got := buf.String(); got != "12:00:00 INFO local noon\n" {
+		t.Errorf("location: got %q", got)
+	}
+
+	// 同一时刻表示全天，Discard 移除窗口
+	l.SubFlag(LUTC)
+	_ = l.SetWindowLevel("08:00", "08:00", InfoLevel)
+	buf.Reset()
+	l.Debug("all day")
+	_ = l.SetWindowLevel("", "", Discard)
+	l.Debug("removed")
+	if got := buf.String(); got != "12:00:00 DEBUG removed\n" {
+		t.Errorf("all day / remove: got %q", got)
+	}
+}
+
+func TestWindowLevelInvalid(t *testing.T) {
+	l := New(InfoLevel, OOutput(&bytes.Buffer{}))
+	_ = l.SetWindowLevel("01:00", "02:00", WarnLevel)
+	for _, s := range []string{"", "6:00", "24:00", "12:60", "12-00", "ab:cd", "12:00:00"} {
+		if err := l.SetWindowLevel(s, "06:00", WarnLevel); err == nil {
+			t.Errorf("SetWindowLevel(%q): want error", s)
+		}
+	}
+	if w := l.window; w == nil || w.from != 60 || w.to != 120 {
+		t.Errorf("an invalid window should not replace the existing one: %+v", w)
+	}
+}
@@ -0,0 +1,141 @@
+package elog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// defaultMaxLineSize 是按行拆分模式下不完整行缓冲的默认上限
+const defaultMaxLineSize = 64 * 1024
+
+// LogWriter 是把写入内容转换成日志的 io.WriteCloser，由 WriterLevel 或 CommandWriters 创建。
+//
+// 默认每次 Write 输出一条日志；开启 SplitLines 后写入内容以 '\n' 切分，每一行作为一条日志输出，
+// 不完整的行会被缓存到下一次 Write，或在 Flush/Close 时输出。缓存超过 MaxLineSize 时会被强制输出。
+type LogWriter struct {
+	mu      sync.Mutex
+	l       *Log
+	level   logLevel
+	prefix  string
+	split   bool
+	maxLine int
+	buf     []byte
+}
+
+var _ io.WriteCloser = &LogWriter{}
+
+// WriterOption 用于配置 WriterLevel 创建的 LogWriter
+type WriterOption func(w *LogWriter)
+
+// SplitLines 设置是否按行拆分写入内容
+func SplitLines(split bool) WriterOption {
+	return func(w *LogWriter) {
+		w.split = split
+	}
+}
+
+// MaxLineSize 设置按行拆分模式下不完整行缓冲的上限，n <= 0 时使用默认值 64 KiB
+func MaxLineSize(n int) WriterOption {
+	return func(w *LogWriter) {
+		if n <= 0 {
+			n = defaultMaxLineSize
+		}
+		w.maxLine = n
+	}
+}
+
+// WriterPrefix 设置加在每条日志消息之前的前缀
+func WriterPrefix(prefix string) WriterOption {
+	return func(w *LogWriter) {
+		w.prefix = ""
+		if prefix != "" {
+			w.prefix = prefix + " "
+		}
+	}
+}
+
+// WriterLevel 返回一个以 level 等级记录写入内容的 io.WriteCloser，
+// 可以接到 exec.Cmd 或第三方库需要 io.Writer 的地方。
+func (l *Log) WriterLevel(level logLevel, options ...WriterOption) *LogWriter {
+	w := &LogWriter{l: l, level: level, maxLine: defaultMaxLineSize}
+	for _, opt := range options {
+		opt(w)
+	}
+	return w
+}
+
+// CommandWriters 返回一对按行缓冲的 Writer，可直接赋值给 exec.Cmd 的 Stdout 和 Stderr，
+// 子进程输出的每一行分别以 outLevel 和 errLevel 等级记录，prefix 不为空时会加在每行之前。
+// 子进程结束后应调用 Close 输出最后不完整的一行。
+func (l *Log) CommandWriters(outLevel, errLevel logLevel, prefix ...string) (stdout, stderr *LogWriter) {
+	p := ""
+	if len(prefix) > 0 {
+		p = prefix[0]
+	}
+	stdout = l.WriterLevel(outLevel, SplitLines(true), WriterPrefix(p))
+	stderr = l.WriterLevel(errLevel, SplitLines(true), WriterPrefix(p))
+	return stdout, stderr
+}
+
+func (w *LogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := len(p)
+	if !w.split {
+		w.emit(bytes.TrimSuffix(p, []byte{'\n'}))
+		return n, nil
+	}
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.buffer(p)
+			break
+		}
+		if len(w.buf) > 0 {
+			w.buffer(p[:i])
+			w.emit(w.buf)
+			w.buf = w.buf[:0]
+		} else {
+			w.emit(p[:i])
+		}
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// buffer 缓存不完整的行，缓存达到上限时先输出已缓存的部分
+func (w *LogWriter) buffer(p []byte) {
+	for len(w.buf)+len(p) > w.maxLine {
+		free := w.maxLine - len(w.buf)
+		w.buf = append(w.buf, p[:free]...)
+		w.emit(w.buf)
+		w.buf = w.buf[:0]
+		p = p[free:]
+	}
+	w.buf = append(w.buf, p...)
+}
+
+// Flush 将缓存中不完整的一行作为一条日志输出
+func (w *LogWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = w.buf[:0]
+	}
+	return nil
+}
+
+// Close 等同于 Flush，LogWriter 关闭后仍然可以继续写入
+func (w *LogWriter) Close() error {
+	return w.Flush()
+}
+
+func (w *LogWriter) emit(line []byte) {
+	if !w.l.enabled(w.level) {
+		return
+	}
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	w.l.Out(defaultCallDepth, w.level, w.prefix+string(line))
+}
@@ -0,0 +1,116 @@
+package elog
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+func TestCommandWriters(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Llevel))
+	stdout, stderr := l.CommandWriters(InfoLevel, ErrorLevel, "[git]")
+
+	stdout.Write([]byte("clon"))
+	stderr.Write([]byte("warn"))
+	stdout.Write([]byte("ing into 'elog'...\nremote: Enumer"))
+	stderr.Write([]byte("ing: x\r\n"))
+	stdout.Write([]byte("ating objects\nremote: done"))
+	stdout.Close()
+	stderr.Close()
+
+	want := "INFO [git] cloning into 'elog'...\n" +
+		"ERROR [git] warning: x\n" +
+		"INFO [git] remote: Enumerating objects\n" +
+		"INFO [git] remote: done\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestCommandWritersLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := New(WarnLevel, OOutput(&b))
+	stdout, stderr := l.CommandWriters(InfoLevel, ErrorLevel)
+	stdout.Write([]byte("filtered\n"))
+	stderr.Write([]byte("kept\n"))
+	if got, want := b.String(), "kept\n"; got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestCommandWritersExec(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	cmd := exec.Command(sh, "-c", "printf 'a\\nb'; printf 'c\\n' 1>&2")
+	stdout, stderr := l.CommandWriters(InfoLevel, ErrorLevel)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Close()
+	stderr.Close()
+	if got := b.String(); len(got) != len("a\nb\nc\n") {
+		t.Errorf("expected three entries, got %q", got)
+	}
+}
+
+func TestWriterLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b), OFlag(Llevel))
+	w := l.WriterLevel(WarnLevel)
+	w.Write([]byte("first\nsecond\n"))
+	w.Write([]byte("third"))
+	want := "WARN first\nsecond\nWARN third\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}
+
+func TestWriterLevelSplitLines(t *testing.T) {
+	input := "alpha\nbeta\r\n\ngamma delta\nepsilon"
+	want := "| alpha\n| beta\n|\n| gamma delta\n| epsilon\n"
+	chunkings := map[string][]int{
+		"single write":  {len(input)},
+		"byte by byte":  nil,
+		"split at crlf": {10, 1, len(input) - 11},
+		"uneven":        {3, 7, 1, 1, 13, len(input) - 25},
+	}
+	for name, sizes := range chunkings {
+		var b bytes.Buffer
+		l := New(InfoLevel, OOutput(&b), OFlag(Lmsgprefix), OPrefix("|"))
+		w := l.WriterLevel(InfoLevel, SplitLines(true))
+		if sizes == nil {
+			for i := 0; i < len(input); i++ {
+				sizes = append(sizes, 1)
+			}
+		}
+		rest := input
+		for _, n := range sizes {
+			w.Write([]byte(rest[:n]))
+			rest = rest[n:]
+		}
+		w.Close()
+		if got := b.String(); got != want {
+			t.Errorf("%s:\n got:  %q\n want: %q", name, got, want)
+		}
+	}
+}
+
+func TestWriterLevelMaxLineSize(t *testing.T) {
+	var b bytes.Buffer
+	l := New(InfoLevel, OOutput(&b))
+	w := l.WriterLevel(InfoLevel, SplitLines(true), MaxLineSize(4))
+	w.Write([]byte("abc"))
+	w.Write([]byte("defghij"))
+	w.Write([]byte("kl\nmn"))
+	w.Flush()
+	want := "abcd\nefgh\nijkl\nmn\n"
+	if got := b.String(); got != want {
+		t.Errorf("\n got:  %q\n want: %q", got, want)
+	}
+}